@@ -0,0 +1,192 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+
+	"fastgo/cmd/fg-apiserver/app/options"
+	"fastgo/internal/apiserver/migrations"
+	"fastgo/pkg/migrate"
+	genericoptions "fastgo/pkg/options"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// defaultMigrationsDir 是迁移文件在源码中的存放目录, `migrate create` 在该目录下生成新的迁移文件.
+const defaultMigrationsDir = "internal/apiserver/migrations"
+
+// newMigrateCommand 创建 migrate 子命令, 用于管理数据库迁移.
+func newMigrateCommand(opts *options.ServerOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "migrate",
+		Short:        "Manage database schema migrations",
+		Long:         "Manage database schema migrations. Migrations are embedded in the binary and recorded in the schema_migrations table.",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "Apply all pending migrations",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				migrator, err := newMigrator(opts)
+				if err != nil {
+					return err
+				}
+
+				applied, err := migrator.Up(cmd.Context())
+				for _, m := range applied {
+					fmt.Printf("Applied %06d_%s\n", m.Version, m.Name)
+				}
+				if err == nil && len(applied) == 0 {
+					fmt.Println("No pending migrations")
+				}
+				return err
+			},
+		},
+		&cobra.Command{
+			Use:   "down [N]",
+			Short: "Roll back the last N applied migrations (default 1)",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				steps := 1
+				if len(args) == 1 {
+					n, err := strconv.Atoi(args[0])
+					if err != nil || n < 1 {
+						return fmt.Errorf("invalid number of migrations to roll back: %s", args[0])
+					}
+					steps = n
+				}
+
+				migrator, err := newMigrator(opts)
+				if err != nil {
+					return err
+				}
+
+				rolledBack, err := migrator.Down(cmd.Context(), steps)
+				for _, m := range rolledBack {
+					fmt.Printf("Rolled back %06d_%s\n", m.Version, m.Name)
+				}
+				return err
+			},
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "Show the status of all migrations",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				migrator, err := newMigrator(opts)
+				if err != nil {
+					return err
+				}
+
+				statuses, err := migrator.Status(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+				for _, s := range statuses {
+					state, appliedAt := "pending", "-"
+					if s.Applied {
+						state, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+					}
+					if s.Dirty {
+						state, appliedAt = "dirty", s.AppliedAt.Format("2006-01-02 15:04:05")
+					}
+					fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+				}
+				return w.Flush()
+			},
+		},
+		&cobra.Command{
+			Use:   "force VERSION applied|pending",
+			Short: "Mark a migration as applied or pending and clear its dirty flag after fixing the database manually",
+			Args:  cobra.ExactArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := strconv.ParseInt(args[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid migration version: %s", args[0])
+				}
+				if args[1] != "applied" && args[1] != "pending" {
+					return fmt.Errorf("invalid migration state '%s': must be applied or pending", args[1])
+				}
+
+				migrator, err := newMigrator(opts)
+				if err != nil {
+					return err
+				}
+
+				if err := migrator.Force(cmd.Context(), version, args[1] == "applied"); err != nil {
+					return err
+				}
+				fmt.Printf("Marked %06d as %s\n", version, args[1])
+				return nil
+			},
+		},
+		newMigrateCreateCommand(),
+	)
+
+	return cmd
+}
+
+// newMigrateCreateCommand 创建 `migrate create` 子命令.
+// 该命令在每种数据库驱动对应的目录下生成一对新的空迁移文件, 不需要连接数据库.
+func newMigrateCreateCommand() *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create a new pair of up/down migration files for every database driver",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, driver := range []string{genericoptions.DriverMySQL, genericoptions.DriverSQLite} {
+				files, err := migrate.Create(filepath.Join(dir, driver), args[0])
+				if err != nil {
+					return err
+				}
+				for _, file := range files {
+					fmt.Printf("Created %s\n", file)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", defaultMigrationsDir, "Directory that contains the migration files of every database driver.")
+
+	return cmd
+}
+
+// newMigrator 读取配置并连接数据库, 创建一个 Migrator 实例.
+func newMigrator(opts *options.ServerOptions) (*migrate.Migrator, error) {
+	db, err := newDB(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrations.NewMigrator(db, opts.DBOptions.Driver)
+}
+
+// newDB 读取配置并连接数据库, 供不启动服务器的子命令使用.
+func newDB(opts *options.ServerOptions) (*gorm.DB, error) {
+	// 初始化 slog
+	initLog()
+
+	// 将 viper 中的配置解析到 opts.
+	if err := viper.Unmarshal(opts); err != nil {
+		return nil, err
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	return opts.DBOptions.NewDB(opts.MySQLOptions, opts.SQLiteOptions)
+}
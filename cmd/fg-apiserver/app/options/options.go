@@ -2,6 +2,9 @@ package options
 
 import (
 	"fastgo/internal/apiserver"
+	"fastgo/internal/pkg/lockout"
+	"fastgo/internal/pkg/ratelimit"
+	"fastgo/internal/pkg/revocation"
 	genericoptions "fastgo/pkg/options"
 	"fmt"
 	"net"
@@ -10,19 +13,70 @@ import (
 )
 
 type ServerOptions struct {
-	MySQLOptions *genericoptions.MySQLOptions `json:"mysql" mapstructure:"mysql"`
-	Addr         string                       `json:"addr" mapstructure:"addr"`
+	// DBOptions 定义使用的数据库驱动.
+	DBOptions     *genericoptions.DBOptions     `json:"db" mapstructure:"db"`
+	MySQLOptions  *genericoptions.MySQLOptions  `json:"mysql" mapstructure:"mysql"`
+	SQLiteOptions *genericoptions.SQLiteOptions `json:"sqlite" mapstructure:"sqlite"`
+	Addr          string                        `json:"addr" mapstructure:"addr"`
 	// JWTKey 定义 JWT 密钥.
-	JWTKey string `json:"jwt-key" mapsturcture:"jwt-key"`
+	JWTKey string `json:"jwt-key" mapstructure:"jwt-key"`
+	// JWTOptions 定义非对称 JWT 签名密钥, 配置后使用 RS256/EdDSA 代替 HS256 签发 token.
+	JWTOptions *genericoptions.JWTOptions `json:"jwt" mapstructure:"jwt"`
 	// Expiration 定义 JWT token 的过期时间.
-	Expiration time.Duration `json:"expiration" mapsturcture:"expiration"`
+	Expiration time.Duration `json:"expiration" mapstructure:"expiration"`
+	// RefreshExpiration 定义 refresh token 的过期时间.
+	RefreshExpiration time.Duration `json:"refresh-expiration" mapstructure:"refresh-expiration"`
+	// RevocationBackend 定义 token 吊销列表的存储后端, 支持 memory 和 db.
+	RevocationBackend string `json:"revocation-backend" mapstructure:"revocation-backend"`
+	// TenantOptions 定义多租户相关配置.
+	TenantOptions *genericoptions.TenantOptions `json:"tenant" mapstructure:"tenant"`
+	// TrashRetention 定义回收站的保留时间, 超过保留时间的记录被永久删除, 0 表示不自动清理.
+	TrashRetention time.Duration `json:"trash-retention" mapstructure:"trash-retention"`
+	// TrustedProxies 定义受信任的反向代理 IP 或 CIDR, 默认不信任任何代理.
+	TrustedProxies []string `json:"trusted-proxies" mapstructure:"trusted-proxies"`
+	// AccessLogOptions 定义 HTTP 访问日志相关配置.
+	AccessLogOptions *genericoptions.AccessLogOptions `json:"access-log" mapstructure:"access-log"`
+	// MetricsOptions 定义 Prometheus 监控指标相关配置.
+	MetricsOptions *genericoptions.MetricsOptions `json:"metrics" mapstructure:"metrics"`
+	// TracingOptions 定义 OpenTelemetry 链路追踪相关配置.
+	TracingOptions *genericoptions.TracingOptions `json:"tracing" mapstructure:"tracing"`
+	// RateLimitOptions 定义限流相关配置.
+	RateLimitOptions *genericoptions.RateLimitOptions `json:"rate-limit" mapstructure:"rate-limit"`
+	// LockoutOptions 定义登录的暴力破解防护相关配置.
+	LockoutOptions *genericoptions.LockoutOptions `json:"lockout" mapstructure:"lockout"`
+	// PasswordPolicyOptions 定义密码策略相关配置.
+	PasswordPolicyOptions *genericoptions.PasswordPolicyOptions `json:"password-policy" mapstructure:"password-policy"`
+	// MailerOptions 定义发送邮件相关配置.
+	MailerOptions *genericoptions.MailerOptions `json:"mailer" mapstructure:"mailer"`
+	// EmailOptions 定义邮箱验证和找回密码相关配置.
+	EmailOptions *genericoptions.EmailOptions `json:"email" mapstructure:"email"`
+	// TwoFactorOptions 定义两步验证相关配置.
+	TwoFactorOptions *genericoptions.TwoFactorOptions `json:"two-factor" mapstructure:"two-factor"`
+	// OIDCOptions 定义外部身份提供方登录相关配置.
+	OIDCOptions *genericoptions.OIDCOptions `json:"oidc" mapstructure:"oidc"`
 }
 
 // NewServerOptions 创建带有默认值的 ServerOptions 实例.
 func NewServerOptions() *ServerOptions {
 	return &ServerOptions{
-		MySQLOptions: genericoptions.NewMySQLOptions(),
-		Addr:         "0.0.0.0:6666",
+		DBOptions:             genericoptions.NewDBOptions(),
+		MySQLOptions:          genericoptions.NewMySQLOptions(),
+		SQLiteOptions:         genericoptions.NewSQLiteOptions(),
+		JWTOptions:            genericoptions.NewJWTOptions(),
+		TenantOptions:         genericoptions.NewTenantOptions(),
+		AccessLogOptions:      genericoptions.NewAccessLogOptions(),
+		MetricsOptions:        genericoptions.NewMetricsOptions(),
+		TracingOptions:        genericoptions.NewTracingOptions(),
+		RateLimitOptions:      genericoptions.NewRateLimitOptions(),
+		LockoutOptions:        genericoptions.NewLockoutOptions(),
+		PasswordPolicyOptions: genericoptions.NewPasswordPolicyOptions(),
+		MailerOptions:         genericoptions.NewMailerOptions(),
+		EmailOptions:          genericoptions.NewEmailOptions(),
+		TwoFactorOptions:      genericoptions.NewTwoFactorOptions(),
+		OIDCOptions:           genericoptions.NewOIDCOptions(),
+		Addr:                  "0.0.0.0:6666",
+		RevocationBackend:     revocation.BackendMemory,
+		TrashRetention:        30 * 24 * time.Hour,
 	}
 }
 
@@ -45,16 +99,131 @@ func (o *ServerOptions) Validate() error {
 		return fmt.Errorf("invalid server port: %s", portStr)
 	}
 
-	// 校验MySQL配置
-	if err := o.MySQLOptions.Validate(); err != nil {
+	// 校验 JWT 签名密钥
+	if err := o.JWTOptions.Validate(); err != nil {
 		return err
 	}
+
+	// 校验多租户配置
+	if err := o.TenantOptions.Validate(); err != nil {
+		return err
+	}
+
+	// 校验访问日志配置
+	if err := o.AccessLogOptions.Validate(); err != nil {
+		return err
+	}
+
+	// 校验监控指标配置
+	if err := o.MetricsOptions.Validate(); err != nil {
+		return err
+	}
+
+	// 校验链路追踪配置
+	if err := o.TracingOptions.Validate(); err != nil {
+		return err
+	}
+
+	// 校验限流配置
+	if err := o.RateLimitOptions.Validate(); err != nil {
+		return err
+	}
+	if o.RateLimitOptions.Backend != ratelimit.BackendMemory {
+		return fmt.Errorf("invalid rate limit backend: %s", o.RateLimitOptions.Backend)
+	}
+
+	// 校验登录的暴力破解防护配置
+	if err := o.LockoutOptions.Validate(); err != nil {
+		return err
+	}
+	if o.LockoutOptions.Backend != lockout.BackendMemory {
+		return fmt.Errorf("invalid lockout backend: %s", o.LockoutOptions.Backend)
+	}
+
+	// 校验密码策略配置
+	if err := o.PasswordPolicyOptions.Validate(); err != nil {
+		return err
+	}
+
+	// 校验邮件配置
+	if err := o.MailerOptions.Validate(); err != nil {
+		return err
+	}
+	if err := o.EmailOptions.Validate(); err != nil {
+		return err
+	}
+
+	// 校验两步验证配置
+	if err := o.TwoFactorOptions.Validate(); err != nil {
+		return err
+	}
+
+	// 校验外部身份提供方配置
+	if err := o.OIDCOptions.Validate(); err != nil {
+		return err
+	}
+
+	// 校验 token 吊销列表后端
+	if o.RevocationBackend != revocation.BackendMemory && o.RevocationBackend != revocation.BackendDB {
+		return fmt.Errorf("invalid revocation backend: %s", o.RevocationBackend)
+	}
+
+	// 校验回收站保留时间
+	if o.TrashRetention < 0 {
+		return fmt.Errorf("trash retention cannot be negative")
+	}
+
+	// 校验受信任的反向代理
+	for _, proxy := range o.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("invalid trusted proxy: %s", proxy)
+			}
+		}
+	}
+
+	// 校验数据库驱动
+	if err := o.DBOptions.Validate(); err != nil {
+		return err
+	}
+
+	// 只校验所选驱动对应的数据库配置
+	switch o.DBOptions.Driver {
+	case genericoptions.DriverMySQL:
+		if err := o.MySQLOptions.Validate(); err != nil {
+			return err
+		}
+	case genericoptions.DriverSQLite:
+		if err := o.SQLiteOptions.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (o *ServerOptions) Config() (*apiserver.Config, error) {
 	return &apiserver.Config{
-		MySQLOptions: o.MySQLOptions,
-		Addr:         o.Addr,
+		DBOptions:             o.DBOptions,
+		MySQLOptions:          o.MySQLOptions,
+		SQLiteOptions:         o.SQLiteOptions,
+		Addr:                  o.Addr,
+		JWTKey:                o.JWTKey,
+		JWTOptions:            o.JWTOptions,
+		Expiration:            o.Expiration,
+		RefreshExpiration:     o.RefreshExpiration,
+		RevocationBackend:     o.RevocationBackend,
+		TenantOptions:         o.TenantOptions,
+		TrashRetention:        o.TrashRetention,
+		TrustedProxies:        o.TrustedProxies,
+		AccessLogOptions:      o.AccessLogOptions,
+		MetricsOptions:        o.MetricsOptions,
+		TracingOptions:        o.TracingOptions,
+		RateLimitOptions:      o.RateLimitOptions,
+		LockoutOptions:        o.LockoutOptions,
+		PasswordPolicyOptions: o.PasswordPolicyOptions,
+		MailerOptions:         o.MailerOptions,
+		EmailOptions:          o.EmailOptions,
+		TwoFactorOptions:      o.TwoFactorOptions,
+		OIDCOptions:           o.OIDCOptions,
 	}, nil
 }
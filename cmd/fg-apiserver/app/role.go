@@ -0,0 +1,65 @@
+package app
+
+import (
+	"fmt"
+
+	"fastgo/cmd/fg-apiserver/app/options"
+	"fastgo/internal/apiserver"
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/known"
+	where "fastgo/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+// newSetRoleCommand 创建 set-role 子命令, 直接修改数据库中的用户角色.
+// 系统中还没有管理员时, 只能通过该命令授予第一个管理员角色.
+func newSetRoleCommand(opts *options.ServerOptions) *cobra.Command {
+	var tenantID string
+
+	cmd := &cobra.Command{
+		Use:          "set-role USERNAME ROLE",
+		Short:        "Set the role of a user",
+		Long:         fmt.Sprintf("Set the role of a user, ROLE must be one of: %s, %s. Use it to grant the first admin.", known.RoleAdmin, known.RoleUser),
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			username, role := args[0], args[1]
+			if role != known.RoleAdmin && role != known.RoleUser {
+				return fmt.Errorf("invalid role %s, must be one of: %s, %s", role, known.RoleAdmin, known.RoleUser)
+			}
+
+			db, err := newDB(opts)
+			if err != nil {
+				return err
+			}
+			if err := apiserver.EnableTenant(db, opts.TenantOptions); err != nil {
+				return err
+			}
+
+			// 用户名在租户内唯一, 未指定租户时使用默认租户
+			ctx := cmd.Context()
+			if tenantID != "" {
+				ctx = contextx.WithTenantID(ctx, tenantID)
+			}
+
+			ds := store.NewStore(db)
+			user, err := ds.User().Get(ctx, where.F("username", username))
+			if err != nil {
+				return err
+			}
+
+			user.Role = role
+			if err := ds.User().Update(ctx, user); err != nil {
+				return err
+			}
+
+			fmt.Printf("Set role of %s (%s) in tenant %s to %s\n", user.Username, user.UserID, user.TenantID, role)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tenantID, "tenant", "", "Tenant of the user, defaults to the default tenant in the configuration.")
+
+	return cmd
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"fastgo/cmd/fg-apiserver/app/options"
+	"fastgo/internal/pkg/logx"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"io"
@@ -41,6 +42,11 @@ func NewFastGOCommand() *cobra.Command {
 	// 推荐使用配置文件来配置应用，便于管理配置项
 	cmd.PersistentFlags().StringVarP(&configFile, "config", "c", filePath(), "Path to the fg-apiserver configuration file.")
 
+	// 注册 migrate 子命令, 用于管理数据库迁移
+	cmd.AddCommand(newMigrateCommand(opts))
+	// 注册 set-role 子命令, 用于设置用户角色(例如创建第一个管理员)
+	cmd.AddCommand(newSetRoleCommand(opts))
+
 	return cmd
 }
 
@@ -134,5 +140,6 @@ func initLog() {
 	}
 
 	// 设置全局的日志实例为自定义的日志实例
-	slog.SetDefault(slog.New(handler))
+	// 自动为带有 context 的日志添加请求 ID、用户 ID 和路由
+	slog.SetDefault(slog.New(logx.NewHandler(handler)))
 }
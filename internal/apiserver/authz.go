@@ -0,0 +1,62 @@
+package apiserver
+
+import (
+	"context"
+
+	store2 "fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/authz"
+	"fastgo/internal/pkg/known"
+	where "fastgo/pkg/store"
+)
+
+// 资源名称, 用于访问策略.
+const (
+	resourceUsers = "users"
+	resourcePosts = "posts"
+	// 审计日志只有管理员可以查看
+	resourceAuditLogs = "audit-logs"
+)
+
+// 操作名称, 用于访问策略.
+const (
+	verbCreate         = "create"
+	verbUpdate         = "update"
+	verbDelete         = "delete"
+	verbGet            = "get"
+	verbList           = "list"
+	verbSearch         = "search"
+	verbChangePassword = "change-password"
+	verbUpdateRole     = "update-role"
+	verbListTrash      = "list-trash"
+	verbRestore        = "restore"
+	verbTwoFactor      = "two-factor"
+	verbIdentity       = "identity"
+	// 解除登录锁定只有管理员可以执行
+	verbUnlock = "unlock"
+)
+
+// policy 定义了 fg-apiserver 的访问策略.
+// 普通用户只能操作自己的资源(由 validation 层校验), 管理员可以执行所有操作.
+// 用户的回收站只有管理员可以查看和恢复, 因为被删除的用户无法再登录.
+var policy = []authz.Rule{
+	{Resource: authz.Any, Verb: authz.Any, Roles: []string{known.RoleAdmin}},
+	{Resource: resourceUsers, Verb: verbGet, Roles: []string{known.RoleUser}},
+	{Resource: resourceUsers, Verb: verbUpdate, Roles: []string{known.RoleUser}},
+	{Resource: resourceUsers, Verb: verbDelete, Roles: []string{known.RoleUser}},
+	{Resource: resourceUsers, Verb: verbChangePassword, Roles: []string{known.RoleUser}},
+	{Resource: resourceUsers, Verb: verbTwoFactor, Roles: []string{known.RoleUser}},
+	{Resource: resourceUsers, Verb: verbIdentity, Roles: []string{known.RoleUser}},
+	{Resource: resourcePosts, Verb: authz.Any, Roles: []string{known.RoleUser}},
+}
+
+// newAuthorizer 创建一个 Authorizer, 每次请求从数据库中查询用户的角色.
+func newAuthorizer(store store2.IStore) *authz.Authorizer {
+	resolver := authz.RoleResolverFunc(func(ctx context.Context, userID string) (string, error) {
+		user, err := store.User().Get(ctx, where.F("userID", userID))
+		if err != nil {
+			return "", err
+		}
+		return user.Role, nil
+	})
+	return authz.New(resolver, policy...)
+}
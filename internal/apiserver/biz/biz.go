@@ -1,9 +1,15 @@
 package biz
 
 import (
+	auditlogv1 "fastgo/internal/apiserver/biz/v1/auditlog"
 	postv1 "fastgo/internal/apiserver/biz/v1/post"
 	userv1 "fastgo/internal/apiserver/biz/v1/user"
+	"fastgo/internal/apiserver/pkg/search"
 	"fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/lockout"
+	"fastgo/internal/pkg/mailer"
+	"fastgo/internal/pkg/password"
+	"fastgo/internal/pkg/revocation"
 )
 
 // IBiz 定义了业务层需要实现的方法.
@@ -12,6 +18,8 @@ type IBiz interface {
 	UserV1() userv1.UserBiz
 	// 获取帖子业务接口.
 	PostV1() postv1.PostBiz
+	// 获取审计日志业务接口.
+	AuditLogV1() auditlogv1.AuditLogBiz
 	// 获取帖子业务接口（V2版本）.
 	// PostV2() post.PostBiz
 }
@@ -19,23 +27,38 @@ type IBiz interface {
 // biz 是 IBiz 的一个具体实现
 // BIZ层依赖STORE层的实现, 因此创建IBiz实例时, 要传入IStore类的实例.
 type biz struct {
-	store store.IStore
+	store    store.IStore
+	revoker  revocation.Revoker
+	searcher search.Searcher
+	guard    *lockout.Guard
+	policy   *password.Policy
+	mailer   mailer.Mailer
+	email    userv1.EmailConfig
+	twoFA    userv1.TwoFactorConfig
+	oidc     userv1.OIDCConfig
 }
 
 // 静态校验接口实现
 var _ IBiz = (*biz)(nil)
 
 // NewBiz 创建一个 IBiz 类型的实例.
-func NewBiz(store store.IStore) *biz {
-	return &biz{store: store}
+// revoker 为 token 吊销列表, 用于退出登录; searcher 用于博客全文检索; guard 用于登录的暴力破解防护;
+// policy 为密码策略, 用于校验历史密码; mailer 和 email 用于发送验证邮件和重置密码邮件; twoFA 为两步验证的配置; oidc 为外部身份提供方登录的配置.
+func NewBiz(store store.IStore, revoker revocation.Revoker, searcher search.Searcher, guard *lockout.Guard, policy *password.Policy, mailer mailer.Mailer, email userv1.EmailConfig, twoFA userv1.TwoFactorConfig, oidc userv1.OIDCConfig) *biz {
+	return &biz{store: store, revoker: revoker, searcher: searcher, guard: guard, policy: policy, mailer: mailer, email: email, twoFA: twoFA, oidc: oidc}
 }
 
 // UserV1 返回一个实现了 UserBiz 接口的实例.
 func (b *biz) UserV1() userv1.UserBiz {
-	return userv1.New(b.store)
+	return userv1.New(b.store, b.revoker, b.guard, b.policy, b.mailer, b.email, b.twoFA, b.oidc)
 }
 
 // PostV1 返回一个实现了 PostBiz 接口的实例.
 func (b *biz) PostV1() postv1.PostBiz {
-	return postv1.New(b.store)
+	return postv1.New(b.store, b.searcher)
+}
+
+// AuditLogV1 返回一个实现了 AuditLogBiz 接口的实例.
+func (b *biz) AuditLogV1() auditlogv1.AuditLogBiz {
+	return auditlogv1.New(b.store)
 }
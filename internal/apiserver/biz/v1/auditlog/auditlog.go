@@ -0,0 +1,69 @@
+package auditlog
+
+import (
+	"context"
+	"fastgo/internal/apiserver/pkg/conversion"
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/tracing"
+	where "fastgo/pkg/store"
+
+	apiv1 "fastgo/pkg/api/apiserver/v1"
+)
+
+// AuditLogBiz 定义处理审计日志请求所需的方法.
+// 审计日志由 store 层在写操作时记录, BIZ 层只提供查询.
+type AuditLogBiz interface {
+	List(ctx context.Context, rq *apiv1.ListAuditLogRequest) (*apiv1.ListAuditLogResponse, error)
+
+	AuditLogExpansion
+}
+
+// AuditLogExpansion 定义额外的审计日志操作方法.
+type AuditLogExpansion interface{}
+
+// auditLogBiz 是 AuditLogBiz 接口的实现.
+type auditLogBiz struct {
+	store store.IStore
+}
+
+// 确保 auditLogBiz 实现了 AuditLogBiz 接口.
+var _ AuditLogBiz = (*auditLogBiz)(nil)
+
+// New 创建 auditLogBiz 的实例.
+func New(store store.IStore) *auditLogBiz {
+	return &auditLogBiz{store: store}
+}
+
+// List 按照操作者、资源和时间范围查询当前租户的审计日志.
+func (b *auditLogBiz) List(ctx context.Context, rq *apiv1.ListAuditLogRequest) (*apiv1.ListAuditLogResponse, error) {
+	ctx, span := tracing.Start(ctx, "AuditLogBiz.List")
+	defer span.End()
+
+	whr := where.P(int(rq.Offset), int(rq.Limit))
+	if rq.ActorID != "" {
+		whr.F("actorID", rq.ActorID)
+	}
+	if rq.Resource != "" {
+		whr.F("resource", rq.Resource)
+	}
+	if rq.ResourceID != "" {
+		whr.F("resourceID", rq.ResourceID)
+	}
+	if !rq.Since.IsZero() {
+		whr.Q("createdAt >= ?", rq.Since)
+	}
+	if !rq.Until.IsZero() {
+		whr.Q("createdAt < ?", rq.Until)
+	}
+
+	count, auditLogList, err := b.store.AuditLog().List(ctx, whr)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLogs := make([]*apiv1.AuditLog, 0, len(auditLogList))
+	for _, auditLog := range auditLogList {
+		auditLogs = append(auditLogs, conversion.AuditLogodelToAuditLogV1(auditLog))
+	}
+	return &apiv1.ListAuditLogResponse{TotalCount: count, AuditLogs: auditLogs}, nil
+}
@@ -4,10 +4,16 @@ import (
 	"context"
 	"fastgo/internal/apiserver/model"
 	"fastgo/internal/apiserver/pkg/conversion"
+	"fastgo/internal/apiserver/pkg/search"
 	"fastgo/internal/apiserver/store"
 	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/known"
+	"fastgo/internal/pkg/query"
+	"fastgo/internal/pkg/tracing"
 	where "fastgo/pkg/store"
 	"github.com/jinzhu/copier"
+	"log/slog"
 
 	apiv1 "fastgo/pkg/api/apiserver/v1"
 )
@@ -19,6 +25,9 @@ type PostBiz interface {
 	Delete(ctx context.Context, rq *apiv1.DeletePostRequest) (*apiv1.DeletePostResponse, error)
 	Get(ctx context.Context, rq *apiv1.GetPostRequest) (*apiv1.GetPostResponse, error)
 	List(ctx context.Context, rq *apiv1.ListPostRequest) (*apiv1.ListPostResponse, error)
+	Search(ctx context.Context, rq *apiv1.SearchPostRequest) (*apiv1.SearchPostResponse, error)
+	ListTrash(ctx context.Context, rq *apiv1.ListTrashPostRequest) (*apiv1.ListTrashPostResponse, error)
+	Restore(ctx context.Context, rq *apiv1.RestorePostRequest) (*apiv1.RestorePostResponse, error)
 
 	PostExpansion
 }
@@ -29,20 +38,33 @@ type PostExpansion interface{}
 // PostBiz 接口的实现.
 // BIZ 层依赖 STORE 层, 通过组合方式实现依赖
 type postBiz struct {
-	store store.IStore
+	store    store.IStore
+	searcher search.Searcher
 }
 
-// 创建 postBiz 实例
-func New(store store.IStore) *postBiz {
+// 创建 postBiz 实例, searcher 用于全文检索, 博客变更时同步更新检索索引
+func New(store store.IStore, searcher search.Searcher) *postBiz {
 	return &postBiz{
-		store: store,
+		store:    store,
+		searcher: searcher,
 	}
 }
 
 // 静态检验接口函数都已实现
 var _ PostBiz = (*postBiz)(nil)
 
+// listSchema 为博客列表支持的过滤和排序字段.
+var listSchema = query.NewSchema(&model.Post{},
+	query.Field{Name: "postID", Type: query.String, Ops: []query.Operator{query.Eq, query.Ne}},
+	query.Field{Name: "title", Type: query.String, Ops: []query.Operator{query.Eq, query.Ne, query.Contains}, Sortable: true},
+	query.Field{Name: "createdAt", Type: query.Time, Ops: query.Comparable, Sortable: true},
+	query.Field{Name: "updatedAt", Type: query.Time, Ops: query.Comparable, Sortable: true},
+)
+
 func (p *postBiz) Create(ctx context.Context, rq *apiv1.CreatePostRequest) (*apiv1.CreatePostResponse, error) {
+	ctx, span := tracing.Start(ctx, "PostBiz.Create")
+	defer span.End()
+
 	var postModel model.Post
 	_ = copier.Copy(&postModel, rq)
 	// 从ctx中获取到用户ID
@@ -51,11 +73,15 @@ func (p *postBiz) Create(ctx context.Context, rq *apiv1.CreatePostRequest) (*api
 	if err := p.store.Post().Create(ctx, &postModel); err != nil {
 		return nil, err
 	}
+	p.index(ctx, &postModel)
 
 	return &apiv1.CreatePostResponse{PostID: postModel.PostID}, nil
 }
 
 func (p *postBiz) Update(ctx context.Context, rq *apiv1.UpdatePostRequest) (*apiv1.UpdatePostResponse, error) {
+	ctx, span := tracing.Start(ctx, "PostBiz.Update")
+	defer span.End()
+
 	whr := where.F("userID", contextx.UserID(ctx), "postID", rq.PostID)
 	postModel, err := p.store.Post().Get(ctx, whr)
 	if err != nil {
@@ -68,22 +94,46 @@ func (p *postBiz) Update(ctx context.Context, rq *apiv1.UpdatePostRequest) (*api
 	if rq.Content != nil {
 		postModel.Content = *rq.Content
 	}
+	// 由 store 层的条件更新校验版本号, 版本号过期时返回 ErrVersionConflict
+	if rq.Version != nil {
+		postModel.Version = *rq.Version
+	}
 	if err := p.store.Post().Update(ctx, postModel); err != nil {
 		return nil, err
 	}
+	p.index(ctx, postModel)
 
-	return &apiv1.UpdatePostResponse{}, nil
+	return &apiv1.UpdatePostResponse{Version: postModel.Version}, nil
 }
 
 func (p *postBiz) Delete(ctx context.Context, rq *apiv1.DeletePostRequest) (*apiv1.DeletePostResponse, error) {
+	ctx, span := tracing.Start(ctx, "PostBiz.Delete")
+	defer span.End()
+
 	whr := where.F("userID", contextx.UserID(ctx), "postID", rq.PostIDs)
+	// 只从检索索引中删除当前用户的博客
+	_, postList, err := p.store.Post().List(ctx, where.F("userID", contextx.UserID(ctx), "postID", rq.PostIDs).NoCount())
+	if err != nil {
+		return nil, err
+	}
 	if err := p.store.Post().Delete(ctx, whr); err != nil {
 		return nil, err
 	}
+
+	postIDs := make([]string, 0, len(postList))
+	for _, post := range postList {
+		postIDs = append(postIDs, post.PostID)
+	}
+	if err := p.searcher.Remove(ctx, postIDs...); err != nil {
+		slog.WarnContext(ctx, "Failed to remove posts from search index", "postIDs", postIDs, "err", err)
+	}
 	return &apiv1.DeletePostResponse{}, nil
 }
 
 func (b *postBiz) Get(ctx context.Context, rq *apiv1.GetPostRequest) (*apiv1.GetPostResponse, error) {
+	ctx, span := tracing.Start(ctx, "PostBiz.Get")
+	defer span.End()
+
 	whr := where.F("userID", contextx.UserID(ctx), "postID", rq.PostID)
 	postM, err := b.store.Post().Get(ctx, whr)
 	if err != nil {
@@ -94,21 +144,138 @@ func (b *postBiz) Get(ctx context.Context, rq *apiv1.GetPostRequest) (*apiv1.Get
 }
 
 func (b *postBiz) List(ctx context.Context, rq *apiv1.ListPostRequest) (*apiv1.ListPostResponse, error) {
+	ctx, span := tracing.Start(ctx, "PostBiz.List")
+	defer span.End()
+
 	whr := where.F("userID", contextx.UserID(ctx)).P(int(rq.Offset), int(rq.Limit))
 	if rq.Title != nil {
 		whr = whr.Q("title like ?", "%"+*rq.Title+"%")
 	}
+	if err := listSchema.Apply(whr, rq.Filter, rq.Sort); err != nil {
+		return nil, err
+	}
+	// 指定 pageToken 时按游标分页
+	if err := listSchema.PageToken(whr, rq.PageToken); err != nil {
+		return nil, err
+	}
+	if rq.SkipTotalCount {
+		whr.NoCount()
+	}
+	// 多查询一条记录, 用于判断是否存在下一页
+	limit := whr.Limit
+	if limit > 0 {
+		whr.L(limit + 1)
+	}
 
 	count, postList, err := b.store.Post().List(ctx, whr)
 	if err != nil {
 		return nil, err
 	}
 
+	var nextPageToken string
+	if limit > 0 && len(postList) > limit {
+		postList = postList[:limit]
+		nextPageToken = listSchema.NextPageToken(ctx, whr, postList[limit-1])
+	}
+
 	posts := make([]*apiv1.Post, 0, len(postList))
 	for _, post := range postList {
 		converted := conversion.PostodelToPostV1(post)
 		posts = append(posts, converted)
 	}
 
-	return &apiv1.ListPostResponse{TotalCount: count, Posts: posts}, nil
+	return &apiv1.ListPostResponse{TotalCount: count, Posts: posts, NextPageToken: nextPageToken}, nil
+}
+
+// ListTrash 查询当前用户回收站中的博客列表.
+func (b *postBiz) ListTrash(ctx context.Context, rq *apiv1.ListTrashPostRequest) (*apiv1.ListTrashPostResponse, error) {
+	ctx, span := tracing.Start(ctx, "PostBiz.ListTrash")
+	defer span.End()
+
+	whr := where.F("userID", contextx.UserID(ctx)).P(int(rq.Offset), int(rq.Limit))
+	count, postList, err := b.store.Post().ListTrash(ctx, whr)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]*apiv1.Post, 0, len(postList))
+	for _, post := range postList {
+		posts = append(posts, conversion.PostodelToPostV1(post))
+	}
+	return &apiv1.ListTrashPostResponse{TotalCount: count, Posts: posts}, nil
+}
+
+// Restore 从回收站中恢复当前用户的博客, 并重新加入检索索引.
+func (b *postBiz) Restore(ctx context.Context, rq *apiv1.RestorePostRequest) (*apiv1.RestorePostResponse, error) {
+	ctx, span := tracing.Start(ctx, "PostBiz.Restore")
+	defer span.End()
+
+	whr := where.F("userID", contextx.UserID(ctx), "postID", rq.PostID)
+	restored, err := b.store.Post().Restore(ctx, whr)
+	if err != nil {
+		return nil, err
+	}
+	if restored == 0 {
+		return nil, errorsx.ErrPostNotFound
+	}
+
+	if post, err := b.store.Post().Get(ctx, whr); err == nil {
+		b.index(ctx, post)
+	}
+	return &apiv1.RestorePostResponse{}, nil
+}
+
+// Search 全文检索当前用户的博客, 结果按照相关度降序排列.
+func (b *postBiz) Search(ctx context.Context, rq *apiv1.SearchPostRequest) (*apiv1.SearchPostResponse, error) {
+	ctx, span := tracing.Start(ctx, "PostBiz.Search")
+	defer span.End()
+
+	if rq.Limit <= 0 {
+		rq.Limit = known.DefaultSearchLimit
+	}
+	whr := where.P(int(rq.Offset), int(rq.Limit))
+	result, err := b.searcher.Search(ctx, &search.Query{Text: rq.Q, UserID: contextx.UserID(ctx), Offset: whr.Offset, Limit: whr.Limit})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Hits) == 0 {
+		return &apiv1.SearchPostResponse{TotalCount: result.TotalCount, Results: []*apiv1.PostSearchResult{}}, nil
+	}
+
+	// 检索结果只包含博客 ID, 从数据库中查询博客详情
+	postIDs := make([]string, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		postIDs = append(postIDs, hit.PostID)
+	}
+	_, postList, err := b.store.Post().List(ctx, where.F("userID", contextx.UserID(ctx), "postID", postIDs).NoCount())
+	if err != nil {
+		return nil, err
+	}
+	posts := make(map[string]*model.Post, len(postList))
+	for _, post := range postList {
+		posts[post.PostID] = post
+	}
+
+	results := make([]*apiv1.PostSearchResult, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		post, ok := posts[hit.PostID]
+		if !ok {
+			continue
+		}
+		results = append(results, &apiv1.PostSearchResult{
+			Post:    conversion.PostodelToPostV1(post),
+			Score:   hit.Score,
+			Title:   hit.Title,
+			Snippet: hit.Snippet,
+		})
+	}
+
+	return &apiv1.SearchPostResponse{TotalCount: result.TotalCount, Results: results}, nil
+}
+
+// index 将博客加入检索索引, 索引失败不影响博客的写入, 只记录日志.
+func (b *postBiz) index(ctx context.Context, post *model.Post) {
+	if err := b.searcher.Index(ctx, post); err != nil {
+		slog.WarnContext(ctx, "Failed to index post", "postID", post.PostID, "err", err)
+	}
 }
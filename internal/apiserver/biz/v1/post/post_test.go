@@ -0,0 +1,255 @@
+package post
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"fastgo/internal/apiserver/pkg/search"
+	"fastgo/internal/apiserver/store/fake"
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+
+	apiv1 "fastgo/pkg/api/apiserver/v1"
+)
+
+// newTestBiz 创建一个使用内存 store 和内存检索索引的 postBiz.
+func newTestBiz(t *testing.T) *postBiz {
+	t.Helper()
+
+	ds := fake.NewStore()
+	return New(ds, search.NewMemory(ds))
+}
+
+// createPost 以 ctx 中的用户创建一篇博客, 返回博客 ID.
+func createPost(t *testing.T, b *postBiz, ctx context.Context, title string) string {
+	t.Helper()
+
+	resp, err := b.Create(ctx, &apiv1.CreatePostRequest{Title: title, Content: "content of " + title})
+	if err != nil {
+		t.Fatalf("Create(%s) error = %v", title, err)
+	}
+	return resp.PostID
+}
+
+// wantError 校验 err 与 want 的 Reason 一致, want 为 nil 时校验 err 为 nil.
+func wantError(t *testing.T, err error, want *errorsx.ErrorX) {
+	t.Helper()
+
+	if want == nil {
+		if err != nil {
+			t.Fatalf("error = %v, want nil", err)
+		}
+		return
+	}
+	if got := errorsx.FromError(err); got == nil || got.Reason != want.Reason {
+		t.Fatalf("error = %v, want reason %s", err, want.Reason)
+	}
+}
+
+func TestGet(t *testing.T) {
+	b := newTestBiz(t)
+	alice := contextx.WithUserID(context.Background(), "user-alice")
+	bob := contextx.WithUserID(context.Background(), "user-bob")
+	postID := createPost(t, b, alice, "hello")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		postID  string
+		wantErr *errorsx.ErrorX
+	}{
+		{name: "owner", ctx: alice, postID: postID},
+		{name: "other user", ctx: bob, postID: postID, wantErr: errorsx.ErrPostNotFound},
+		{name: "not found", ctx: alice, postID: "post-missing", wantErr: errorsx.ErrPostNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := b.Get(tt.ctx, &apiv1.GetPostRequest{PostID: tt.postID})
+			wantError(t, err, tt.wantErr)
+			if err == nil && (resp.Post.Title != "hello" || resp.Post.UserID != "user-alice") {
+				t.Errorf("Get() = %+v, want alice's post", resp.Post)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	b := newTestBiz(t)
+	alice := contextx.WithUserID(context.Background(), "user-alice")
+	bob := contextx.WithUserID(context.Background(), "user-bob")
+	postID := createPost(t, b, alice, "hello")
+
+	title, stale := "updated", int64(0)
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		rq      *apiv1.UpdatePostRequest
+		wantErr *errorsx.ErrorX
+	}{
+		{name: "other user", ctx: bob, rq: &apiv1.UpdatePostRequest{PostID: postID, Title: &title}, wantErr: errorsx.ErrPostNotFound},
+		{name: "stale version", ctx: alice, rq: &apiv1.UpdatePostRequest{PostID: postID, Title: &title, Version: &stale}, wantErr: errorsx.ErrVersionConflict},
+		{name: "owner", ctx: alice, rq: &apiv1.UpdatePostRequest{PostID: postID, Title: &title}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := b.Update(tt.ctx, tt.rq)
+			wantError(t, err, tt.wantErr)
+		})
+	}
+
+	resp, err := b.Get(alice, &apiv1.GetPostRequest{PostID: postID})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if resp.Post.Title != title || resp.Post.Version != 2 {
+		t.Errorf("Get() = %+v, want the title updated once", resp.Post)
+	}
+}
+
+func TestList(t *testing.T) {
+	b := newTestBiz(t)
+	alice := contextx.WithUserID(context.Background(), "user-alice")
+	bob := contextx.WithUserID(context.Background(), "user-bob")
+	for _, title := range []string{"go", "rust", "golang", "100% sure"} {
+		createPost(t, b, alice, title)
+	}
+	createPost(t, b, bob, "go")
+
+	title := "go"
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		rq        *apiv1.ListPostRequest
+		want      []string
+		wantCount int64
+		wantErr   *errorsx.ErrorX
+	}{
+		{name: "own posts only", ctx: alice, rq: &apiv1.ListPostRequest{Limit: 10}, want: []string{"100% sure", "golang", "rust", "go"}, wantCount: 4},
+		{name: "title", ctx: alice, rq: &apiv1.ListPostRequest{Limit: 10, Title: &title}, want: []string{"golang", "go"}, wantCount: 2},
+		{name: "filter and sort", ctx: alice, rq: &apiv1.ListPostRequest{Limit: 10, Filter: "title~go", Sort: "title"}, want: []string{"go", "golang"}, wantCount: 2},
+		{name: "literal wildcard", ctx: alice, rq: &apiv1.ListPostRequest{Limit: 10, Filter: "title~%"}, want: []string{"100% sure"}, wantCount: 1},
+		{name: "other user", ctx: bob, rq: &apiv1.ListPostRequest{Limit: 10}, want: []string{"go"}, wantCount: 1},
+		{name: "unsortable field", ctx: alice, rq: &apiv1.ListPostRequest{Limit: 10, Sort: "content"}, wantErr: errorsx.ErrInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := b.List(tt.ctx, tt.rq)
+			wantError(t, err, tt.wantErr)
+			if err != nil {
+				return
+			}
+
+			if resp.TotalCount != tt.wantCount {
+				t.Errorf("TotalCount = %d, want %d", resp.TotalCount, tt.wantCount)
+			}
+			if got := titles(resp.Posts); !slices.Equal(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	b := newTestBiz(t)
+	alice := contextx.WithUserID(context.Background(), "user-alice")
+	bob := contextx.WithUserID(context.Background(), "user-bob")
+	postID := createPost(t, b, alice, "hello")
+
+	// 只能删除自己的博客
+	if _, err := b.Delete(bob, &apiv1.DeletePostRequest{PostIDs: []string{postID}}); err != nil {
+		t.Fatalf("Delete() by other user error = %v", err)
+	}
+	if _, err := b.Get(alice, &apiv1.GetPostRequest{PostID: postID}); err != nil {
+		t.Fatalf("Get() after Delete() by other user error = %v", err)
+	}
+
+	if _, err := b.Delete(alice, &apiv1.DeletePostRequest{PostIDs: []string{postID}}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	_, err := b.Get(alice, &apiv1.GetPostRequest{PostID: postID})
+	wantError(t, err, errorsx.ErrPostNotFound)
+
+	found, err := b.Search(alice, &apiv1.SearchPostRequest{Q: "hello", Limit: 10})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if found.TotalCount != 0 {
+		t.Errorf("Search() after Delete() = %+v, want no hits", found)
+	}
+
+	_, err = b.Restore(bob, &apiv1.RestorePostRequest{PostID: postID})
+	wantError(t, err, errorsx.ErrPostNotFound)
+	if _, err := b.Restore(alice, &apiv1.RestorePostRequest{PostID: postID}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	found, err = b.Search(alice, &apiv1.SearchPostRequest{Q: "hello", Limit: 10})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if found.TotalCount != 1 {
+		t.Errorf("Search() after Restore() = %+v, want one hit", found)
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	// 租户是全局注册的, 使用租户的测试不能并行执行
+	where.RegisterTenant("tenantID", func(ctx context.Context) string {
+		if tenantID := contextx.TenantID(ctx); tenantID != "" {
+			return tenantID
+		}
+		return "default"
+	})
+	t.Cleanup(func() { where.RegisterTenant("", nil) })
+
+	b := newTestBiz(t)
+	// 不同租户中的用户 ID 相同, 博客仍然相互隔离
+	acme := contextx.WithUserID(contextx.WithTenantID(context.Background(), "acme"), "user-alice")
+	umbrella := contextx.WithUserID(contextx.WithTenantID(context.Background(), "umbrella"), "user-alice")
+	postID := createPost(t, b, acme, "hello")
+
+	_, err := b.Get(umbrella, &apiv1.GetPostRequest{PostID: postID})
+	wantError(t, err, errorsx.ErrPostNotFound)
+
+	list, err := b.List(umbrella, &apiv1.ListPostRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if list.TotalCount != 0 {
+		t.Errorf("List() in another tenant = %v, want no posts", titles(list.Posts))
+	}
+
+	found, err := b.Search(umbrella, &apiv1.SearchPostRequest{Q: "hello", Limit: 10})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if found.TotalCount != 0 {
+		t.Errorf("Search() in another tenant = %+v, want no hits", found)
+	}
+
+	title := "hijacked"
+	_, err = b.Update(umbrella, &apiv1.UpdatePostRequest{PostID: postID, Title: &title})
+	wantError(t, err, errorsx.ErrPostNotFound)
+	if _, err := b.Delete(umbrella, &apiv1.DeletePostRequest{PostIDs: []string{postID}}); err != nil {
+		t.Fatalf("Delete() in another tenant error = %v", err)
+	}
+
+	resp, err := b.Get(acme, &apiv1.GetPostRequest{PostID: postID})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if resp.Post.Title != "hello" {
+		t.Errorf("Get() = %+v, want the post untouched by another tenant", resp.Post)
+	}
+}
+
+// titles 返回博客列表中的标题.
+func titles(posts []*apiv1.Post) []string {
+	ret := make([]string, 0, len(posts))
+	for _, post := range posts {
+		ret = append(ret, post.Title)
+	}
+	return ret
+}
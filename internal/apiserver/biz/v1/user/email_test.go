@@ -0,0 +1,106 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/known"
+	where "fastgo/pkg/store"
+	"fastgo/pkg/token"
+
+	apiv1 "fastgo/pkg/api/apiserver/v1"
+)
+
+// actionToken 按照当前的用户状态签发用途为 purpose 的一次性令牌, 与邮件中链接携带的令牌相同.
+func actionToken(t *testing.T, b *userBiz, ctx context.Context, userID string, purpose string) string {
+	t.Helper()
+
+	userModel, err := b.store.User().Get(ctx, where.F("userID", userID))
+	if err != nil {
+		t.Fatalf("User().Get() error = %v", err)
+	}
+	tokenStr, _, err := token.SignAction(purpose, userModel.UserID, userModel.TenantID, actionState(purpose, userModel), time.Hour)
+	if err != nil {
+		t.Fatalf("SignAction() error = %v", err)
+	}
+	return tokenStr
+}
+
+func TestVerifyEmail(t *testing.T) {
+	b, _ := newTestBiz(t)
+	ctx := context.Background()
+	userID := createUser(t, b, ctx, "alice")
+	verify := actionToken(t, b, ctx, userID, known.ActionVerifyEmail)
+	reset := actionToken(t, b, ctx, userID, known.ActionResetPassword)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr *errorsx.ErrorX
+	}{
+		{name: "reset token", token: reset, wantErr: errorsx.ErrVerificationTokenInvalid},
+		{name: "malformed", token: "not-a-token", wantErr: errorsx.ErrVerificationTokenInvalid},
+		{name: "valid", token: verify},
+		// 邮箱已验证, 令牌随即失效
+		{name: "used twice", token: verify, wantErr: errorsx.ErrVerificationTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := b.VerifyEmail(ctx, &apiv1.VerifyEmailRequest{Token: tt.token})
+			wantError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestVerifyEmailAfterEmailChanged(t *testing.T) {
+	b, _ := newTestBiz(t)
+	ctx := context.Background()
+	userID := createUser(t, b, ctx, "alice")
+	verify := actionToken(t, b, ctx, userID, known.ActionVerifyEmail)
+
+	// 修改邮箱后, 发往旧邮箱的令牌失效
+	email := "alice@example.org"
+	if _, err := b.Update(ctx, &apiv1.UpdateUserRequest{UserID: userID, Email: &email}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	_, err := b.VerifyEmail(ctx, &apiv1.VerifyEmailRequest{Token: verify})
+	wantError(t, err, errorsx.ErrVerificationTokenInvalid)
+}
+
+func TestConfirmPasswordReset(t *testing.T) {
+	b, _ := newTestBiz(t)
+	ctx := context.Background()
+	userID := createUser(t, b, ctx, "alice")
+	reset := actionToken(t, b, ctx, userID, known.ActionResetPassword)
+	verify := actionToken(t, b, ctx, userID, known.ActionVerifyEmail)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr *errorsx.ErrorX
+	}{
+		{name: "verification token", token: verify, wantErr: errorsx.ErrPasswordResetTokenInvalid},
+		{name: "valid", token: reset},
+		// 密码已修改, 令牌随即失效
+		{name: "used twice", token: reset, wantErr: errorsx.ErrPasswordResetTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := b.ConfirmPasswordReset(ctx, &apiv1.ConfirmPasswordResetRequest{Token: tt.token, NewPassword: "Passw0rd!1"})
+			wantError(t, err, tt.wantErr)
+		})
+	}
+
+	if _, err := b.Login(ctx, &apiv1.LoginRequest{Username: "alice", Password: "Passw0rd!1"}); err != nil {
+		t.Errorf("Login() with the reset password error = %v", err)
+	}
+	resp, err := b.Get(ctx, &apiv1.GetUserRequest{UserID: userID})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if resp.User.EmailVerifiedAt == nil {
+		t.Error("email is not verified after the password reset")
+	}
+}
@@ -0,0 +1,371 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/pkg/conversion"
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/known"
+	"fastgo/internal/pkg/metrics"
+	"fastgo/internal/pkg/oidc"
+	"fastgo/internal/pkg/tracing"
+	where "fastgo/pkg/store"
+	"fastgo/pkg/token"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	apiv1 "fastgo/pkg/api/apiserver/v1"
+)
+
+// maxUsernameCandidates 为自动创建用户时最多尝试的用户名个数.
+const maxUsernameCandidates = 3
+
+// AuthorizeOIDC 发起使用外部身份提供方登录, 返回身份提供方的授权地址.
+// 返回的 Session 需要保存在浏览器中, 回调时与 state 一起校验, 防止登录 CSRF.
+func (b *userBiz) AuthorizeOIDC(ctx context.Context, rq *apiv1.AuthorizeOIDCRequest) (*apiv1.AuthorizeOIDCResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.AuthorizeOIDC")
+	defer span.End()
+
+	// 登录时令牌的主体为身份提供方名称, 回调时确定登录的用户
+	return b.authorize(ctx, rq.Provider, known.ActionOIDCLogin, rq.Provider)
+}
+
+// LinkIdentity 发起为用户绑定外部身份, 返回身份提供方的授权地址, 回调时将外部身份绑定到该用户.
+func (b *userBiz) LinkIdentity(ctx context.Context, rq *apiv1.LinkIdentityRequest) (*apiv1.AuthorizeOIDCResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.LinkIdentity")
+	defer span.End()
+
+	if _, err := b.store.User().Get(ctx, where.F("userID", rq.UserID)); err != nil {
+		return nil, err
+	}
+	return b.authorize(ctx, rq.Provider, known.ActionOIDCLink, rq.UserID)
+}
+
+// OIDCCallback 处理身份提供方的回调, 使用授权码换取用户的外部身份.
+// 登录时返回身份验证令牌, 用户启用两步验证时返回登录挑战令牌; 绑定外部身份时返回绑定的外部身份.
+func (b *userBiz) OIDCCallback(ctx context.Context, rq *apiv1.OIDCCallbackRequest) (resp *apiv1.OIDCCallbackResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.OIDCCallback")
+	defer span.End()
+
+	provider, ok := b.oidc.Providers[rq.Provider]
+	if !ok {
+		return nil, errorsx.ErrOIDCProviderNotFound
+	}
+	claims, err := b.parseOIDCState(rq)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Tenant != "" {
+		ctx = contextx.WithTenantID(ctx, claims.Tenant)
+	}
+
+	// 记录登录成功和失败的次数, 需要两步验证的登录在 CompleteLogin 中记录最终结果
+	if claims.Purpose == known.ActionOIDCLogin {
+		defer func() {
+			if err == nil && resp.TwoFactorRequired {
+				metrics.ObserveLoginChallenge()
+				return
+			}
+			metrics.ObserveLogin(err)
+		}()
+	}
+
+	// 用户拒绝授权或者身份提供方出错
+	if rq.Error != "" {
+		slog.WarnContext(ctx, "OIDC authorization failed", "provider", rq.Provider, "error", rq.Error)
+		return nil, errorsx.ErrOIDCLoginFailed
+	}
+	verifier, nonce, _ := strings.Cut(rq.Session, ".")
+	identity, err := provider.Exchange(ctx, rq.Code, verifier, nonce)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to exchange OIDC authorization code", "provider", rq.Provider, "err", err)
+		return nil, errorsx.ErrOIDCLoginFailed
+	}
+
+	if claims.Purpose == known.ActionOIDCLink {
+		identityModel, err := b.linkIdentity(ctx, claims.Subject, rq.Provider, identity)
+		if err != nil {
+			return nil, err
+		}
+		return &apiv1.OIDCCallbackResponse{Identity: conversion.ExternalIdentityodelToExternalIdentityV1(identityModel)}, nil
+	}
+
+	loginResp, err := b.oidcLogin(ctx, rq.Provider, provider, identity)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.OIDCCallbackResponse{LoginResponse: *loginResp}, nil
+}
+
+// ListIdentities 返回用户绑定的外部身份.
+func (b *userBiz) ListIdentities(ctx context.Context, rq *apiv1.ListIdentitiesRequest) (*apiv1.ListIdentitiesResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.ListIdentities")
+	defer span.End()
+
+	count, identityList, err := b.store.ExternalIdentity().List(ctx, where.F("userID", rq.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	identities := make([]*apiv1.ExternalIdentity, 0, len(identityList))
+	for _, identity := range identityList {
+		identities = append(identities, conversion.ExternalIdentityodelToExternalIdentityV1(identity))
+	}
+	return &apiv1.ListIdentitiesResponse{TotalCount: count, Identities: identities}, nil
+}
+
+// UnlinkIdentity 解除用户与身份提供方中外部身份的绑定.
+// 自动创建的用户没有可用的密码, 解除全部绑定后需要通过找回密码设置密码才能登录.
+func (b *userBiz) UnlinkIdentity(ctx context.Context, rq *apiv1.UnlinkIdentityRequest) (*apiv1.UnlinkIdentityResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.UnlinkIdentity")
+	defer span.End()
+
+	whr := where.F("userID", rq.UserID, "provider", rq.Provider)
+	if _, err := b.store.ExternalIdentity().Get(ctx, whr); err != nil {
+		return nil, err
+	}
+	if err := b.store.ExternalIdentity().Delete(ctx, whr); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "External identity unlinked", "userID", rq.UserID, "provider", rq.Provider)
+
+	return &apiv1.UnlinkIdentityResponse{}, nil
+}
+
+// authorize 生成 PKCE 校验码和 nonce, 返回身份提供方的授权地址.
+// state 为用途为 purpose 的一次性令牌, 与身份提供方名称和会话信息绑定, 回调时只接受同一浏览器中发起的授权.
+func (b *userBiz) authorize(ctx context.Context, name string, purpose string, subject string) (*apiv1.AuthorizeOIDCResponse, error) {
+	provider, ok := b.oidc.Providers[name]
+	if !ok {
+		return nil, errorsx.ErrOIDCProviderNotFound
+	}
+
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate PKCE verifier", "err", err)
+		return nil, errorsx.ErrInternal
+	}
+	nonce, err := oidc.NewNonce()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate OIDC nonce", "err", err)
+		return nil, errorsx.ErrInternal
+	}
+	session := verifier + "." + nonce
+
+	state, _, err := token.SignAction(purpose, subject, contextx.TenantID(ctx), oidcState(name, session), b.oidc.StateExpiration)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to sign OIDC state", "err", err)
+		return nil, errorsx.ErrSignToken
+	}
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.Challenge(verifier))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to build OIDC authorization url", "provider", name, "err", err)
+		return nil, errorsx.ErrInternal
+	}
+	return &apiv1.AuthorizeOIDCResponse{AuthorizationURL: authURL, Session: session}, nil
+}
+
+// parseOIDCState 校验回调中的 state, state 可以是登录或者绑定外部身份的令牌.
+func (b *userBiz) parseOIDCState(rq *apiv1.OIDCCallbackRequest) (*token.ActionClaims, error) {
+	for _, purpose := range []string{known.ActionOIDCLogin, known.ActionOIDCLink} {
+		claims, err := token.ParseAction(rq.State, purpose)
+		if err != nil {
+			continue
+		}
+		if !claims.Matches(oidcState(rq.Provider, rq.Session)) {
+			break
+		}
+		return claims, nil
+	}
+	return nil, errorsx.ErrOIDCStateInvalid
+}
+
+// oidcLogin 使用外部身份登录. 外部身份没有绑定用户时, 身份提供方开启了自动创建用户则创建用户并绑定.
+func (b *userBiz) oidcLogin(ctx context.Context, name string, provider *OIDCProvider, identity *oidc.Identity) (*apiv1.LoginResponse, error) {
+	identityModel, err := b.store.ExternalIdentity().Get(ctx, where.F("provider", name, "subject", identity.Subject))
+	var userModel *model.User
+	switch {
+	case err == nil:
+		userModel, err = b.store.User().Get(ctx, where.F("userID", identityModel.UserID))
+		if err != nil {
+			// 绑定的用户已被删除
+			if errors.Is(err, errorsx.ErrUserNotFound) {
+				slog.WarnContext(ctx, "OIDC login rejected, linked user not found", "provider", name, "userID", identityModel.UserID)
+				return nil, errorsx.ErrOIDCLoginFailed
+			}
+			return nil, err
+		}
+	case errors.Is(err, errorsx.ErrExternalIdentityNotFound):
+		if !provider.AutoProvision {
+			slog.WarnContext(ctx, "OIDC login rejected, external identity is not linked", "provider", name, "subject", identity.Subject)
+			return nil, errorsx.ErrExternalIdentityNotLinked
+		}
+		userModel, identityModel, err = b.provisionUser(ctx, name, identity)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	now := time.Now()
+	identityModel.LastLoginAt = &now
+	identityModel.Email = identity.Email
+	if err := b.store.ExternalIdentity().Update(ctx, identityModel); err != nil {
+		return nil, err
+	}
+
+	// 要求验证邮箱时, 未验证邮箱的用户不能登录
+	if b.email.RequireVerified && userModel.EmailVerifiedAt == nil {
+		slog.WarnContext(ctx, "Login rejected, email is not verified", "username", userModel.Username)
+		return nil, errorsx.ErrEmailNotVerified
+	}
+	// 身份提供方不一定要求两步验证, 用户启用两步验证时同样需要提交验证码
+	tf, err := b.enabledTwoFactor(ctx, userModel.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if tf != nil {
+		return b.loginChallenge(ctx, userModel, tf)
+	}
+	return b.issueTokens(ctx, userModel)
+}
+
+// provisionUser 为没有绑定用户的外部身份创建用户并绑定.
+// 用户名优先使用身份提供方返回的 preferred_username 或者邮箱前缀, 已被使用时追加随机后缀.
+// 用户的密码为随机密码, 需要通过找回密码设置密码后才能使用密码登录.
+func (b *userBiz) provisionUser(ctx context.Context, name string, identity *oidc.Identity) (*model.User, *model.ExternalIdentity, error) {
+	userModel := &model.User{
+		Password: uuid.New().String(),
+		Nickname: truncate(identity.Name, 30),
+		Email:    identity.Email,
+	}
+	// 身份提供方已验证的邮箱视为已验证
+	if identity.EmailVerified && identity.Email != "" {
+		now := time.Now()
+		userModel.EmailVerifiedAt = &now
+	}
+	identityModel := &model.ExternalIdentity{Provider: name, Subject: identity.Subject, Email: identity.Email}
+
+	err := b.store.TX(ctx, func(ctx context.Context) error {
+		username, err := b.availableUsername(ctx, identity)
+		if err != nil {
+			return err
+		}
+		userModel.Username = username
+		if err := b.store.User().Create(ctx, userModel); err != nil {
+			return err
+		}
+		identityModel.UserID = userModel.UserID
+		return b.store.ExternalIdentity().Create(ctx, identityModel)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	slog.InfoContext(ctx, "User provisioned from external identity", "userID", userModel.UserID, "username", userModel.Username, "provider", name)
+
+	return userModel, identityModel, nil
+}
+
+// availableUsername 返回一个未被使用的用户名, 回收站中的用户名同样视为已被使用.
+func (b *userBiz) availableUsername(ctx context.Context, identity *oidc.Identity) (string, error) {
+	base := usernameBase(identity)
+	for i := range maxUsernameCandidates {
+		username := base
+		if i > 0 {
+			username = truncate(base, 25) + "_" + uuid.New().String()[:6]
+		}
+
+		_, err := b.store.User().Get(ctx, where.F("username", username))
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, errorsx.ErrUserNotFound) {
+			return "", err
+		}
+		count, _, err := b.store.User().ListTrash(ctx, where.F("username", username))
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return username, nil
+		}
+	}
+	return "", errorsx.ErrUserAlreadyExists
+}
+
+// linkIdentity 将外部身份绑定到用户. 外部身份已绑定其他用户, 或者用户已绑定该身份提供方中的其他身份时返回 ErrExternalIdentityAlreadyLinked.
+func (b *userBiz) linkIdentity(ctx context.Context, userID string, name string, identity *oidc.Identity) (*model.ExternalIdentity, error) {
+	if _, err := b.store.User().Get(ctx, where.F("userID", userID)); err != nil {
+		if errors.Is(err, errorsx.ErrUserNotFound) {
+			return nil, errorsx.ErrOIDCStateInvalid
+		}
+		return nil, err
+	}
+
+	existing, err := b.store.ExternalIdentity().Get(ctx, where.F("provider", name, "subject", identity.Subject))
+	if err == nil {
+		// 重复绑定同一个外部身份
+		if existing.UserID == userID {
+			return existing, nil
+		}
+		slog.WarnContext(ctx, "External identity is linked to another user", "provider", name, "userID", userID)
+		return nil, errorsx.ErrExternalIdentityAlreadyLinked
+	}
+	if !errors.Is(err, errorsx.ErrExternalIdentityNotFound) {
+		return nil, err
+	}
+	if _, err := b.store.ExternalIdentity().Get(ctx, where.F("userID", userID, "provider", name)); err == nil {
+		return nil, errorsx.ErrExternalIdentityAlreadyLinked
+	} else if !errors.Is(err, errorsx.ErrExternalIdentityNotFound) {
+		return nil, err
+	}
+
+	identityModel := &model.ExternalIdentity{UserID: userID, Provider: name, Subject: identity.Subject, Email: identity.Email}
+	if err := b.store.ExternalIdentity().Create(ctx, identityModel); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "External identity linked", "userID", userID, "provider", name)
+
+	return identityModel, nil
+}
+
+// oidcState 返回 state 令牌绑定的状态, 令牌只能用于 name 对应的身份提供方和发起授权的浏览器.
+func oidcState(name string, session string) string {
+	return name + "\x00" + session
+}
+
+// usernameBase 根据外部身份生成用户名, 只保留字母、数字、下划线、连字符和点, 不足 4 个字符时使用默认前缀.
+func usernameBase(identity *oidc.Identity) string {
+	candidate := identity.PreferredUsername
+	if candidate == "" {
+		candidate, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	var sb strings.Builder
+	for _, r := range strings.ToLower(candidate) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-.", r)) {
+			sb.WriteRune(r)
+		}
+	}
+	username := truncate(sb.String(), 32)
+	if len(username) < 4 {
+		username = "user" + username
+	}
+	return username
+}
+
+// truncate 将 s 截断为最多 n 个字符.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
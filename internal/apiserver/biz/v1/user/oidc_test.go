@@ -0,0 +1,298 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/oidc"
+	"fastgo/internal/pkg/oidc/oidctest"
+	where "fastgo/pkg/store"
+
+	apiv1 "fastgo/pkg/api/apiserver/v1"
+)
+
+// newOIDCTestBiz 创建一个使用模拟身份提供方 idp 登录的 userBiz, 身份提供方名称为 test.
+func newOIDCTestBiz(t *testing.T, autoProvision bool) (*userBiz, *oidctest.Server) {
+	t.Helper()
+
+	idp := oidctest.NewServer("fastgo", "secret")
+	t.Cleanup(idp.Close)
+	provider, err := oidc.NewProvider(oidc.Config{Issuer: idp.Issuer(), ClientID: "fastgo", ClientSecret: "secret", RedirectURL: "http://localhost/callback"})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	b, _ := newTestBiz(t)
+	b.oidc = OIDCConfig{
+		Providers:       map[string]*OIDCProvider{"test": {Provider: provider, AutoProvision: autoProvision}},
+		StateExpiration: time.Minute,
+	}
+	return b, idp
+}
+
+// authorizeCode 在模拟身份提供方中完成授权, 返回回调请求, 其中的 Session 为发起授权时返回的会话信息.
+func authorizeCode(t *testing.T, auth *apiv1.AuthorizeOIDCResponse) *apiv1.OIDCCallbackRequest {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(auth.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("GET authorization url error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorization response = %d %s, want a redirect", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	query := location.Query()
+	return &apiv1.OIDCCallbackRequest{Provider: "test", Code: query.Get("code"), State: query.Get("state"), Error: query.Get("error"), Session: auth.Session}
+}
+
+// oidcLogin 发起 OIDC 登录并完成授权, 返回回调请求.
+func oidcLogin(t *testing.T, b *userBiz, ctx context.Context) *apiv1.OIDCCallbackRequest {
+	t.Helper()
+
+	auth, err := b.AuthorizeOIDC(ctx, &apiv1.AuthorizeOIDCRequest{Provider: "test"})
+	if err != nil {
+		t.Fatalf("AuthorizeOIDC() error = %v", err)
+	}
+	return authorizeCode(t, auth)
+}
+
+func TestOIDCCallback(t *testing.T) {
+	tests := []struct {
+		name          string
+		autoProvision bool
+		// claims 为身份提供方签发的 ID Token 中覆盖的声明
+		claims map[string]any
+		// callback 修改回调请求, 模拟攻击者篡改或者替换回调参数
+		callback func(t *testing.T, b *userBiz, ctx context.Context, rq *apiv1.OIDCCallbackRequest)
+		wantErr  *errorsx.ErrorX
+	}{
+		{name: "provision user", autoProvision: true},
+		{name: "not linked", wantErr: errorsx.ErrExternalIdentityNotLinked},
+		{
+			name:          "multiple audiences authorized for the client",
+			autoProvision: true,
+			claims:        map[string]any{"aud": []string{"fastgo", "other"}, "azp": "fastgo"},
+		},
+		{
+			name:          "session of another browser",
+			autoProvision: true,
+			callback: func(t *testing.T, b *userBiz, ctx context.Context, rq *apiv1.OIDCCallbackRequest) {
+				other, err := b.AuthorizeOIDC(ctx, &apiv1.AuthorizeOIDCRequest{Provider: "test"})
+				if err != nil {
+					t.Fatalf("AuthorizeOIDC() error = %v", err)
+				}
+				rq.Session = other.Session
+			},
+			wantErr: errorsx.ErrOIDCStateInvalid,
+		},
+		{
+			name:          "missing session",
+			autoProvision: true,
+			callback:      func(t *testing.T, b *userBiz, ctx context.Context, rq *apiv1.OIDCCallbackRequest) { rq.Session = "" },
+			wantErr:       errorsx.ErrOIDCStateInvalid,
+		},
+		{
+			name:          "forged state",
+			autoProvision: true,
+			callback: func(t *testing.T, b *userBiz, ctx context.Context, rq *apiv1.OIDCCallbackRequest) {
+				rq.State = "forged"
+			},
+			wantErr: errorsx.ErrOIDCStateInvalid,
+		},
+		{
+			name:          "code of another authorization",
+			autoProvision: true,
+			callback: func(t *testing.T, b *userBiz, ctx context.Context, rq *apiv1.OIDCCallbackRequest) {
+				// 授权码与 PKCE 校验码绑定, 不能在其他授权的回调中使用
+				rq.Code = oidcLogin(t, b, ctx).Code
+			},
+			wantErr: errorsx.ErrOIDCLoginFailed,
+		},
+		{
+			name:          "nonce mismatch",
+			autoProvision: true,
+			claims:        map[string]any{"nonce": "replayed"},
+			wantErr:       errorsx.ErrOIDCLoginFailed,
+		},
+		{
+			name:          "missing nonce",
+			autoProvision: true,
+			claims:        map[string]any{"nonce": nil},
+			wantErr:       errorsx.ErrOIDCLoginFailed,
+		},
+		{
+			name:          "wrong audience",
+			autoProvision: true,
+			claims:        map[string]any{"aud": "other"},
+			wantErr:       errorsx.ErrOIDCLoginFailed,
+		},
+		{
+			name:          "wrong authorized party",
+			autoProvision: true,
+			claims:        map[string]any{"aud": []string{"fastgo", "other"}, "azp": "other"},
+			wantErr:       errorsx.ErrOIDCLoginFailed,
+		},
+		{
+			name:          "missing authorized party",
+			autoProvision: true,
+			claims:        map[string]any{"aud": []string{"fastgo", "other"}},
+			wantErr:       errorsx.ErrOIDCLoginFailed,
+		},
+		{
+			name:          "wrong issuer",
+			autoProvision: true,
+			claims:        map[string]any{"iss": "https://attacker.example.com"},
+			wantErr:       errorsx.ErrOIDCLoginFailed,
+		},
+		{
+			name:          "authorization denied",
+			autoProvision: true,
+			callback: func(t *testing.T, b *userBiz, ctx context.Context, rq *apiv1.OIDCCallbackRequest) {
+				rq.Code, rq.Error = "", "access_denied"
+			},
+			wantErr: errorsx.ErrOIDCLoginFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, idp := newOIDCTestBiz(t, tt.autoProvision)
+			ctx := context.Background()
+			idp.SetUser(oidctest.User{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true, Name: "Alice", PreferredUsername: "alice"})
+			idp.SetClaims(tt.claims)
+
+			rq := oidcLogin(t, b, ctx)
+			if tt.callback != nil {
+				tt.callback(t, b, ctx, rq)
+			}
+			resp, err := b.OIDCCallback(ctx, rq)
+			wantError(t, err, tt.wantErr)
+
+			// 只有登录成功时才创建用户
+			count, _, listErr := b.store.User().List(ctx, where.F("username", "alice"))
+			if listErr != nil {
+				t.Fatalf("User().List() error = %v", listErr)
+			}
+			wantCount := int64(0)
+			if tt.wantErr == nil {
+				wantCount = 1
+			}
+			if count != wantCount {
+				t.Errorf("%d users provisioned, want %d", count, wantCount)
+			}
+			if err == nil && (resp.Token == "" || resp.RefreshToken == "") {
+				t.Errorf("OIDCCallback() = %+v, want a token and a refresh token", resp)
+			}
+		})
+	}
+}
+
+func TestOIDCProvisionedUser(t *testing.T) {
+	b, idp := newOIDCTestBiz(t, true)
+	ctx := context.Background()
+	// 用户名已被使用时追加随机后缀
+	createUser(t, b, ctx, "alice")
+	idp.SetUser(oidctest.User{Subject: "alice-sub", Email: "alice@corp.example.com", EmailVerified: true, Name: "Alice", PreferredUsername: "Alice"})
+
+	if _, err := b.OIDCCallback(ctx, oidcLogin(t, b, ctx)); err != nil {
+		t.Fatalf("OIDCCallback() error = %v", err)
+	}
+	identity, err := b.store.ExternalIdentity().Get(ctx, where.F("provider", "test", "subject", "alice-sub"))
+	if err != nil {
+		t.Fatalf("ExternalIdentity().Get() error = %v", err)
+	}
+	user, err := b.store.User().Get(ctx, where.F("userID", identity.UserID))
+	if err != nil {
+		t.Fatalf("User().Get() error = %v", err)
+	}
+	if !strings.HasPrefix(user.Username, "alice_") {
+		t.Errorf("Username = %s, want alice with a random suffix", user.Username)
+	}
+	if user.Email != "alice@corp.example.com" || user.EmailVerifiedAt == nil || user.Nickname != "Alice" {
+		t.Errorf("User() = %+v, want the verified email and name of the external identity", user)
+	}
+
+	// 再次登录使用已绑定的用户, 不会重复创建
+	if _, err := b.OIDCCallback(ctx, oidcLogin(t, b, ctx)); err != nil {
+		t.Fatalf("second OIDCCallback() error = %v", err)
+	}
+	count, _, err := b.store.ExternalIdentity().List(ctx, where.F("subject", "alice-sub"))
+	if err != nil {
+		t.Fatalf("ExternalIdentity().List() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("%d external identities after the second login, want 1", count)
+	}
+}
+
+func TestOIDCLinkIdentity(t *testing.T) {
+	b, idp := newOIDCTestBiz(t, false)
+	ctx := context.Background()
+	aliceID := createUser(t, b, ctx, "alice")
+	bobID := createUser(t, b, ctx, "bob")
+
+	link := func(userID string, subject string) (*apiv1.OIDCCallbackResponse, error) {
+		idp.SetUser(oidctest.User{Subject: subject, Email: subject + "@example.com", EmailVerified: true})
+		auth, err := b.LinkIdentity(ctx, &apiv1.LinkIdentityRequest{UserID: userID, Provider: "test"})
+		if err != nil {
+			t.Fatalf("LinkIdentity() error = %v", err)
+		}
+		return b.OIDCCallback(ctx, authorizeCode(t, auth))
+	}
+
+	resp, err := link(aliceID, "alice-sub")
+	if err != nil {
+		t.Fatalf("link alice-sub to alice error = %v", err)
+	}
+	if resp.Identity == nil || resp.Identity.Subject != "alice-sub" || resp.Token != "" {
+		t.Errorf("OIDCCallback() = %+v, want only the linked identity", resp)
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		subject string
+		wantErr *errorsx.ErrorX
+	}{
+		{name: "same identity again", userID: aliceID, subject: "alice-sub"},
+		{name: "identity linked to another user", userID: bobID, subject: "alice-sub", wantErr: errorsx.ErrExternalIdentityAlreadyLinked},
+		{name: "second identity of the same provider", userID: aliceID, subject: "other-sub", wantErr: errorsx.ErrExternalIdentityAlreadyLinked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := link(tt.userID, tt.subject)
+			wantError(t, err, tt.wantErr)
+		})
+	}
+
+	// 外部身份仍然绑定在 alice 上, 使用外部身份登录的是 alice
+	idp.SetUser(oidctest.User{Subject: "alice-sub"})
+	if _, err := b.OIDCCallback(ctx, oidcLogin(t, b, ctx)); err != nil {
+		t.Fatalf("OIDCCallback() login error = %v", err)
+	}
+	identity, err := b.store.ExternalIdentity().Get(ctx, where.F("provider", "test", "subject", "alice-sub"))
+	if err != nil {
+		t.Fatalf("ExternalIdentity().Get() error = %v", err)
+	}
+	if identity.UserID != aliceID || identity.LastLoginAt == nil {
+		t.Errorf("identity = %+v, want linked to alice with the last login recorded", identity)
+	}
+
+	// 绑定的 state 只能在发起绑定的浏览器中使用
+	auth, err := b.LinkIdentity(ctx, &apiv1.LinkIdentityRequest{UserID: bobID, Provider: "test"})
+	if err != nil {
+		t.Fatalf("LinkIdentity() error = %v", err)
+	}
+	rq := authorizeCode(t, auth)
+	rq.Session = oidcLogin(t, b, ctx).Session
+	_, err = b.OIDCCallback(ctx, rq)
+	wantError(t, err, errorsx.ErrOIDCStateInvalid)
+}
@@ -0,0 +1,126 @@
+package user
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/lockout"
+	where "fastgo/pkg/store"
+
+	apiv1 "fastgo/pkg/api/apiserver/v1"
+)
+
+// enableTenant 与 apiserver.EnableTenant 一样注册租户, 测试结束后取消注册.
+// 租户是全局注册的, 因此使用租户的测试不能并行执行.
+func enableTenant(t *testing.T) {
+	t.Helper()
+
+	where.RegisterTenant("tenantID", func(ctx context.Context) string {
+		if tenantID := contextx.TenantID(ctx); tenantID != "" {
+			return tenantID
+		}
+		return "default"
+	})
+	t.Cleanup(func() { where.RegisterTenant("", nil) })
+}
+
+func TestTenantIsolation(t *testing.T) {
+	enableTenant(t)
+	b, ds := newTestBiz(t)
+	acme := contextx.WithTenantID(context.Background(), "acme")
+	umbrella := contextx.WithTenantID(context.Background(), "umbrella")
+
+	aliceID := createUser(t, b, acme, "alice")
+	createUser(t, b, acme, "bob")
+	// 用户名只需要在租户内唯一
+	createUser(t, b, umbrella, "alice")
+
+	alice, err := ds.User().Get(acme, where.F("userID", aliceID))
+	if err != nil {
+		t.Fatalf("User().Get() error = %v", err)
+	}
+	if alice.TenantID != "acme" {
+		t.Errorf("TenantID = %s, want acme", alice.TenantID)
+	}
+
+	_, err = b.Create(acme, &apiv1.CreateUserRequest{Username: "bob", Password: testPassword, Email: "bob@example.com", Phone: "13800000000"})
+	wantError(t, err, errorsx.ErrDBWrite)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want []string
+	}{
+		{name: "acme", ctx: acme, want: []string{"bob", "alice"}},
+		{name: "umbrella", ctx: umbrella, want: []string{"alice"}},
+		{name: "default tenant", ctx: context.Background()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := b.List(tt.ctx, &apiv1.ListUserRequest{Limit: 10})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if got := usernames(resp.Users); !slices.Equal(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 其他租户中的用户不可见, 也不能被修改或删除
+	_, err = b.Get(umbrella, &apiv1.GetUserRequest{UserID: aliceID})
+	wantError(t, err, errorsx.ErrUserNotFound)
+	nickname := "mallory"
+	_, err = b.Update(umbrella, &apiv1.UpdateUserRequest{UserID: aliceID, Nickname: &nickname})
+	wantError(t, err, errorsx.ErrUserNotFound)
+	_, _ = b.Delete(umbrella, &apiv1.DeleteUserRequest{UserID: aliceID})
+	if _, err := b.Get(acme, &apiv1.GetUserRequest{UserID: aliceID}); err != nil {
+		t.Errorf("Get() after Delete() from another tenant error = %v", err)
+	}
+}
+
+func TestTenantLogin(t *testing.T) {
+	enableTenant(t)
+	b, _ := newTestBiz(t)
+	acme := contextx.WithTenantID(context.Background(), "acme")
+	umbrella := contextx.WithTenantID(context.Background(), "umbrella")
+	createUser(t, b, acme, "alice")
+
+	// 用户只能在所属的租户中登录
+	_, err := b.Login(umbrella, &apiv1.LoginRequest{Username: "alice", Password: testPassword})
+	wantError(t, err, errorsx.ErrLoginFailed)
+
+	// refresh token 只能在签发的租户中使用
+	resp := login(t, b, acme, "alice")
+	_, err = b.RefreshToken(umbrella, &apiv1.RefreshTokenRequest{RefreshToken: resp.RefreshToken})
+	wantError(t, err, errorsx.ErrRefreshTokenInvalid)
+	if _, err := b.RefreshToken(acme, &apiv1.RefreshTokenRequest{RefreshToken: resp.RefreshToken}); err != nil {
+		t.Errorf("RefreshToken() in the issuing tenant error = %v", err)
+	}
+}
+
+func TestTenantLockout(t *testing.T) {
+	enableTenant(t)
+	b, _ := newTestBiz(t)
+	b.guard = lockout.New(lockout.NewMemory(), lockout.Policy{MaxFailures: 3, MaxIPFailures: 10, Window: time.Minute, Duration: time.Minute})
+	acme := contextx.WithClientIP(contextx.WithTenantID(context.Background(), "acme"), "10.0.0.1")
+	umbrella := contextx.WithClientIP(contextx.WithTenantID(context.Background(), "umbrella"), "10.0.0.2")
+	createUser(t, b, acme, "alice")
+	createUser(t, b, umbrella, "alice")
+
+	for range 3 {
+		_, err := b.Login(acme, &apiv1.LoginRequest{Username: "alice", Password: "Wrong0!xyz"})
+		wantError(t, err, errorsx.ErrLoginFailed)
+	}
+	_, err := b.Login(acme, &apiv1.LoginRequest{Username: "alice", Password: testPassword})
+	wantError(t, err, errorsx.ErrAccountLocked)
+
+	// 锁定只作用于当前租户中的同名用户
+	if _, err := b.Login(umbrella, &apiv1.LoginRequest{Username: "alice", Password: testPassword}); err != nil {
+		t.Errorf("Login() in another tenant error = %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/known"
+	"fastgo/internal/pkg/metrics"
+	"fastgo/internal/pkg/totp"
+	"fastgo/internal/pkg/tracing"
+	where "fastgo/pkg/store"
+	"fastgo/pkg/token"
+	"github.com/onexstack/onexstack/pkg/authn"
+	"log/slog"
+	"strconv"
+	"time"
+
+	apiv1 "fastgo/pkg/api/apiserver/v1"
+)
+
+// CompleteLogin 使用两步验证码或恢复码完成登录, 成功后签发 token 和 refresh token.
+// 验证码错误与密码错误一样计入登录失败次数, 达到阈值后锁定用户名和客户端 IP.
+func (b *userBiz) CompleteLogin(ctx context.Context, rq *apiv1.CompleteLoginRequest) (_ *apiv1.LoginResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.CompleteLogin")
+	defer span.End()
+
+	// 记录登录成功和失败的次数
+	defer func() { metrics.ObserveLogin(err) }()
+
+	claims, err := token.ParseAction(rq.ChallengeToken, known.ActionTwoFactor)
+	if err != nil {
+		return nil, errorsx.ErrChallengeTokenInvalid
+	}
+	if claims.Tenant != "" {
+		ctx = contextx.WithTenantID(ctx, claims.Tenant)
+	}
+
+	userModel, err := b.store.User().Get(ctx, where.F("userID", claims.Subject))
+	if err != nil {
+		if errors.Is(err, errorsx.ErrUserNotFound) {
+			return nil, errorsx.ErrChallengeTokenInvalid
+		}
+		return nil, err
+	}
+	tf, err := b.enabledTwoFactor(ctx, userModel.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// 修改密码、关闭两步验证或者验证码已被使用后, 登录挑战令牌失效
+	if tf == nil || !claims.Matches(challengeState(userModel, tf)) {
+		return nil, errorsx.ErrChallengeTokenInvalid
+	}
+
+	if err := b.verifyCode(ctx, userModel, tf, rq.Code); err != nil {
+		return nil, err
+	}
+	return b.issueTokens(ctx, userModel)
+}
+
+// EnrollTOTP 为用户生成新的 TOTP 密钥, 用户使用验证码确认后才会启用两步验证.
+// 已启用两步验证时需要先关闭, 尚未确认时重新生成密钥.
+func (b *userBiz) EnrollTOTP(ctx context.Context, rq *apiv1.EnrollTOTPRequest) (*apiv1.EnrollTOTPResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.EnrollTOTP")
+	defer span.End()
+
+	userModel, err := b.store.User().Get(ctx, where.F("userID", rq.UserID))
+	if err != nil {
+		return nil, err
+	}
+	// 绑定验证器应用需要再次校验密码, 避免 token 泄露后被他人绑定
+	if err := authn.Compare(userModel.Password, rq.Password); err != nil {
+		return nil, errorsx.ErrPasswordInvalid
+	}
+
+	tf, err := b.store.TwoFactor().Get(ctx, where.F("userID", userModel.UserID))
+	if err != nil && !errors.Is(err, errorsx.ErrTwoFactorNotEnrolled) {
+		return nil, err
+	}
+	if tf != nil && tf.EnabledAt != nil {
+		return nil, errorsx.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate TOTP secret", "err", err)
+		return nil, errorsx.ErrInternal
+	}
+	if tf == nil {
+		err = b.store.TwoFactor().Create(ctx, &model.TwoFactor{UserID: userModel.UserID, Secret: secret})
+	} else {
+		tf.Secret, tf.LastStep = secret, 0
+		err = b.store.TwoFactor().Update(ctx, tf)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiv1.EnrollTOTPResponse{Secret: secret, URI: totp.URI(b.twoFA.Issuer, userModel.Username, secret)}, nil
+}
+
+// ConfirmTOTP 使用验证器应用生成的验证码确认绑定并启用两步验证, 返回一组新的恢复码.
+func (b *userBiz) ConfirmTOTP(ctx context.Context, rq *apiv1.ConfirmTOTPRequest) (*apiv1.ConfirmTOTPResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.ConfirmTOTP")
+	defer span.End()
+
+	userModel, err := b.store.User().Get(ctx, where.F("userID", rq.UserID))
+	if err != nil {
+		return nil, err
+	}
+	tf, err := b.store.TwoFactor().Get(ctx, where.F("userID", userModel.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if tf.EnabledAt != nil {
+		return nil, errorsx.ErrTwoFactorAlreadyEnabled
+	}
+	// 确认时只接受验证码, 证明验证器应用已正确导入密钥
+	if totp.IsRecoveryCode(rq.Code) {
+		return nil, errorsx.ErrTwoFactorCodeInvalid
+	}
+	if err := b.verifyCode(ctx, userModel, tf, rq.Code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = b.store.TX(ctx, func(ctx context.Context) error {
+		// verifyCode 已更新了 lastStep, 重新查询避免覆盖
+		current, err := b.store.TwoFactor().Get(ctx, where.F("id", tf.ID))
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		current.EnabledAt = &now
+		if err := b.store.TwoFactor().Update(ctx, current); err != nil {
+			return err
+		}
+		codes, err = b.replaceRecoveryCodes(ctx, userModel.UserID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Two-factor authentication enabled", "userID", userModel.UserID)
+
+	return &apiv1.ConfirmTOTPResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP 关闭两步验证并删除所有恢复码.
+// 用户关闭自己的两步验证时需要提交验证码或恢复码, 管理员可以直接关闭其他用户的两步验证(例如用户丢失了验证器应用和恢复码).
+func (b *userBiz) DisableTOTP(ctx context.Context, rq *apiv1.DisableTOTPRequest) (*apiv1.DisableTOTPResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.DisableTOTP")
+	defer span.End()
+
+	userModel, err := b.store.User().Get(ctx, where.F("userID", rq.UserID))
+	if err != nil {
+		return nil, err
+	}
+	tf, err := b.store.TwoFactor().Get(ctx, where.F("userID", userModel.UserID))
+	if err != nil {
+		return nil, err
+	}
+	// 尚未确认的绑定可以直接取消
+	if tf.EnabledAt != nil && contextx.UserID(ctx) == userModel.UserID {
+		if err := b.verifyCode(ctx, userModel, tf, rq.Code); err != nil {
+			return nil, err
+		}
+	}
+
+	err = b.store.TX(ctx, func(ctx context.Context) error {
+		if err := b.store.TwoFactor().Delete(ctx, where.F("id", tf.ID)); err != nil {
+			return err
+		}
+		return b.store.RecoveryCode().Delete(ctx, where.F("userID", userModel.UserID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Two-factor authentication disabled", "userID", userModel.UserID, "operator", contextx.UserID(ctx))
+
+	return &apiv1.DisableTOTPResponse{}, nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码, 原有的恢复码全部失效.
+func (b *userBiz) RegenerateRecoveryCodes(ctx context.Context, rq *apiv1.RegenerateRecoveryCodesRequest) (*apiv1.RegenerateRecoveryCodesResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.RegenerateRecoveryCodes")
+	defer span.End()
+
+	userModel, err := b.store.User().Get(ctx, where.F("userID", rq.UserID))
+	if err != nil {
+		return nil, err
+	}
+	tf, err := b.enabledTwoFactor(ctx, userModel.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, errorsx.ErrTwoFactorNotEnrolled
+	}
+	if err := b.verifyCode(ctx, userModel, tf, rq.Code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = b.store.TX(ctx, func(ctx context.Context) error {
+		var err error
+		codes, err = b.replaceRecoveryCodes(ctx, userModel.UserID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiv1.RegenerateRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// enabledTwoFactor 返回用户已启用的两步验证记录, 用户未绑定或尚未确认时返回 nil.
+func (b *userBiz) enabledTwoFactor(ctx context.Context, userID string) (*model.TwoFactor, error) {
+	tf, err := b.store.TwoFactor().Get(ctx, where.F("userID", userID))
+	if err != nil {
+		if errors.Is(err, errorsx.ErrTwoFactorNotEnrolled) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if tf.EnabledAt == nil {
+		return nil, nil
+	}
+	return tf, nil
+}
+
+// loginChallenge 为密码校验通过的用户签发登录挑战令牌.
+func (b *userBiz) loginChallenge(ctx context.Context, userModel *model.User, tf *model.TwoFactor) (*apiv1.LoginResponse, error) {
+	challenge, expireAt, err := token.SignAction(known.ActionTwoFactor, userModel.UserID, userModel.TenantID, challengeState(userModel, tf), b.twoFA.ChallengeExpiration)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to sign login challenge", "err", err)
+		return nil, errorsx.ErrSignToken
+	}
+	return &apiv1.LoginResponse{TwoFactorRequired: true, ChallengeToken: challenge, ChallengeExpireAt: expireAt}, nil
+}
+
+// verifyCode 校验用户提交的验证码或恢复码, 每个验证码和恢复码只能使用一次.
+// 与登录共用失败次数和锁定状态, 避免通过两步验证相关接口暴力破解验证码.
+func (b *userBiz) verifyCode(ctx context.Context, userModel *model.User, tf *model.TwoFactor, code string) error {
+	account, clientIP := lockoutAccount(ctx, userModel.Username), contextx.ClientIP(ctx)
+	if err := b.checkLocked(ctx, account, clientIP); err != nil {
+		return err
+	}
+
+	var ok bool
+	var err error
+	if totp.IsRecoveryCode(code) {
+		ok, err = b.useRecoveryCode(ctx, tf, code)
+	} else if step, valid := totp.Validate(tf.Secret, code, time.Now(), b.twoFA.Skew, tf.LastStep); valid {
+		// 条件更新, 同一个验证码被并发提交时只有一个请求成功
+		ok, err = b.store.TwoFactor().Advance(ctx, tf.ID, step)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		slog.WarnContext(ctx, "Two-factor code is incorrect", "userID", userModel.UserID, "clientIP", clientIP)
+		return b.loginFailed(ctx, account, clientIP, errorsx.ErrTwoFactorCodeInvalid)
+	}
+
+	if err := b.guard.Succeed(ctx, account); err != nil {
+		slog.ErrorContext(ctx, "Failed to reset login failures", "username", userModel.Username, "err", err)
+	}
+	return nil
+}
+
+// useRecoveryCode 将用户未使用的恢复码 code 标记为已使用, 恢复码不存在或已被使用时返回 false.
+func (b *userBiz) useRecoveryCode(ctx context.Context, tf *model.TwoFactor, code string) (bool, error) {
+	used, err := b.store.RecoveryCode().Use(ctx, where.F("userID", tf.UserID, "codeHash", totp.HashRecoveryCode(code)))
+	if err != nil || !used {
+		return false, err
+	}
+	slog.InfoContext(ctx, "Recovery code used", "userID", tf.UserID)
+
+	// 推进时间步使已签发的登录挑战令牌失效, 当前时间步已被使用时无需推进
+	if _, err := b.store.TwoFactor().Advance(ctx, tf.ID, totp.Step(time.Now())); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// replaceRecoveryCodes 删除用户原有的恢复码并生成一组新的恢复码, 需要在事务中调用.
+func (b *userBiz) replaceRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	codes, err := totp.GenerateRecoveryCodes(b.twoFA.RecoveryCodes)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate recovery codes", "err", err)
+		return nil, errorsx.ErrInternal
+	}
+
+	if err := b.store.RecoveryCode().Delete(ctx, where.F("userID", userID)); err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		if err := b.store.RecoveryCode().Create(ctx, &model.RecoveryCode{UserID: userID, CodeHash: totp.HashRecoveryCode(code)}); err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// challengeState 返回签发登录挑战令牌时用户的状态.
+// 登录挑战令牌与密码哈希、TOTP 密钥和最近一次验证通过的时间步绑定, 完成登录或修改密码后令牌失效.
+func challengeState(userModel *model.User, tf *model.TwoFactor) string {
+	return userModel.Password + "\x00" + tf.Secret + "\x00" + strconv.FormatInt(tf.LastStep, 10)
+}
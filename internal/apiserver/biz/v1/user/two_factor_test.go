@@ -0,0 +1,310 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/lockout"
+	"fastgo/internal/pkg/totp"
+	where "fastgo/pkg/store"
+
+	apiv1 "fastgo/pkg/api/apiserver/v1"
+)
+
+// enrolled 为启用了两步验证的测试用户.
+type enrolled struct {
+	userID        string
+	secret        string
+	recoveryCodes []string
+	// step 为确认绑定时使用的时间步, 之后的验证码需要使用更晚的时间步
+	step int64
+}
+
+// code 返回 step 之后第 n 个时间步的验证码.
+func (e *enrolled) code(t *testing.T, n int64) string {
+	t.Helper()
+
+	code, err := totp.Code(e.secret, e.step+n)
+	if err != nil {
+		t.Fatalf("Code() error = %v", err)
+	}
+	return code
+}
+
+// wrongCode 返回一个在当前时间附近都无效的验证码.
+func (e *enrolled) wrongCode(t *testing.T) string {
+	t.Helper()
+
+	valid := map[string]bool{}
+	for n := int64(-2); n <= 3; n++ {
+		valid[e.code(t, n)] = true
+	}
+	for i := 0; ; i++ {
+		if code := fmt.Sprintf("%06d", i); !valid[code] {
+			return code
+		}
+	}
+}
+
+// enrollTOTP 创建用户 username 并启用两步验证.
+func enrollTOTP(t *testing.T, b *userBiz, ctx context.Context, username string) *enrolled {
+	t.Helper()
+
+	userID := createUser(t, b, ctx, username)
+	ctx = contextx.WithUserID(ctx, userID)
+	enroll, err := b.EnrollTOTP(ctx, &apiv1.EnrollTOTPRequest{UserID: userID, Password: testPassword})
+	if err != nil {
+		t.Fatalf("EnrollTOTP() error = %v", err)
+	}
+
+	e := &enrolled{userID: userID, secret: enroll.Secret, step: totp.Step(time.Now())}
+	confirm, err := b.ConfirmTOTP(ctx, &apiv1.ConfirmTOTPRequest{UserID: userID, Code: e.code(t, 0)})
+	if err != nil {
+		t.Fatalf("ConfirmTOTP() error = %v", err)
+	}
+	e.recoveryCodes = confirm.RecoveryCodes
+	return e
+}
+
+// challenge 使用密码登录, 返回登录挑战令牌.
+func challenge(t *testing.T, b *userBiz, ctx context.Context, username string) string {
+	t.Helper()
+
+	resp := login(t, b, ctx, username)
+	if !resp.TwoFactorRequired || resp.ChallengeToken == "" || resp.Token != "" {
+		t.Fatalf("Login() = %+v, want only a challenge token", resp)
+	}
+	return resp.ChallengeToken
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	tests := []struct {
+		name string
+		// code 返回确认时提交的验证码
+		code    func(t *testing.T, secret string) string
+		wantErr *errorsx.ErrorX
+	}{
+		{
+			name: "current code",
+			code: func(t *testing.T, secret string) string {
+				code, _ := totp.Code(secret, totp.Step(time.Now()))
+				return code
+			},
+		},
+		{
+			name: "code outside skew",
+			code: func(t *testing.T, secret string) string {
+				code, _ := totp.Code(secret, totp.Step(time.Now())-5)
+				return code
+			},
+			wantErr: errorsx.ErrTwoFactorCodeInvalid,
+		},
+		{
+			name:    "recovery code format",
+			code:    func(t *testing.T, secret string) string { return "abcde-fghij" },
+			wantErr: errorsx.ErrTwoFactorCodeInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBiz(t)
+			ctx := context.Background()
+			userID := createUser(t, b, ctx, "alice")
+			ctx = contextx.WithUserID(ctx, userID)
+
+			enroll, err := b.EnrollTOTP(ctx, &apiv1.EnrollTOTPRequest{UserID: userID, Password: testPassword})
+			if err != nil {
+				t.Fatalf("EnrollTOTP() error = %v", err)
+			}
+			resp, err := b.ConfirmTOTP(ctx, &apiv1.ConfirmTOTPRequest{UserID: userID, Code: tt.code(t, enroll.Secret)})
+			wantError(t, err, tt.wantErr)
+			if err != nil {
+				// 确认失败时不启用两步验证
+				if resp := login(t, b, ctx, "alice"); resp.TwoFactorRequired {
+					t.Error("Login() requires two-factor authentication after a failed confirmation")
+				}
+				return
+			}
+			if len(resp.RecoveryCodes) != b.twoFA.RecoveryCodes {
+				t.Errorf("ConfirmTOTP() returned %d recovery codes, want %d", len(resp.RecoveryCodes), b.twoFA.RecoveryCodes)
+			}
+		})
+	}
+}
+
+func TestCompleteLogin(t *testing.T) {
+	tests := []struct {
+		name string
+		// complete 在完成登录之前执行, 返回完成登录使用的挑战令牌和验证码
+		complete func(t *testing.T, b *userBiz, ctx context.Context, e *enrolled) (string, string)
+		wantErr  *errorsx.ErrorX
+	}{
+		{
+			name: "next code",
+			complete: func(t *testing.T, b *userBiz, ctx context.Context, e *enrolled) (string, string) {
+				return challenge(t, b, ctx, "alice"), e.code(t, 1)
+			},
+		},
+		{
+			name: "code used for confirmation",
+			complete: func(t *testing.T, b *userBiz, ctx context.Context, e *enrolled) (string, string) {
+				return challenge(t, b, ctx, "alice"), e.code(t, 0)
+			},
+			wantErr: errorsx.ErrTwoFactorCodeInvalid,
+		},
+		{
+			name: "replayed code",
+			complete: func(t *testing.T, b *userBiz, ctx context.Context, e *enrolled) (string, string) {
+				if _, err := b.CompleteLogin(ctx, &apiv1.CompleteLoginRequest{ChallengeToken: challenge(t, b, ctx, "alice"), Code: e.code(t, 1)}); err != nil {
+					t.Fatalf("CompleteLogin() error = %v", err)
+				}
+				return challenge(t, b, ctx, "alice"), e.code(t, 1)
+			},
+			wantErr: errorsx.ErrTwoFactorCodeInvalid,
+		},
+		{
+			name: "code older than the last used one",
+			complete: func(t *testing.T, b *userBiz, ctx context.Context, e *enrolled) (string, string) {
+				return challenge(t, b, ctx, "alice"), e.code(t, -1)
+			},
+			wantErr: errorsx.ErrTwoFactorCodeInvalid,
+		},
+		{
+			name: "challenge token used twice",
+			complete: func(t *testing.T, b *userBiz, ctx context.Context, e *enrolled) (string, string) {
+				challengeToken := challenge(t, b, ctx, "alice")
+				if _, err := b.CompleteLogin(ctx, &apiv1.CompleteLoginRequest{ChallengeToken: challengeToken, Code: e.code(t, 1)}); err != nil {
+					t.Fatalf("CompleteLogin() error = %v", err)
+				}
+				return challengeToken, e.code(t, 2)
+			},
+			wantErr: errorsx.ErrChallengeTokenInvalid,
+		},
+		{
+			name: "challenge token after password change",
+			complete: func(t *testing.T, b *userBiz, ctx context.Context, e *enrolled) (string, string) {
+				challengeToken := challenge(t, b, ctx, "alice")
+				_, err := b.ChangePassword(contextx.WithUserID(ctx, e.userID), &apiv1.ChangePasswordRequest{UserID: e.userID, OldPassword: testPassword, NewPassword: "Passw0rd!1"})
+				if err != nil {
+					t.Fatalf("ChangePassword() error = %v", err)
+				}
+				return challengeToken, e.code(t, 1)
+			},
+			wantErr: errorsx.ErrChallengeTokenInvalid,
+		},
+		{
+			name: "malformed challenge token",
+			complete: func(t *testing.T, b *userBiz, ctx context.Context, e *enrolled) (string, string) {
+				return "malformed", e.code(t, 1)
+			},
+			wantErr: errorsx.ErrChallengeTokenInvalid,
+		},
+		{
+			name: "recovery code",
+			complete: func(t *testing.T, b *userBiz, ctx context.Context, e *enrolled) (string, string) {
+				return challenge(t, b, ctx, "alice"), e.recoveryCodes[0]
+			},
+		},
+		{
+			name: "used recovery code",
+			complete: func(t *testing.T, b *userBiz, ctx context.Context, e *enrolled) (string, string) {
+				if _, err := b.CompleteLogin(ctx, &apiv1.CompleteLoginRequest{ChallengeToken: challenge(t, b, ctx, "alice"), Code: e.recoveryCodes[0]}); err != nil {
+					t.Fatalf("CompleteLogin() error = %v", err)
+				}
+				return challenge(t, b, ctx, "alice"), e.recoveryCodes[0]
+			},
+			wantErr: errorsx.ErrTwoFactorCodeInvalid,
+		},
+		{
+			name: "regenerated recovery codes",
+			complete: func(t *testing.T, b *userBiz, ctx context.Context, e *enrolled) (string, string) {
+				_, err := b.RegenerateRecoveryCodes(contextx.WithUserID(ctx, e.userID), &apiv1.RegenerateRecoveryCodesRequest{UserID: e.userID, Code: e.code(t, 1)})
+				if err != nil {
+					t.Fatalf("RegenerateRecoveryCodes() error = %v", err)
+				}
+				return challenge(t, b, ctx, "alice"), e.recoveryCodes[1]
+			},
+			wantErr: errorsx.ErrTwoFactorCodeInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBiz(t)
+			ctx := context.Background()
+			e := enrollTOTP(t, b, ctx, "alice")
+
+			challengeToken, code := tt.complete(t, b, ctx, e)
+			resp, err := b.CompleteLogin(ctx, &apiv1.CompleteLoginRequest{ChallengeToken: challengeToken, Code: code})
+			wantError(t, err, tt.wantErr)
+			if err == nil && (resp.Token == "" || resp.RefreshToken == "") {
+				t.Errorf("CompleteLogin() = %+v, want a token and a refresh token", resp)
+			}
+		})
+	}
+}
+
+func TestCompleteLoginLockout(t *testing.T) {
+	b, _ := newTestBiz(t)
+	b.guard = lockout.New(lockout.NewMemory(), lockout.Policy{MaxFailures: 3, MaxIPFailures: 10, Window: time.Minute, Duration: time.Minute})
+	ctx := contextx.WithClientIP(context.Background(), "10.0.0.1")
+	e := enrollTOTP(t, b, ctx, "alice")
+
+	// 密码正确但验证码错误同样计入失败次数, 重新使用密码登录不会清除失败记录
+	for i := range 3 {
+		_, err := b.CompleteLogin(ctx, &apiv1.CompleteLoginRequest{ChallengeToken: challenge(t, b, ctx, "alice"), Code: e.wrongCode(t)})
+		if i < 2 {
+			wantError(t, err, errorsx.ErrTwoFactorCodeInvalid)
+		}
+	}
+
+	_, err := b.Login(ctx, &apiv1.LoginRequest{Username: "alice", Password: testPassword})
+	wantError(t, err, errorsx.ErrAccountLocked)
+}
+
+func TestDisableTOTP(t *testing.T) {
+	tests := []struct {
+		name string
+		// operator 为关闭两步验证的用户, 为空时表示用户自己
+		operator string
+		code     func(t *testing.T, e *enrolled) string
+		wantErr  *errorsx.ErrorX
+	}{
+		{name: "own with code", code: func(t *testing.T, e *enrolled) string { return e.code(t, 1) }},
+		{name: "own with recovery code", code: func(t *testing.T, e *enrolled) string { return e.recoveryCodes[0] }},
+		{name: "own with wrong code", code: func(t *testing.T, e *enrolled) string { return e.wrongCode(t) }, wantErr: errorsx.ErrTwoFactorCodeInvalid},
+		{name: "by administrator", operator: "user-admin", code: func(t *testing.T, e *enrolled) string { return "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBiz(t)
+			ctx := context.Background()
+			e := enrollTOTP(t, b, ctx, "alice")
+
+			operator := tt.operator
+			if operator == "" {
+				operator = e.userID
+			}
+			_, err := b.DisableTOTP(contextx.WithUserID(ctx, operator), &apiv1.DisableTOTPRequest{UserID: e.userID, Code: tt.code(t, e)})
+			wantError(t, err, tt.wantErr)
+
+			// 关闭后只需要密码即可登录, 恢复码同时删除
+			resp := login(t, b, ctx, "alice")
+			if resp.TwoFactorRequired != (tt.wantErr != nil) {
+				t.Errorf("Login() TwoFactorRequired = %v, want %v", resp.TwoFactorRequired, tt.wantErr != nil)
+			}
+			if tt.wantErr == nil {
+				count, _, err := b.store.RecoveryCode().List(ctx, where.F("userID", e.userID))
+				if err != nil {
+					t.Fatalf("RecoveryCode().List() error = %v", err)
+				}
+				if count != 0 {
+					t.Errorf("%d recovery codes left after DisableTOTP()", count)
+				}
+			}
+		})
+	}
+}
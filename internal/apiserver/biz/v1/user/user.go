@@ -2,18 +2,31 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fastgo/internal/apiserver/model"
 	"fastgo/internal/apiserver/pkg/conversion"
 	"fastgo/internal/apiserver/store"
 	"fastgo/internal/pkg/contextx"
 	"fastgo/internal/pkg/errorsx"
 	"fastgo/internal/pkg/known"
+	"fastgo/internal/pkg/lockout"
+	"fastgo/internal/pkg/mailer"
+	"fastgo/internal/pkg/metrics"
+	"fastgo/internal/pkg/oidc"
+	"fastgo/internal/pkg/password"
+	"fastgo/internal/pkg/query"
+	"fastgo/internal/pkg/revocation"
+	"fastgo/internal/pkg/tracing"
 	where "fastgo/pkg/store"
 	"fastgo/pkg/token"
+	"fmt"
 	"github.com/onexstack/onexstack/pkg/authn"
 	"log/slog"
+	"net/url"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jinzhu/copier"
 	"golang.org/x/sync/errgroup"
 
@@ -37,24 +50,115 @@ type UserBiz interface {
 type UserExpansion interface {
 	Login(ctx context.Context, rq *apiv1.LoginRequest) (*apiv1.LoginResponse, error)
 	RefreshToken(ctx context.Context, rq *apiv1.RefreshTokenRequest) (*apiv1.RefreshTokenResponse, error)
+	Logout(ctx context.Context, rq *apiv1.LogoutRequest) (*apiv1.LogoutResponse, error)
+	LogoutAll(ctx context.Context, rq *apiv1.LogoutAllRequest) (*apiv1.LogoutAllResponse, error)
 	ChangePassword(ctx context.Context, rq *apiv1.ChangePasswordRequest) (*apiv1.ChangePasswordResponse, error)
+	UpdateRole(ctx context.Context, rq *apiv1.UpdateUserRoleRequest) (*apiv1.UpdateUserRoleResponse, error)
+	ListTrash(ctx context.Context, rq *apiv1.ListTrashUserRequest) (*apiv1.ListTrashUserResponse, error)
+	Restore(ctx context.Context, rq *apiv1.RestoreUserRequest) (*apiv1.RestoreUserResponse, error)
+	Unlock(ctx context.Context, rq *apiv1.UnlockUserRequest) (*apiv1.UnlockUserResponse, error)
+	SendVerificationEmail(ctx context.Context, rq *apiv1.SendVerificationEmailRequest) (*apiv1.SendVerificationEmailResponse, error)
+	VerifyEmail(ctx context.Context, rq *apiv1.VerifyEmailRequest) (*apiv1.VerifyEmailResponse, error)
+	RequestPasswordReset(ctx context.Context, rq *apiv1.RequestPasswordResetRequest) (*apiv1.RequestPasswordResetResponse, error)
+	ConfirmPasswordReset(ctx context.Context, rq *apiv1.ConfirmPasswordResetRequest) (*apiv1.ConfirmPasswordResetResponse, error)
+	CompleteLogin(ctx context.Context, rq *apiv1.CompleteLoginRequest) (*apiv1.LoginResponse, error)
+	EnrollTOTP(ctx context.Context, rq *apiv1.EnrollTOTPRequest) (*apiv1.EnrollTOTPResponse, error)
+	ConfirmTOTP(ctx context.Context, rq *apiv1.ConfirmTOTPRequest) (*apiv1.ConfirmTOTPResponse, error)
+	DisableTOTP(ctx context.Context, rq *apiv1.DisableTOTPRequest) (*apiv1.DisableTOTPResponse, error)
+	RegenerateRecoveryCodes(ctx context.Context, rq *apiv1.RegenerateRecoveryCodesRequest) (*apiv1.RegenerateRecoveryCodesResponse, error)
+	AuthorizeOIDC(ctx context.Context, rq *apiv1.AuthorizeOIDCRequest) (*apiv1.AuthorizeOIDCResponse, error)
+	LinkIdentity(ctx context.Context, rq *apiv1.LinkIdentityRequest) (*apiv1.AuthorizeOIDCResponse, error)
+	OIDCCallback(ctx context.Context, rq *apiv1.OIDCCallbackRequest) (*apiv1.OIDCCallbackResponse, error)
+	ListIdentities(ctx context.Context, rq *apiv1.ListIdentitiesRequest) (*apiv1.ListIdentitiesResponse, error)
+	UnlinkIdentity(ctx context.Context, rq *apiv1.UnlinkIdentityRequest) (*apiv1.UnlinkIdentityResponse, error)
+}
+
+// EmailConfig 为邮箱验证和找回密码的配置.
+type EmailConfig struct {
+	// VerifyURL 为验证邮箱的链接地址, 邮件中的链接为该地址加上 token 查询参数.
+	VerifyURL string
+	// ResetURL 为重置密码的链接地址, 邮件中的链接为该地址加上 token 查询参数.
+	ResetURL string
+	// VerifyExpiration 为验证邮箱链接的有效期.
+	VerifyExpiration time.Duration
+	// ResetExpiration 为重置密码链接的有效期.
+	ResetExpiration time.Duration
+	// RequireVerified 为 true 时, 未验证邮箱的用户不能登录.
+	RequireVerified bool
+}
+
+// TwoFactorConfig 为两步验证的配置.
+type TwoFactorConfig struct {
+	// Issuer 为显示在验证器应用中的服务名称.
+	Issuer string
+	// ChallengeExpiration 为登录挑战令牌的有效期.
+	ChallengeExpiration time.Duration
+	// RecoveryCodes 为每次生成的恢复码个数.
+	RecoveryCodes int
+	// Skew 为校验验证码时允许的时钟偏差, 单位为时间步.
+	Skew int
+}
+
+// OIDCConfig 为外部身份提供方登录的配置.
+type OIDCConfig struct {
+	// Providers 为可以用于登录的身份提供方, 键为身份提供方名称.
+	Providers map[string]*OIDCProvider
+	// StateExpiration 为一次 OIDC 登录从发起到回调的最长时间.
+	StateExpiration time.Duration
+}
+
+// OIDCProvider 为一个 OIDC 身份提供方.
+type OIDCProvider struct {
+	*oidc.Provider
+	// AutoProvision 表示外部身份没有绑定用户时, 是否在第一次登录时自动创建用户.
+	AutoProvision bool
 }
 
 // userBiz 是 UserBiz 接口的具体实现
 type userBiz struct {
-	store store.IStore
+	store   store.IStore
+	revoker revocation.Revoker
+	guard   *lockout.Guard
+	policy  *password.Policy
+	mailer  mailer.Mailer
+	email   EmailConfig
+	twoFA   TwoFactorConfig
+	oidc    OIDCConfig
 }
 
 // 静态检验 userBiz 是否实现 UserBiz 所有方法
 var _ UserBiz = (*userBiz)(nil)
 
-// 创建一个userBiz实体
-func New(store store.IStore) *userBiz {
-	return &userBiz{store: store}
+// listSchema 为用户列表支持的过滤和排序字段.
+var listSchema = query.NewSchema(&model.User{},
+	query.Field{Name: "username", Type: query.String, Ops: []query.Operator{query.Eq, query.Ne, query.Contains}, Sortable: true},
+	query.Field{Name: "nickname", Type: query.String, Ops: []query.Operator{query.Eq, query.Ne, query.Contains}, Sortable: true},
+	query.Field{Name: "email", Type: query.String, Ops: []query.Operator{query.Eq, query.Contains}},
+	query.Field{Name: "role", Type: query.String, Ops: []query.Operator{query.Eq, query.Ne}},
+	query.Field{Name: "createdAt", Type: query.Time, Ops: query.Comparable, Sortable: true},
+	query.Field{Name: "updatedAt", Type: query.Time, Ops: query.Comparable, Sortable: true},
+)
+
+// dummyPassword 为用户不存在时参与校验的密码哈希, 使用户不存在和密码错误时的响应时间一致.
+var dummyPassword = sync.OnceValue(func() string {
+	hashed, _ := authn.Encrypt(uuid.New().String())
+	return hashed
+})
+
+// maxAccountsPerEmail 为按照邮箱发送验证邮件或重置密码邮件时, 最多处理的用户数量.
+// 邮箱不要求唯一, 限制数量避免一次请求发送大量邮件.
+const maxAccountsPerEmail = 10
+
+// 创建一个userBiz实体, guard 用于登录的暴力破解防护, policy 用于校验历史密码, mailer 和 email 用于发送验证邮件和重置密码邮件, twoFA 为两步验证的配置, oidc 为外部身份提供方登录的配置
+func New(store store.IStore, revoker revocation.Revoker, guard *lockout.Guard, policy *password.Policy, mailer mailer.Mailer, email EmailConfig, twoFA TwoFactorConfig, oidc OIDCConfig) *userBiz {
+	return &userBiz{store: store, revoker: revoker, guard: guard, policy: policy, mailer: mailer, email: email, twoFA: twoFA, oidc: oidc}
 }
 
 // 实现 UserBiz 接口中的 Create 方法.
 func (b *userBiz) Create(ctx context.Context, rq *apiv1.CreateUserRequest) (*apiv1.CreateUserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.Create")
+	defer span.End()
+
 	var userModel model.User
 	// 将 rq 结构体赋值到 userModel
 	// `copier.Copy`通过反射, 对同名/同标签的匹配字段进行复制赋值, 并忽略不匹配字段.
@@ -65,14 +169,20 @@ func (b *userBiz) Create(ctx context.Context, rq *apiv1.CreateUserRequest) (*api
 		return nil, err
 	}
 
+	// 发送验证邮件, 发送失败不影响创建用户, 用户可以重新发送验证邮件
+	b.sendVerificationEmail(ctx, &userModel)
+
 	return &apiv1.CreateUserResponse{UserID: userModel.UserID}, nil
 }
 
 // 实现 UserBiz 接口的 Update 方法.
 // 对 rq 的字段判空如果不为 nil 表示 request 带有这些信息
 func (b *userBiz) Update(ctx context.Context, rq *apiv1.UpdateUserRequest) (*apiv1.UpdateUserResponse, error) {
-	// TODO 看懂获取这个userModel的逻辑, 查询逻辑是从哪里写入的 ?
-	userModel, err := b.store.User().Get(ctx, where.T(ctx))
+	ctx, span := tracing.Start(ctx, "UserBiz.Update")
+	defer span.End()
+
+	// rq.UserID 已由 validation 层校验: 普通用户只能更新自己, 管理员可以更新任意用户
+	userModel, err := b.store.User().Get(ctx, where.F("userID", rq.UserID))
 	if err != nil {
 		return nil, err
 	}
@@ -80,8 +190,11 @@ func (b *userBiz) Update(ctx context.Context, rq *apiv1.UpdateUserRequest) (*api
 	if rq.Username != nil {
 		userModel.Username = *rq.Username
 	}
-	if rq.Email != nil {
+	// 修改邮箱后需要重新验证邮箱
+	emailChanged := rq.Email != nil && *rq.Email != userModel.Email
+	if emailChanged {
 		userModel.Email = *rq.Email
+		userModel.EmailVerifiedAt = nil
 	}
 	if rq.Nickname != nil {
 		userModel.Nickname = *rq.Nickname
@@ -89,23 +202,55 @@ func (b *userBiz) Update(ctx context.Context, rq *apiv1.UpdateUserRequest) (*api
 	if rq.Phone != nil {
 		userModel.Phone = *rq.Phone
 	}
+	// 由 store 层的条件更新校验版本号, 版本号过期时返回 ErrVersionConflict
+	if rq.Version != nil {
+		userModel.Version = *rq.Version
+	}
 
 	if err := b.store.User().Update(ctx, userModel); err != nil {
 		return nil, err
 	}
+	if emailChanged {
+		b.sendVerificationEmail(ctx, userModel)
+	}
 
-	return &apiv1.UpdateUserResponse{}, nil
+	return &apiv1.UpdateUserResponse{Version: userModel.Version}, nil
 }
 
 // 实现 UserBiz 接口中的 List 方法.
 func (b *userBiz) List(ctx context.Context, rq *apiv1.ListUserRequest) (*apiv1.ListUserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.List")
+	defer span.End()
+
 	// go 中 int 是32位还是64位取决操作系统
 	whr := where.P(int(rq.Offset), int(rq.Limit))
+	if err := listSchema.Apply(whr, rq.Filter, rq.Sort); err != nil {
+		return nil, err
+	}
+	// 指定 pageToken 时按游标分页
+	if err := listSchema.PageToken(whr, rq.PageToken); err != nil {
+		return nil, err
+	}
+	if rq.SkipTotalCount {
+		whr.NoCount()
+	}
+	// 多查询一条记录, 用于判断是否存在下一页
+	limit := whr.Limit
+	if limit > 0 {
+		whr.L(limit + 1)
+	}
+
 	count, userList, err := b.store.User().List(ctx, whr)
 	if err != nil {
 		return nil, err
 	}
 
+	var nextPageToken string
+	if limit > 0 && len(userList) > limit {
+		userList = userList[:limit]
+		nextPageToken = listSchema.NextPageToken(ctx, whr, userList[limit-1])
+	}
+
 	// 并发安全的 map
 	var m sync.Map
 	// TODO eg是什么?
@@ -123,7 +268,8 @@ func (b *userBiz) List(ctx context.Context, rq *apiv1.ListUserRequest) (*apiv1.L
 			case <-ctx.Done():
 				return nil
 			default:
-				count, _, err := b.store.Post().List(ctx, where.T(ctx))
+				// 统计该用户拥有的博客数量
+				count, _, err := b.store.Post().List(ctx, where.F("userID", user.UserID))
 				if err != nil {
 					return err
 				}
@@ -156,21 +302,69 @@ func (b *userBiz) List(ctx context.Context, rq *apiv1.ListUserRequest) (*apiv1.L
 
 	slog.DebugContext(ctx, "Get users from backend storage", "count", len(users))
 
-	return &apiv1.ListUserResponse{TotalCount: count, Users: users}, nil
+	return &apiv1.ListUserResponse{TotalCount: count, Users: users, NextPageToken: nextPageToken}, nil
 }
 
 // 实现 UserBiz 接口中的 Delete 方法.
 func (b *userBiz) Delete(ctx context.Context, rq *apiv1.DeleteUserRequest) (*apiv1.DeleteUserResponse, error) {
-	if err := b.store.User().Delete(ctx, where.F("userID", contextx.UserID(ctx))); err != nil {
+	ctx, span := tracing.Start(ctx, "UserBiz.Delete")
+	defer span.End()
+
+	if err := b.store.User().Delete(ctx, where.F("userID", rq.UserID)); err != nil {
+		return nil, err
+	}
+
+	// 被删除的用户不能继续使用此前签发的 token 和 refresh token
+	now := time.Now()
+	if err := b.revoker.RevokeUser(ctx, rq.UserID, now, now.Add(token.Expiration())); err != nil {
+		slog.ErrorContext(ctx, "Failed to revoke user tokens", "userID", rq.UserID, "err", err)
+		return nil, errorsx.ErrInternal
+	}
+	if _, err := b.store.RefreshToken().Revoke(ctx, where.F("userID", rq.UserID)); err != nil {
 		return nil, err
 	}
 
 	return &apiv1.DeleteUserResponse{}, nil
 }
 
+// ListTrash 查询回收站中的用户列表.
+func (b *userBiz) ListTrash(ctx context.Context, rq *apiv1.ListTrashUserRequest) (*apiv1.ListTrashUserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.ListTrash")
+	defer span.End()
+
+	count, userList, err := b.store.User().ListTrash(ctx, where.P(int(rq.Offset), int(rq.Limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*apiv1.User, 0, len(userList))
+	for _, user := range userList {
+		users = append(users, conversion.UserodelToUserV1(user))
+	}
+	return &apiv1.ListTrashUserResponse{TotalCount: count, Users: users}, nil
+}
+
+// Restore 从回收站中恢复用户, 用户不在回收站中时返回 ErrUserNotFound.
+func (b *userBiz) Restore(ctx context.Context, rq *apiv1.RestoreUserRequest) (*apiv1.RestoreUserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.Restore")
+	defer span.End()
+
+	restored, err := b.store.User().Restore(ctx, where.F("userID", rq.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if restored == 0 {
+		return nil, errorsx.ErrUserNotFound
+	}
+	return &apiv1.RestoreUserResponse{}, nil
+}
+
 // 实现 UserBiz 接口中的 Get 方法.
 func (b *userBiz) Get(ctx context.Context, rq *apiv1.GetUserRequest) (*apiv1.GetUserResponse, error) {
-	userModel, err := b.store.User().Get(ctx, where.F("userID", contextx.UserID(ctx)))
+	ctx, span := tracing.Start(ctx, "UserBiz.Get")
+	defer span.End()
+
+	userModel, err := b.store.User().Get(ctx, where.F("userID", rq.UserID))
 	if err != nil {
 		return nil, err
 	}
@@ -179,46 +373,287 @@ func (b *userBiz) Get(ctx context.Context, rq *apiv1.GetUserRequest) (*apiv1.Get
 }
 
 // Login 实现 UserBiz 接口的 Login 方法.
-// 用户登录时调用此方法.
-func (b *userBiz) Login(ctx context.Context, rq *apiv1.LoginRequest) (*apiv1.LoginResponse, error) {
-	// 获取用户登录信息
+// 用户登录时调用此方法. 用户启用了两步验证时, 密码校验通过后只返回登录挑战令牌, 由 CompleteLogin 完成登录.
+func (b *userBiz) Login(ctx context.Context, rq *apiv1.LoginRequest) (resp *apiv1.LoginResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.Login")
+	defer span.End()
+
+	// 记录登录成功和失败的次数, 需要两步验证的登录在 CompleteLogin 中记录最终结果
+	defer func() {
+		if err == nil && resp.TwoFactorRequired {
+			metrics.ObserveLoginChallenge()
+			return
+		}
+		metrics.ObserveLogin(err)
+	}()
+
+	// 用户名或客户端 IP 被锁定期间不校验密码
+	account, clientIP := lockoutAccount(ctx, rq.Username), contextx.ClientIP(ctx)
+	if err := b.checkLocked(ctx, account, clientIP); err != nil {
+		return nil, err
+	}
+
+	// 获取用户登录信息, 用户不存在时同样校验一次密码, 使响应时间和响应内容与密码错误时一致
 	whr := where.F("username", rq.Username)
 	userModel, err := b.store.User().Get(ctx, whr)
+	hashed := dummyPassword()
+	if err == nil {
+		hashed = userModel.Password
+	}
+	if cmpErr := authn.Compare(hashed, rq.Password); err != nil || cmpErr != nil {
+		slog.WarnContext(ctx, "用户名或密码错误", "username", rq.Username, "clientIP", clientIP)
+		return nil, b.loginFailed(ctx, account, clientIP, errorsx.ErrLoginFailed)
+	}
+
+	tf, err := b.enabledTwoFactor(ctx, userModel.UserID)
 	if err != nil {
-		return nil, errorsx.ErrUserNotFound
+		return nil, err
+	}
+	// 启用两步验证时, 验证码校验通过后才清除登录失败记录, 避免通过反复使用密码登录绕过验证码的失败次数限制
+	if tf == nil {
+		if err := b.guard.Succeed(ctx, account); err != nil {
+			slog.ErrorContext(ctx, "Failed to reset login failures", "username", rq.Username, "err", err)
+		}
+	}
+	// 要求验证邮箱时, 未验证邮箱的用户不能登录
+	if b.email.RequireVerified && userModel.EmailVerifiedAt == nil {
+		slog.WarnContext(ctx, "Login rejected, email is not verified", "username", rq.Username)
+		return nil, errorsx.ErrEmailNotVerified
 	}
 
-	// 密码校验是否匹配
-	if err := authn.Compare(userModel.Password, rq.Password); err != nil {
-		slog.ErrorContext(ctx, "密码不匹配", "err", err)
-		return nil, errorsx.ErrPasswordInvalid
+	if tf != nil {
+		return b.loginChallenge(ctx, userModel, tf)
 	}
+	return b.issueTokens(ctx, userModel)
+}
 
-	// 成功登录, 签发 token 并返回
-	tokenStr, expireAt, err := token.Sign(userModel.UserID)
+// issueTokens 登录成功, 为用户签发 token 和 refresh token.
+func (b *userBiz) issueTokens(ctx context.Context, userModel *model.User) (*apiv1.LoginResponse, error) {
+	// token 中带有用户所属的租户
+	tokenStr, expireAt, err := token.SignWithClaims(userModel.UserID, map[string]any{known.XTenantID: userModel.TenantID})
 	if err != nil {
 		slog.ErrorContext(ctx, "签发token失败", "err", err)
 		return nil, errorsx.ErrSignToken
 	}
 
-	return &apiv1.LoginResponse{tokenStr, expireAt}, nil
+	// 每次登录开启一个新的 refresh token 家族
+	refreshToken, refreshExpireAt, err := b.issueRefreshToken(ctx, userModel.UserID, uuid.New().String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiv1.LoginResponse{
+		Token:           tokenStr,
+		ExpireAt:        expireAt,
+		RefreshToken:    refreshToken,
+		RefreshExpireAt: refreshExpireAt,
+	}, nil
+}
+
+// loginFailed 记录一次登录失败, 并按照失败次数延迟响应, 返回 failure.
+// 密码错误和两步验证码错误都计入失败次数.
+func (b *userBiz) loginFailed(ctx context.Context, account string, clientIP string, failure *errorsx.ErrorX) error {
+	delay, err := b.guard.Fail(ctx, account, clientIP)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to record login failure", "err", err)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return failure
+}
 
+// checkLocked 在用户名或客户端 IP 被锁定时返回 ErrAccountLocked.
+func (b *userBiz) checkLocked(ctx context.Context, account string, clientIP string) error {
+	remaining, err := b.guard.Locked(ctx, account, clientIP)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		slog.WarnContext(ctx, "Login rejected, account or client IP is locked", "account", account, "clientIP", clientIP, "remaining", remaining)
+		return errorsx.ErrAccountLocked
+	}
+	return nil
 }
 
-// RefreshToken 用于刷新用户的身份验证令牌.
-// 当用户的令牌即将过期时, 调用此方法可生成新的令牌
+// Unlock 解除用户的登录锁定, 并清除用户名的登录失败记录.
+func (b *userBiz) Unlock(ctx context.Context, rq *apiv1.UnlockUserRequest) (*apiv1.UnlockUserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.Unlock")
+	defer span.End()
+
+	userModel, err := b.store.User().Get(ctx, where.F("userID", rq.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if err := b.guard.Unlock(ctx, lockoutAccount(ctx, userModel.Username)); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "User unlocked", "userID", userModel.UserID, "username", userModel.Username)
+
+	return &apiv1.UnlockUserResponse{}, nil
+}
+
+// lockoutAccount 返回记录登录失败时使用的账号, 不同租户中的同名用户分别记录.
+func lockoutAccount(ctx context.Context, username string) string {
+	if tenant, ok := where.RegisteredTenant(); ok {
+		return tenant.ValueFunc(ctx) + "/" + username
+	}
+	return username
+}
+
+// RefreshToken 使用 refresh token 换取新的身份验证令牌.
+// 每次刷新都会轮换 refresh token: 旧的 refresh token 被吊销, 同时签发一个属于同一家族的新 refresh token.
+// 如果已被轮换或吊销的 refresh token 被再次使用, 说明 refresh token 可能已泄露, 此时吊销整个家族.
 func (b *userBiz) RefreshToken(ctx context.Context, rq *apiv1.RefreshTokenRequest) (*apiv1.RefreshTokenResponse, error) {
-	tokenStr, expireAt, err := token.Sign(contextx.UserID(ctx))
+	ctx, span := tracing.Start(ctx, "UserBiz.RefreshToken")
+	defer span.End()
+
+	rt, err := b.store.RefreshToken().Get(ctx, where.F("tokenHash", token.HashRefreshToken(rq.RefreshToken)))
 	if err != nil {
-		return nil, errorsx.ErrSignToken.WithMessage(err.Error())
+		return nil, err
+	}
+
+	// 重放检测
+	if rt.RevokedAt != nil {
+		slog.WarnContext(ctx, "Refresh token reuse detected, revoking token family", "userID", rt.UserID, "familyID", rt.FamilyID)
+		if _, err := b.store.RefreshToken().Revoke(ctx, where.F("familyID", rt.FamilyID)); err != nil {
+			return nil, err
+		}
+		return nil, errorsx.ErrRefreshTokenInvalid
+	}
+
+	if time.Now().After(rt.ExpiresAt) {
+		return nil, errorsx.ErrRefreshTokenInvalid
+	}
+
+	// 用户已被删除时, 其 refresh token 同样失效
+	if _, err := b.store.User().Get(ctx, where.F("userID", rt.UserID)); err != nil {
+		if errors.Is(err, errorsx.ErrUserNotFound) {
+			return nil, errorsx.ErrRefreshTokenInvalid
+		}
+		return nil, err
+	}
+
+	var resp apiv1.RefreshTokenResponse
+	var reused bool
+	err = b.store.TX(ctx, func(ctx context.Context) error {
+		// 吊销当前 refresh token, 并签发同一家族的新 refresh token
+		revoked, err := b.store.RefreshToken().Revoke(ctx, where.F("id", rt.ID))
+		if err != nil {
+			return err
+		}
+		// 条件更新没有吊销任何记录, 说明读取之后该 refresh token 已被并发请求使用, 同样视为重放.
+		// 在事务中吊销整个家族并提交, 事务之外再返回错误
+		if revoked == 0 {
+			slog.WarnContext(ctx, "Concurrent refresh token reuse detected, revoking token family", "familyID", rt.FamilyID)
+			reused = true
+			_, err := b.store.RefreshToken().Revoke(ctx, where.F("familyID", rt.FamilyID))
+			return err
+		}
+
+		refreshToken, refreshExpireAt, err := b.issueRefreshToken(ctx, rt.UserID, rt.FamilyID)
+		if err != nil {
+			return err
+		}
+		resp.RefreshToken, resp.RefreshExpireAt = refreshToken, refreshExpireAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return &apiv1.RefreshTokenResponse{Token: tokenStr, ExpireAt: expireAt}, nil
+	if reused {
+		return nil, errorsx.ErrRefreshTokenInvalid
+	}
+
+	resp.Token, resp.ExpireAt, err = token.SignWithClaims(rt.UserID, map[string]any{known.XTenantID: rt.TenantID})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to sign token", "err", err)
+		return nil, errorsx.ErrSignToken
+	}
+
+	return &resp, nil
+}
+
+// Logout 退出登录, 吊销当前请求使用的 token.
+// 如果请求中带有 refresh token, 同时吊销该 refresh token 所在的家族, 使其无法再换取新的 token.
+func (b *userBiz) Logout(ctx context.Context, rq *apiv1.LogoutRequest) (*apiv1.LogoutResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.Logout")
+	defer span.End()
+
+	userID := contextx.UserID(ctx)
+
+	// 吊销记录只需要保存到 token 过期为止
+	if err := b.revoker.Revoke(ctx, contextx.TokenID(ctx), contextx.TokenExpireAt(ctx)); err != nil {
+		slog.ErrorContext(ctx, "Failed to revoke token", "err", err)
+		return nil, errorsx.ErrInternal
+	}
+
+	if rq.RefreshToken != "" {
+		rt, err := b.store.RefreshToken().Get(ctx, where.F("tokenHash", token.HashRefreshToken(rq.RefreshToken)))
+		// 只允许吊销自己的 refresh token, 无效的 refresh token 直接忽略
+		if err == nil && rt.UserID == userID {
+			if _, err := b.store.RefreshToken().Revoke(ctx, where.F("familyID", rt.FamilyID)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &apiv1.LogoutResponse{}, nil
+}
+
+// LogoutAll 退出所有设备, 吊销当前用户已签发的所有 token 和 refresh token.
+func (b *userBiz) LogoutAll(ctx context.Context, rq *apiv1.LogoutAllRequest) (*apiv1.LogoutAllResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.LogoutAll")
+	defer span.End()
+
+	userID := contextx.UserID(ctx)
+
+	// 此前签发的 token 最晚在 now + token 有效期时过期, 吊销记录保存到此时即可
+	now := time.Now()
+	if err := b.revoker.RevokeUser(ctx, userID, now, now.Add(token.Expiration())); err != nil {
+		slog.ErrorContext(ctx, "Failed to revoke user tokens", "err", err)
+		return nil, errorsx.ErrInternal
+	}
+
+	if _, err := b.store.RefreshToken().Revoke(ctx, where.F("userID", userID)); err != nil {
+		return nil, err
+	}
+
+	return &apiv1.LogoutAllResponse{}, nil
+}
+
+// issueRefreshToken 为用户签发一个属于 familyID 家族的 refresh token, 并持久化其哈希值.
+func (b *userBiz) issueRefreshToken(ctx context.Context, userID string, familyID string) (string, time.Time, error) {
+	refreshToken, hash, expireAt, err := token.SignRefreshToken()
+	if err != nil {
+		slog.ErrorContext(ctx, "签发refresh token失败", "err", err)
+		return "", time.Time{}, errorsx.ErrSignToken
+	}
+
+	rt := &model.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: expireAt,
+	}
+	if err := b.store.RefreshToken().Create(ctx, rt); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return refreshToken, expireAt, nil
 }
 
 // ChangePassword 实现 UserBiz 接口中的 ChangePassword 方法.
 // 用户变更密码时调用此方法.
 func (b *userBiz) ChangePassword(ctx context.Context, rq *apiv1.ChangePasswordRequest) (*apiv1.ChangePasswordResponse, error) {
-	userModel, err := b.store.User().Get(ctx, where.T(ctx))
+	ctx, span := tracing.Start(ctx, "UserBiz.ChangePassword")
+	defer span.End()
+
+	userModel, err := b.store.User().Get(ctx, where.F("userID", contextx.UserID(ctx)))
 	if err != nil {
 		return nil, err
 	}
@@ -229,12 +664,263 @@ func (b *userBiz) ChangePassword(ctx context.Context, rq *apiv1.ChangePasswordRe
 		return nil, errorsx.ErrPasswordInvalid
 	}
 
-	// 更新密码
+	if err := b.updatePassword(ctx, userModel, rq.NewPassword); err != nil {
+		return nil, err
+	}
+
+	return &apiv1.ChangePasswordResponse{}, nil
+}
+
+// updatePassword 将用户的密码修改为 newPassword, 不能重复使用最近使用过的密码.
+func (b *userBiz) updatePassword(ctx context.Context, userModel *model.User, newPassword string) error {
+	if err := b.checkPasswordHistory(ctx, userModel, newPassword); err != nil {
+		return err
+	}
+
+	// 更新密码, 并在同一个事务中将被替换的密码记入历史密码
+	// 校验只需要当前密码之外最近的 History-1 个历史密码, 更早的记录同时删除, 避免历史密码无限增长
 	// authn.Encrypt 对密码进行加密
-	userModel.Password, _ = authn.Encrypt(rq.NewPassword)
+	previous := userModel.Password
+	userModel.Password, _ = authn.Encrypt(newPassword)
+	return b.store.TX(ctx, func(ctx context.Context) error {
+		if b.policy.History > 1 {
+			if err := b.store.PasswordHistory().Create(ctx, &model.PasswordHistory{UserID: userModel.UserID, Password: previous}); err != nil {
+				return err
+			}
+			if err := b.store.PasswordHistory().Prune(ctx, userModel.UserID, b.policy.History-1); err != nil {
+				return err
+			}
+		}
+		return b.store.User().Update(ctx, userModel)
+	})
+}
+
+// checkPasswordHistory 校验新密码 newPassword 不是用户最近使用过的 policy.History 个密码之一(包括当前密码).
+func (b *userBiz) checkPasswordHistory(ctx context.Context, userModel *model.User, newPassword string) error {
+	if b.policy.History <= 0 {
+		return nil
+	}
+
+	hashes := []string{userModel.Password}
+	if b.policy.History > 1 {
+		_, history, err := b.store.PasswordHistory().List(ctx, where.F("userID", userModel.UserID).L(b.policy.History-1).NoCount())
+		if err != nil {
+			return err
+		}
+		for _, h := range history {
+			hashes = append(hashes, h.Password)
+		}
+	}
+
+	for _, hashed := range hashes {
+		if authn.Compare(hashed, newPassword) == nil {
+			msg := fmt.Sprintf("Password must not be one of the last %d passwords", b.policy.History)
+			return errorsx.New(errorsx.ErrPasswordPolicy.Code, errorsx.ErrPasswordPolicy.Reason, "%s", msg).KV("history", msg)
+		}
+	}
+	return nil
+}
+
+// UpdateRole 实现 UserBiz 接口中的 UpdateRole 方法.
+// 管理员修改用户角色时调用此方法, 角色变更在用户的下一次请求中立即生效.
+func (b *userBiz) UpdateRole(ctx context.Context, rq *apiv1.UpdateUserRoleRequest) (*apiv1.UpdateUserRoleResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.UpdateRole")
+	defer span.End()
+
+	userModel, err := b.store.User().Get(ctx, where.F("userID", rq.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	userModel.Role = rq.Role
 	if err := b.store.User().Update(ctx, userModel); err != nil {
 		return nil, err
 	}
 
-	return &apiv1.ChangePasswordResponse{}, nil
+	return &apiv1.UpdateUserRoleResponse{}, nil
+}
+
+// SendVerificationEmail 向使用 rq.Email 且未验证邮箱的用户重新发送验证邮件.
+// 无论邮箱是否存在都返回成功, 避免邮箱被枚举.
+func (b *userBiz) SendVerificationEmail(ctx context.Context, rq *apiv1.SendVerificationEmailRequest) (*apiv1.SendVerificationEmailResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.SendVerificationEmail")
+	defer span.End()
+
+	_, userList, err := b.store.User().List(ctx, where.F("email", rq.Email).L(maxAccountsPerEmail).NoCount())
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range userList {
+		if user.EmailVerifiedAt == nil {
+			b.sendVerificationEmail(ctx, user)
+		}
+	}
+
+	return &apiv1.SendVerificationEmailResponse{}, nil
+}
+
+// VerifyEmail 使用验证邮件中的令牌验证用户的邮箱.
+// 令牌中带有签发时的邮箱, 邮箱已验证或已修改时令牌失效, 因此每个令牌只能使用一次.
+func (b *userBiz) VerifyEmail(ctx context.Context, rq *apiv1.VerifyEmailRequest) (*apiv1.VerifyEmailResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.VerifyEmail")
+	defer span.End()
+
+	ctx, userModel, err := b.userFromActionToken(ctx, rq.Token, known.ActionVerifyEmail, errorsx.ErrVerificationTokenInvalid)
+	if err != nil {
+		return nil, err
+	}
+	if userModel.EmailVerifiedAt != nil {
+		return nil, errorsx.ErrVerificationTokenInvalid
+	}
+
+	now := time.Now()
+	userModel.EmailVerifiedAt = &now
+	if err := b.store.User().Update(ctx, userModel); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Email verified", "userID", userModel.UserID, "email", userModel.Email)
+
+	return &apiv1.VerifyEmailResponse{}, nil
+}
+
+// RequestPasswordReset 向使用 rq.Email 的用户发送重置密码邮件.
+// 无论邮箱是否存在都返回成功, 避免邮箱被枚举.
+func (b *userBiz) RequestPasswordReset(ctx context.Context, rq *apiv1.RequestPasswordResetRequest) (*apiv1.RequestPasswordResetResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.RequestPasswordReset")
+	defer span.End()
+
+	_, userList, err := b.store.User().List(ctx, where.F("email", rq.Email).L(maxAccountsPerEmail).NoCount())
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range userList {
+		link, expireAt, err := b.actionLink(ctx, b.email.ResetURL, known.ActionResetPassword, user, b.email.ResetExpiration)
+		if err != nil {
+			return nil, err
+		}
+		b.sendMail(ctx, &mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Hi %s,\n\nSomeone requested a password reset for your account. Open the link below to choose a new password:\n\n%s\n\n"+
+				"The link can only be used once and expires at %s. If you did not request a password reset, you can ignore this email.\n",
+				user.Username, link, expireAt.Format(time.RFC1123)),
+		})
+	}
+
+	return &apiv1.RequestPasswordResetResponse{}, nil
+}
+
+// ConfirmPasswordReset 使用重置密码邮件中的令牌重置用户的密码.
+// 令牌中带有签发时的密码哈希和邮箱, 密码重置后令牌失效, 因此每个令牌只能使用一次.
+// 重置密码后吊销用户已签发的所有 token 并解除登录锁定, 由于重置密码同时证明了用户拥有该邮箱, 邮箱被标记为已验证.
+func (b *userBiz) ConfirmPasswordReset(ctx context.Context, rq *apiv1.ConfirmPasswordResetRequest) (*apiv1.ConfirmPasswordResetResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserBiz.ConfirmPasswordReset")
+	defer span.End()
+
+	ctx, userModel, err := b.userFromActionToken(ctx, rq.Token, known.ActionResetPassword, errorsx.ErrPasswordResetTokenInvalid)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if userModel.EmailVerifiedAt == nil {
+		userModel.EmailVerifiedAt = &now
+	}
+	if err := b.updatePassword(ctx, userModel, rq.NewPassword); err != nil {
+		return nil, err
+	}
+
+	// 密码可能已经泄露, 吊销此前签发的所有 token 和 refresh token
+	if err := b.revoker.RevokeUser(ctx, userModel.UserID, now, now.Add(token.Expiration())); err != nil {
+		slog.ErrorContext(ctx, "Failed to revoke user tokens", "userID", userModel.UserID, "err", err)
+		return nil, errorsx.ErrInternal
+	}
+	if _, err := b.store.RefreshToken().Revoke(ctx, where.F("userID", userModel.UserID)); err != nil {
+		return nil, err
+	}
+	if err := b.guard.Unlock(ctx, lockoutAccount(ctx, userModel.Username)); err != nil {
+		slog.ErrorContext(ctx, "Failed to reset login failures", "username", userModel.Username, "err", err)
+	}
+	slog.InfoContext(ctx, "Password reset", "userID", userModel.UserID)
+
+	return &apiv1.ConfirmPasswordResetResponse{}, nil
+}
+
+// sendVerificationEmail 向用户发送验证邮件, 失败时只记录日志.
+func (b *userBiz) sendVerificationEmail(ctx context.Context, userModel *model.User) {
+	// 签发失败时 actionLink 已记录日志
+	link, expireAt, err := b.actionLink(ctx, b.email.VerifyURL, known.ActionVerifyEmail, userModel, b.email.VerifyExpiration)
+	if err != nil {
+		return
+	}
+	b.sendMail(ctx, &mailer.Message{
+		To:      userModel.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease open the link below to verify your email address:\n\n%s\n\n"+
+			"The link can only be used once and expires at %s. If you did not create an account, you can ignore this email.\n",
+			userModel.Username, link, expireAt.Format(time.RFC1123)),
+	})
+}
+
+// sendMail 在后台发送邮件, 发送失败时只记录日志.
+// 异步发送避免邮件服务器的响应时间影响接口的响应时间, 同时避免通过响应时间判断邮箱是否存在.
+func (b *userBiz) sendMail(ctx context.Context, msg *mailer.Message) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := b.mailer.Send(ctx, msg); err != nil {
+			slog.ErrorContext(ctx, "Failed to send mail", "to", msg.To, "subject", msg.Subject, "err", err)
+		}
+	}()
+}
+
+// actionLink 为用户签发用途为 purpose 的一次性令牌, 返回带有令牌的链接和链接的过期时间.
+func (b *userBiz) actionLink(ctx context.Context, base string, purpose string, userModel *model.User, ttl time.Duration) (string, time.Time, error) {
+	tokenStr, expireAt, err := token.SignAction(purpose, userModel.UserID, userModel.TenantID, actionState(purpose, userModel), ttl)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to sign action token", "purpose", purpose, "userID", userModel.UserID, "err", err)
+		return "", time.Time{}, errorsx.ErrSignToken
+	}
+
+	link, err := url.Parse(base)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse action link base url", "url", base, "err", err)
+		return "", time.Time{}, errorsx.ErrInternal
+	}
+	query := link.Query()
+	query.Set("token", tokenStr)
+	link.RawQuery = query.Encode()
+	return link.String(), expireAt, nil
+}
+
+// userFromActionToken 校验用途为 purpose 的一次性令牌, 返回带有令牌中租户的上下文和令牌对应的用户.
+// 令牌无效、用户不存在或用户状态已变化时返回 invalid.
+func (b *userBiz) userFromActionToken(ctx context.Context, tokenStr string, purpose string, invalid *errorsx.ErrorX) (context.Context, *model.User, error) {
+	claims, err := token.ParseAction(tokenStr, purpose)
+	if err != nil {
+		return ctx, nil, invalid
+	}
+	if claims.Tenant != "" {
+		ctx = contextx.WithTenantID(ctx, claims.Tenant)
+	}
+
+	userModel, err := b.store.User().Get(ctx, where.F("userID", claims.Subject))
+	if err != nil {
+		if errors.Is(err, errorsx.ErrUserNotFound) {
+			return ctx, nil, invalid
+		}
+		return ctx, nil, err
+	}
+	if !claims.Matches(actionState(purpose, userModel)) {
+		return ctx, nil, invalid
+	}
+	return ctx, userModel, nil
+}
+
+// actionState 返回签发一次性令牌时用户的状态, 状态变化后令牌失效.
+// 验证邮箱的令牌与邮箱绑定; 重置密码的令牌与密码哈希和邮箱绑定, 密码修改后令牌失效.
+func actionState(purpose string, userModel *model.User) string {
+	if purpose == known.ActionResetPassword {
+		return userModel.Password + "\x00" + userModel.Email
+	}
+	return userModel.Email
 }
@@ -0,0 +1,630 @@
+package user
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/apiserver/store/fake"
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/lockout"
+	"fastgo/internal/pkg/mailer"
+	"fastgo/internal/pkg/password"
+	"fastgo/internal/pkg/revocation"
+	where "fastgo/pkg/store"
+	"fastgo/pkg/token"
+
+	apiv1 "fastgo/pkg/api/apiserver/v1"
+	"github.com/gin-gonic/gin"
+)
+
+// testPassword 为测试用户的初始密码.
+const testPassword = "Passw0rd!x"
+
+// newTestBiz 创建一个使用内存 store 的 userBiz, 各个测试用例之间相互隔离.
+func newTestBiz(t *testing.T) (*userBiz, store.IStore) {
+	t.Helper()
+
+	ds := fake.NewStore()
+	guard := lockout.New(lockout.NewMemory(), lockout.Policy{MaxFailures: 5, MaxIPFailures: 20, Window: time.Minute, Duration: time.Minute})
+	b := New(ds, revocation.NewMemory(), guard, &password.Policy{}, mailer.NewWriter("fastgo", io.Discard),
+		EmailConfig{VerifyURL: "http://localhost/verify", ResetURL: "http://localhost/reset", VerifyExpiration: time.Hour, ResetExpiration: time.Hour},
+		TwoFactorConfig{Issuer: "fastgo", ChallengeExpiration: time.Minute, RecoveryCodes: 4, Skew: 1},
+		OIDCConfig{})
+	return b, ds
+}
+
+// createUser 创建一个密码为 testPassword 的用户, 返回用户 ID.
+func createUser(t *testing.T, b *userBiz, ctx context.Context, username string) string {
+	t.Helper()
+
+	resp, err := b.Create(ctx, &apiv1.CreateUserRequest{Username: username, Password: testPassword, Email: username + "@example.com", Phone: "13800000000"})
+	if err != nil {
+		t.Fatalf("Create(%s) error = %v", username, err)
+	}
+	return resp.UserID
+}
+
+// wantError 校验 err 与 want 的 Reason 一致, want 为 nil 时校验 err 为 nil.
+func wantError(t *testing.T, err error, want *errorsx.ErrorX) {
+	t.Helper()
+
+	if want == nil {
+		if err != nil {
+			t.Fatalf("error = %v, want nil", err)
+		}
+		return
+	}
+	if got := errorsx.FromError(err); got == nil || got.Reason != want.Reason {
+		t.Fatalf("error = %v, want reason %s", err, want.Reason)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	b, _ := newTestBiz(t)
+	ctx := context.Background()
+	createUser(t, b, ctx, "alice")
+
+	tests := []struct {
+		name     string
+		username string
+		wantErr  *errorsx.ErrorX
+	}{
+		{name: "new username", username: "bob"},
+		{name: "duplicate username", username: "alice", wantErr: errorsx.ErrDBWrite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := b.Create(ctx, &apiv1.CreateUserRequest{Username: tt.username, Password: testPassword, Email: "a@example.com", Phone: "13800000000"})
+			wantError(t, err, tt.wantErr)
+			if err != nil {
+				return
+			}
+
+			got, err := b.Get(contextx.WithUserID(ctx, resp.UserID), &apiv1.GetUserRequest{UserID: resp.UserID})
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got.User.Username != tt.username || got.User.Role == "" || got.User.Version != 1 {
+				t.Errorf("Get() = %+v, want username %s with default role and version 1", got.User, tt.username)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	b, _ := newTestBiz(t)
+	userID := createUser(t, b, context.Background(), "alice")
+	createUser(t, b, context.Background(), "bob")
+	ctx := contextx.WithUserID(context.Background(), userID)
+
+	nickname, taken, stale := "Alice", "bob", int64(0)
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		rq      *apiv1.UpdateUserRequest
+		wantErr *errorsx.ErrorX
+	}{
+		{name: "update nickname", ctx: ctx, rq: &apiv1.UpdateUserRequest{UserID: userID, Nickname: &nickname}},
+		{name: "username taken", ctx: ctx, rq: &apiv1.UpdateUserRequest{UserID: userID, Username: &taken}, wantErr: errorsx.ErrDBWrite},
+		{name: "stale version", ctx: ctx, rq: &apiv1.UpdateUserRequest{UserID: userID, Nickname: &nickname, Version: &stale}, wantErr: errorsx.ErrVersionConflict},
+		{
+			name:    "user not found",
+			ctx:     contextx.WithUserID(context.Background(), "user-missing"),
+			rq:      &apiv1.UpdateUserRequest{UserID: "user-missing", Nickname: &nickname},
+			wantErr: errorsx.ErrUserNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := b.Update(tt.ctx, tt.rq)
+			wantError(t, err, tt.wantErr)
+		})
+	}
+
+	got, err := b.Get(ctx, &apiv1.GetUserRequest{UserID: userID})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.User.Nickname != nickname || got.User.Username != "alice" || got.User.Version != 2 {
+		t.Errorf("Get() = %+v, want only the nickname updated once", got.User)
+	}
+}
+
+func TestList(t *testing.T) {
+	b, _ := newTestBiz(t)
+	ctx := context.Background()
+	for _, username := range []string{"alice", "bob", "carol", "dave"} {
+		createUser(t, b, ctx, username)
+	}
+
+	tests := []struct {
+		name      string
+		rq        *apiv1.ListUserRequest
+		want      []string
+		wantCount int64
+		wantErr   *errorsx.ErrorX
+	}{
+		{name: "default order", rq: &apiv1.ListUserRequest{Limit: 10}, want: []string{"dave", "carol", "bob", "alice"}, wantCount: 4},
+		{name: "sort by username", rq: &apiv1.ListUserRequest{Limit: 10, Sort: "username"}, want: []string{"alice", "bob", "carol", "dave"}, wantCount: 4},
+		{name: "second page", rq: &apiv1.ListUserRequest{Offset: 2, Limit: 2, Sort: "username"}, want: []string{"carol", "dave"}, wantCount: 4},
+		{name: "skip total count", rq: &apiv1.ListUserRequest{Limit: 1, SkipTotalCount: true}, want: []string{"dave"}, wantCount: -1},
+		{name: "filter", rq: &apiv1.ListUserRequest{Limit: 10, Filter: "username~a", Sort: "-username"}, want: []string{"dave", "carol", "alice"}, wantCount: 3},
+		{name: "unknown field", rq: &apiv1.ListUserRequest{Limit: 10, Filter: "password=x"}, wantErr: errorsx.ErrInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := b.List(ctx, tt.rq)
+			wantError(t, err, tt.wantErr)
+			if err != nil {
+				return
+			}
+
+			if resp.TotalCount != tt.wantCount {
+				t.Errorf("TotalCount = %d, want %d", resp.TotalCount, tt.wantCount)
+			}
+			if got := usernames(resp.Users); !slices.Equal(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListPageToken(t *testing.T) {
+	b, _ := newTestBiz(t)
+	ctx := context.Background()
+	for _, username := range []string{"alice", "bob", "carol", "dave", "erin"} {
+		createUser(t, b, ctx, username)
+	}
+
+	var got []string
+	rq := &apiv1.ListUserRequest{Limit: 2, Sort: "username"}
+	for range 5 {
+		resp, err := b.List(ctx, rq)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		got = append(got, usernames(resp.Users)...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		rq.PageToken = resp.NextPageToken
+	}
+	if want := []string{"alice", "bob", "carol", "dave", "erin"}; !slices.Equal(got, want) {
+		t.Errorf("paged List() = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	b, _ := newTestBiz(t)
+	ctx := context.Background()
+	userID := createUser(t, b, ctx, "alice")
+
+	if _, err := b.Delete(ctx, &apiv1.DeleteUserRequest{UserID: userID}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	_, err := b.Get(ctx, &apiv1.GetUserRequest{UserID: userID})
+	wantError(t, err, errorsx.ErrUserNotFound)
+
+	trash, err := b.ListTrash(ctx, &apiv1.ListTrashUserRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListTrash() error = %v", err)
+	}
+	if trash.TotalCount != 1 || trash.Users[0].UserID != userID {
+		t.Fatalf("ListTrash() = %+v, want the deleted user", trash)
+	}
+
+	if _, err := b.Restore(ctx, &apiv1.RestoreUserRequest{UserID: userID}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if _, err := b.Get(ctx, &apiv1.GetUserRequest{UserID: userID}); err != nil {
+		t.Fatalf("Get() after Restore() error = %v", err)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	b, _ := newTestBiz(t)
+	b.policy = &password.Policy{History: 3}
+	ctx := context.Background()
+	userID := createUser(t, b, ctx, "alice")
+	ctx = contextx.WithUserID(ctx, userID)
+
+	current := testPassword
+	tests := []struct {
+		name        string
+		oldPassword string
+		newPassword string
+		wantErr     *errorsx.ErrorX
+	}{
+		{name: "wrong old password", oldPassword: "Wrong0!xyz", newPassword: "Passw0rd!1", wantErr: errorsx.ErrPasswordInvalid},
+		{name: "first change", newPassword: "Passw0rd!1"},
+		{name: "second change", newPassword: "Passw0rd!2"},
+		{name: "reuse current password", newPassword: "Passw0rd!2", wantErr: errorsx.ErrPasswordPolicy},
+		{name: "reuse password within history", newPassword: testPassword, wantErr: errorsx.ErrPasswordPolicy},
+		{name: "third change", newPassword: "Passw0rd!3"},
+		{name: "reuse password beyond history", newPassword: testPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldPassword := tt.oldPassword
+			if oldPassword == "" {
+				oldPassword = current
+			}
+			_, err := b.ChangePassword(ctx, &apiv1.ChangePasswordRequest{UserID: userID, OldPassword: oldPassword, NewPassword: tt.newPassword})
+			wantError(t, err, tt.wantErr)
+			if err == nil {
+				current = tt.newPassword
+			}
+		})
+	}
+
+	// 只保留校验所需的 History-1 个历史密码
+	count, _, err := b.store.PasswordHistory().List(ctx, where.F("userID", userID))
+	if err != nil {
+		t.Fatalf("PasswordHistory().List() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("password history count = %d, want 2", count)
+	}
+}
+
+// usernames 返回用户列表中的用户名.
+func usernames(users []*apiv1.User) []string {
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Username)
+	}
+	return names
+}
+
+// login 使用 testPassword 登录, 返回登录结果.
+func login(t *testing.T, b *userBiz, ctx context.Context, username string) *apiv1.LoginResponse {
+	t.Helper()
+
+	resp, err := b.Login(ctx, &apiv1.LoginRequest{Username: username, Password: testPassword})
+	if err != nil {
+		t.Fatalf("Login(%s) error = %v", username, err)
+	}
+	return resp
+}
+
+func TestRefreshToken(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare 返回用于刷新的 refresh token
+		prepare func(t *testing.T, b *userBiz, ctx context.Context) string
+		wantErr *errorsx.ErrorX
+	}{
+		{
+			name: "valid token",
+			prepare: func(t *testing.T, b *userBiz, ctx context.Context) string {
+				return login(t, b, ctx, "alice").RefreshToken
+			},
+		},
+		{
+			name: "unknown token",
+			prepare: func(t *testing.T, b *userBiz, ctx context.Context) string {
+				return "unknown"
+			},
+			wantErr: errorsx.ErrRefreshTokenInvalid,
+		},
+		{
+			name: "expired token",
+			prepare: func(t *testing.T, b *userBiz, ctx context.Context) string {
+				refreshToken := login(t, b, ctx, "alice").RefreshToken
+				rt, err := b.store.RefreshToken().Get(ctx, where.F("tokenHash", token.HashRefreshToken(refreshToken)))
+				if err != nil {
+					t.Fatalf("RefreshToken().Get() error = %v", err)
+				}
+				rt.ExpiresAt = time.Now().Add(-time.Second)
+				if err := b.store.RefreshToken().Update(ctx, rt); err != nil {
+					t.Fatalf("RefreshToken().Update() error = %v", err)
+				}
+				return refreshToken
+			},
+			wantErr: errorsx.ErrRefreshTokenInvalid,
+		},
+		{
+			name: "rotated token",
+			prepare: func(t *testing.T, b *userBiz, ctx context.Context) string {
+				refreshToken := login(t, b, ctx, "alice").RefreshToken
+				if _, err := b.RefreshToken(ctx, &apiv1.RefreshTokenRequest{RefreshToken: refreshToken}); err != nil {
+					t.Fatalf("RefreshToken() error = %v", err)
+				}
+				return refreshToken
+			},
+			wantErr: errorsx.ErrRefreshTokenInvalid,
+		},
+		{
+			name: "deleted user",
+			prepare: func(t *testing.T, b *userBiz, ctx context.Context) string {
+				refreshToken := login(t, b, ctx, "alice").RefreshToken
+				// 绕过 Delete 的吊销, 只校验用户是否存在
+				if err := b.store.User().Delete(ctx, where.F("username", "alice")); err != nil {
+					t.Fatalf("User().Delete() error = %v", err)
+				}
+				return refreshToken
+			},
+			wantErr: errorsx.ErrRefreshTokenInvalid,
+		},
+		{
+			name: "logged out",
+			prepare: func(t *testing.T, b *userBiz, ctx context.Context) string {
+				resp := login(t, b, ctx, "alice")
+				userModel, err := b.store.User().Get(ctx, where.F("username", "alice"))
+				if err != nil {
+					t.Fatalf("User().Get() error = %v", err)
+				}
+				ctx = contextx.WithUserID(ctx, userModel.UserID)
+				if _, err := b.Logout(ctx, &apiv1.LogoutRequest{RefreshToken: resp.RefreshToken}); err != nil {
+					t.Fatalf("Logout() error = %v", err)
+				}
+				return resp.RefreshToken
+			},
+			wantErr: errorsx.ErrRefreshTokenInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBiz(t)
+			ctx := context.Background()
+			createUser(t, b, ctx, "alice")
+
+			refreshToken := tt.prepare(t, b, ctx)
+			resp, err := b.RefreshToken(ctx, &apiv1.RefreshTokenRequest{RefreshToken: refreshToken})
+			wantError(t, err, tt.wantErr)
+			if err != nil {
+				return
+			}
+			if resp.Token == "" || resp.RefreshToken == "" || resp.RefreshToken == refreshToken {
+				t.Errorf("RefreshToken() = %+v, want a new token and a rotated refresh token", resp)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	b, _ := newTestBiz(t)
+	ctx := context.Background()
+	createUser(t, b, ctx, "alice")
+
+	first := login(t, b, ctx, "alice").RefreshToken
+	other := login(t, b, ctx, "alice").RefreshToken
+	second, err := b.RefreshToken(ctx, &apiv1.RefreshTokenRequest{RefreshToken: first})
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+
+	// 重放已轮换的 refresh token 吊销整个家族, 包括轮换后签发的 refresh token
+	_, err = b.RefreshToken(ctx, &apiv1.RefreshTokenRequest{RefreshToken: first})
+	wantError(t, err, errorsx.ErrRefreshTokenInvalid)
+	_, err = b.RefreshToken(ctx, &apiv1.RefreshTokenRequest{RefreshToken: second.RefreshToken})
+	wantError(t, err, errorsx.ErrRefreshTokenInvalid)
+
+	// 其他家族不受影响
+	if _, err := b.RefreshToken(ctx, &apiv1.RefreshTokenRequest{RefreshToken: other}); err != nil {
+		t.Fatalf("RefreshToken() of another family error = %v", err)
+	}
+}
+
+func TestRefreshTokenConcurrentReuse(t *testing.T) {
+	b, _ := newTestBiz(t)
+	ctx := context.Background()
+	createUser(t, b, ctx, "alice")
+	refreshToken := login(t, b, ctx, "alice").RefreshToken
+
+	// 同一个 refresh token 被并发使用时只有一个请求成功, 其余请求视为重放
+	const n = 8
+	results := make(chan *apiv1.RefreshTokenResponse, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp, err := b.RefreshToken(ctx, &apiv1.RefreshTokenRequest{RefreshToken: refreshToken}); err == nil {
+				results <- resp
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	var succeeded []*apiv1.RefreshTokenResponse
+	for resp := range results {
+		succeeded = append(succeeded, resp)
+	}
+	if len(succeeded) != 1 {
+		t.Fatalf("%d concurrent refreshes succeeded, want 1", len(succeeded))
+	}
+
+	// 重放吊销了整个家族, 成功轮换得到的 refresh token 同样失效
+	_, err := b.RefreshToken(ctx, &apiv1.RefreshTokenRequest{RefreshToken: succeeded[0].RefreshToken})
+	wantError(t, err, errorsx.ErrRefreshTokenInvalid)
+}
+
+// isRevoked 解析 tokenString 中的声明, 返回 token 是否已被吊销.
+func isRevoked(t *testing.T, b *userBiz, tokenString string) bool {
+	t.Helper()
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
+	claims, err := token.ParseRequestClaims(c)
+	if err != nil {
+		t.Fatalf("ParseRequestClaims() error = %v", err)
+	}
+	revoked, err := b.revoker.IsRevoked(context.Background(), claims.ID, claims.Identity, claims.IssuedAt)
+	if err != nil {
+		t.Fatalf("IsRevoked() error = %v", err)
+	}
+	return revoked
+}
+
+func TestLogoutAll(t *testing.T) {
+	b, _ := newTestBiz(t)
+	userID := createUser(t, b, context.Background(), "alice")
+	ctx := contextx.WithUserID(context.Background(), userID)
+	// 在一秒的开始登录, 使得 LogoutAll 之后的再次登录与 LogoutAll 处于同一秒
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	before := login(t, b, ctx, "alice")
+
+	if _, err := b.LogoutAll(ctx, &apiv1.LogoutAllRequest{}); err != nil {
+		t.Fatalf("LogoutAll() error = %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	after := login(t, b, ctx, "alice")
+
+	// 此前签发的 token 和 refresh token 全部失效
+	if !isRevoked(t, b, before.Token) {
+		t.Error("token issued before LogoutAll() is not revoked")
+	}
+	_, err := b.RefreshToken(ctx, &apiv1.RefreshTokenRequest{RefreshToken: before.RefreshToken})
+	wantError(t, err, errorsx.ErrRefreshTokenInvalid)
+
+	// 同一秒内再次登录签发的 token 和 refresh token 仍然有效
+	if isRevoked(t, b, after.Token) {
+		t.Error("token issued after LogoutAll() in the same second is revoked")
+	}
+	if _, err := b.RefreshToken(ctx, &apiv1.RefreshTokenRequest{RefreshToken: after.RefreshToken}); err != nil {
+		t.Errorf("RefreshToken() after LogoutAll() error = %v", err)
+	}
+}
+
+func TestDeleteRevokesSessions(t *testing.T) {
+	b, _ := newTestBiz(t)
+	ctx := context.Background()
+	userID := createUser(t, b, ctx, "alice")
+	resp := login(t, b, ctx, "alice")
+	issuedAt := time.Now().Add(-time.Second)
+
+	if _, err := b.Delete(ctx, &apiv1.DeleteUserRequest{UserID: userID}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	// 删除前签发的 token 被吊销
+	revoked, err := b.revoker.IsRevoked(ctx, "jti", userID, issuedAt)
+	if err != nil {
+		t.Fatalf("IsRevoked() error = %v", err)
+	}
+	if !revoked {
+		t.Error("token issued before Delete() is not revoked")
+	}
+
+	// 恢复用户后, 删除前签发的 refresh token 仍然无效
+	if _, err := b.Restore(ctx, &apiv1.RestoreUserRequest{UserID: userID}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	_, err = b.RefreshToken(ctx, &apiv1.RefreshTokenRequest{RefreshToken: resp.RefreshToken})
+	wantError(t, err, errorsx.ErrRefreshTokenInvalid)
+}
+
+func TestLoginLockout(t *testing.T) {
+	type attempt struct {
+		username string
+		clientIP string
+		password string
+		wantErr  *errorsx.ErrorX
+	}
+	const wrong = "Wrong0!xyz"
+
+	tests := []struct {
+		name     string
+		attempts []attempt
+		// unlock 为 true 时, 最后一次尝试之前由管理员解除 alice 的锁定
+		unlock bool
+	}{
+		{
+			name: "username locked after max failures",
+			attempts: []attempt{
+				{"alice", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.2", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.3", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.4", testPassword, errorsx.ErrAccountLocked},
+			},
+		},
+		{
+			name: "success resets username failures",
+			attempts: []attempt{
+				{"alice", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.1", testPassword, nil},
+				{"alice", "10.0.0.2", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.2", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.2", testPassword, nil},
+			},
+		},
+		{
+			name: "client IP locked across usernames",
+			attempts: []attempt{
+				{"bob", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"carol", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"nobody", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"dave", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"erin", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.1", testPassword, errorsx.ErrAccountLocked},
+				{"alice", "10.0.0.2", testPassword, nil},
+			},
+		},
+		{
+			name: "success does not reset client IP failures",
+			attempts: []attempt{
+				{"bob", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"carol", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"dave", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.1", testPassword, nil},
+				{"erin", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"frank", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.1", testPassword, errorsx.ErrAccountLocked},
+			},
+		},
+		{
+			name: "unknown username is locked too",
+			attempts: []attempt{
+				{"nobody", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"nobody", "10.0.0.2", wrong, errorsx.ErrLoginFailed},
+				{"nobody", "10.0.0.3", wrong, errorsx.ErrLoginFailed},
+				{"nobody", "10.0.0.4", wrong, errorsx.ErrAccountLocked},
+			},
+		},
+		{
+			name: "unlock by administrator",
+			attempts: []attempt{
+				{"alice", "10.0.0.1", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.2", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.3", wrong, errorsx.ErrLoginFailed},
+				{"alice", "10.0.0.4", testPassword, nil},
+			},
+			unlock: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBiz(t)
+			b.guard = lockout.New(lockout.NewMemory(), lockout.Policy{MaxFailures: 3, MaxIPFailures: 5, Window: time.Minute, Duration: time.Minute})
+			ctx := context.Background()
+			userID := createUser(t, b, ctx, "alice")
+			for _, username := range []string{"bob", "carol", "dave", "erin", "frank"} {
+				createUser(t, b, ctx, username)
+			}
+
+			for i, a := range tt.attempts {
+				if tt.unlock && i == len(tt.attempts)-1 {
+					if _, err := b.Unlock(ctx, &apiv1.UnlockUserRequest{UserID: userID}); err != nil {
+						t.Fatalf("Unlock() error = %v", err)
+					}
+				}
+				_, err := b.Login(contextx.WithClientIP(ctx, a.clientIP), &apiv1.LoginRequest{Username: a.username, Password: a.password})
+				if got := errorsx.FromError(err); (got == nil) != (a.wantErr == nil) || (got != nil && got.Reason != a.wantErr.Reason) {
+					t.Fatalf("attempt %d: Login(%s from %s) error = %v, want %v", i+1, a.username, a.clientIP, err, a.wantErr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"fastgo/internal/pkg/core"
+	"fastgo/internal/pkg/errorsx"
+	v1 "fastgo/pkg/api/apiserver/v1"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+)
+
+// ListAuditLog 查询审计日志列表.
+func (h *Handler) ListAuditLog(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用查询审计日志列表功能...")
+
+	var rq v1.ListAuditLogRequest
+	if err := c.ShouldBindQuery(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateListAuditLogRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.AuditLogV1().List(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
@@ -1,8 +1,14 @@
 package handler
 
 import (
+	"errors"
+	"strconv"
+	"strings"
+
 	"fastgo/internal/apiserver/biz"
 	"fastgo/internal/apiserver/pkg/validation"
+	"fastgo/internal/pkg/errorsx"
+	"github.com/gin-gonic/gin"
 )
 
 // 处理博客模块请求
@@ -19,3 +25,35 @@ func NewHandler(biz biz.IBiz, val *validation.Validator) *Handler {
 		val: val,
 	}
 }
+
+// invalidArgument 将校验错误转换为 ErrInvalidArgument, 已经是 *errorsx.ErrorX 的错误(例如密码策略错误)原样返回, 保留其中的 Metadata.
+func invalidArgument(err error) error {
+	var errx *errorsx.ErrorX
+	if errors.As(err, &errx) {
+		return errx
+	}
+	return errorsx.ErrInvalidArgument.WithMessage("%s", err.Error())
+}
+
+// setETag 将资源的版本号作为 ETag 响应头返回.
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// ifMatch 解析 If-Match 请求头中的版本号, 请求头不存在或为 `*` 时返回 nil, 表示不校验版本.
+// 弱 ETag 和无法解析的 ETag 不可能与任何版本匹配, 返回 ErrVersionConflict.
+func ifMatch(c *gin.Context) (*int64, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return nil, errorsx.ErrVersionConflict
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return nil, errorsx.ErrVersionConflict
+	}
+	return &version, nil
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/pkg/validation"
+	"fastgo/internal/apiserver/store/fake"
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/password"
+	"github.com/gin-gonic/gin"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		want      int64
+		wantNil   bool
+		wantError bool
+	}{
+		{name: "absent", wantNil: true},
+		{name: "any", header: "*", wantNil: true},
+		{name: "strong etag", header: `"3"`, want: 3},
+		{name: "surrounding whitespace", header: ` "3" `, want: 3},
+		{name: "weak etag", header: `W/"3"`, wantError: true},
+		{name: "unquoted", header: "3", wantError: true},
+		{name: "not a version", header: `"abc"`, wantError: true},
+		{name: "several etags", header: `"3", "4"`, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+			if tt.header != "" {
+				c.Request.Header.Set("If-Match", tt.header)
+			}
+
+			version, err := ifMatch(c)
+			if tt.wantError {
+				if err != errorsx.ErrVersionConflict {
+					t.Fatalf("ifMatch() error = %v, want %v", err, errorsx.ErrVersionConflict)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ifMatch() error = %v", err)
+			}
+			if tt.wantNil != (version == nil) || version != nil && *version != tt.want {
+				t.Errorf("ifMatch() = %v, want %d", version, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateWithInvalidETag(t *testing.T) {
+	h := NewHandler(nil, nil)
+	engine := gin.New()
+	engine.PUT("/v1/posts/:postID", h.UpdatePost)
+	engine.PUT("/v1/users/:userID", h.UpdateUser)
+
+	// 无法与任何版本匹配的 If-Match 在调用 BIZ 层之前返回 412
+	for _, path := range []string{"/v1/posts/post-a", "/v1/users/user-a"} {
+		for _, etag := range []string{`W/"1"`, "garbage"} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(`{}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", etag)
+			engine.ServeHTTP(w, req)
+			if w.Code != http.StatusPreconditionFailed || !strings.Contains(w.Body.String(), errorsx.ErrVersionConflict.Reason) {
+				t.Errorf("PUT %s with If-Match %s = %d %s, want 412", path, etag, w.Code, w.Body.String())
+			}
+		}
+	}
+}
+
+func TestChangePasswordValidation(t *testing.T) {
+	ds := fake.NewStore()
+	user := &model.User{Username: "alice", Password: "Passw0rd!x"}
+	if err := ds.User().Create(context.Background(), user); err != nil {
+		t.Fatalf("User().Create() error = %v", err)
+	}
+	h := NewHandler(nil, validation.NewValidator(ds, &password.Policy{Rules: []password.Rule{password.Length(12, 64)}}))
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(contextx.WithUserID(c.Request.Context(), user.UserID))
+	})
+	engine.PUT("/v1/users/:userID/change-password", h.ChangePassword)
+
+	// 校验失败在调用 BIZ 层之前返回, 普通错误转换为 400, 已经是 ErrorX 的错误保持原样
+	tests := []struct {
+		name     string
+		userID   string
+		body     string
+		wantCode int
+		want     *errorsx.ErrorX
+	}{
+		{name: "other user", userID: "user-other", body: `{"oldPassword":"Passw0rd!x","newPassword":"Passw0rd!xyz1"}`, wantCode: http.StatusForbidden, want: errorsx.ErrPermissionDenied},
+		{name: "empty old password", userID: user.UserID, body: `{"newPassword":"Passw0rd!xyz1"}`, wantCode: http.StatusBadRequest, want: errorsx.ErrInvalidArgument},
+		{name: "same password", userID: user.UserID, body: `{"oldPassword":"Passw0rd!x","newPassword":"Passw0rd!x"}`, wantCode: http.StatusBadRequest, want: errorsx.ErrInvalidArgument},
+		{name: "password policy", userID: user.UserID, body: `{"oldPassword":"Passw0rd!x","newPassword":"Passw0rd!1"}`, wantCode: http.StatusBadRequest, want: errorsx.ErrPasswordPolicy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/v1/users/"+tt.userID+"/change-password", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			engine.ServeHTTP(w, req)
+			if w.Code != tt.wantCode || !strings.Contains(w.Body.String(), `"reason":"`+tt.want.Reason+`"`) {
+				t.Errorf("ChangePassword() = %d %s, want %d %s", w.Code, w.Body.String(), tt.wantCode, tt.want.Reason)
+			}
+		})
+	}
+}
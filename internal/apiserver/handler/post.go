@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"fastgo/internal/pkg/core"
+	"fastgo/internal/pkg/errorsx"
+	v1 "fastgo/pkg/api/apiserver/v1"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+)
+
+// CreatePost 创建博客.
+func (h *Handler) CreatePost(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用创建博客功能...")
+
+	var rq v1.CreatePostRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateCreatePostRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.PostV1().Create(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// UpdatePost 更新博客.
+// 博客 ID 从路径参数 `:postID` 中获取, 更新内容从请求体中获取.
+func (h *Handler) UpdatePost(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用更新博客功能...")
+
+	var rq v1.UpdatePostRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+	// 路径参数优先级高于请求体, 防止请求体中的 postID 与路径不一致
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	// 版本号从 If-Match 请求头中获取, 由 store 层的条件更新校验
+	version, err := ifMatch(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+	rq.Version = version
+
+	if err := h.val.ValidateUpdatePostRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.PostV1().Update(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	setETag(c, resp.Version)
+	core.WriteResponse(c, nil, resp)
+}
+
+// DeletePost 批量删除博客.
+func (h *Handler) DeletePost(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用删除博客功能...")
+
+	var rq v1.DeletePostRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	resp, err := h.biz.PostV1().Delete(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// GetPost 查询博客详情.
+func (h *Handler) GetPost(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用查询博客详情功能...")
+
+	var rq v1.GetPostRequest
+	// `c.ShouldBindUri` 将路径参数解析到带有 `uri` 标签的字段中
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	resp, err := h.biz.PostV1().Get(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	setETag(c, resp.Post.Version)
+	core.WriteResponse(c, nil, resp)
+}
+
+// ListPost 查询博客列表.
+func (h *Handler) ListPost(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用查询博客列表功能...")
+
+	var rq v1.ListPostRequest
+	// `c.ShouldBindQuery` 将查询参数解析到带有 `form` 标签的字段中
+	if err := c.ShouldBindQuery(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	resp, err := h.biz.PostV1().List(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// SearchPost 全文检索博客.
+func (h *Handler) SearchPost(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用全文检索博客功能...")
+
+	var rq v1.SearchPostRequest
+	if err := c.ShouldBindQuery(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateSearchPostRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.PostV1().Search(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// ListTrashPost 查询回收站中的博客列表.
+func (h *Handler) ListTrashPost(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用查询回收站博客列表功能...")
+
+	var rq v1.ListTrashPostRequest
+	if err := c.ShouldBindQuery(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	resp, err := h.biz.PostV1().ListTrash(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// RestorePost 从回收站中恢复博客.
+func (h *Handler) RestorePost(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用恢复博客功能...")
+
+	var rq v1.RestorePostRequest
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	resp, err := h.biz.PostV1().Restore(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
@@ -1,16 +1,18 @@
 package handler
 
 import (
+	"fastgo/internal/pkg/contextx"
 	"fastgo/internal/pkg/core"
 	"fastgo/internal/pkg/errorsx"
 	v1 "fastgo/pkg/api/apiserver/v1"
 	"github.com/gin-gonic/gin"
 	"log/slog"
+	"net/http"
 )
 
 // CreateUser 创建新用户.
 func (h *Handler) CreateUser(c *gin.Context) {
-	slog.Info("调用创建用户功能...")
+	slog.InfoContext(c.Request.Context(), "调用创建用户功能...")
 
 	var rq v1.CreateUserRequest
 	// `c.ShouldBindJSON`是gin框架提供的一个方法
@@ -23,7 +25,7 @@ func (h *Handler) CreateUser(c *gin.Context) {
 	// gin.Context 是 Gin 框架特有的上下文对象，它提供了许多处理 HTTP 请求和响应的方法，让开发者能够更方便地编写 Web 应用。
 	// gin.Context.Request.Context 是 Go 标准库 net/http 中 http.Request 的 Context，主要用于管理请求的生命周期、传递请求范围内的数据以及处理超时和取消操作。
 	if err := h.val.ValidateCreateUserRequest(c.Request.Context(), &rq); err != nil {
-		core.WriteResponse(c, errorsx.ErrInvalidArgument.WithMessage(err.Error()), nil)
+		core.WriteResponse(c, invalidArgument(err), nil)
 		return
 	}
 
@@ -39,7 +41,7 @@ func (h *Handler) CreateUser(c *gin.Context) {
 
 // Login 用户登录并返回 Token.
 func (h *Handler) Login(c *gin.Context) {
-	slog.Info("调用用户登录功能...")
+	slog.InfoContext(c.Request.Context(), "调用用户登录功能...")
 
 	var rq v1.LoginRequest
 	if err := c.ShouldBindJSON(&rq); err != nil {
@@ -54,7 +56,9 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.biz.UserV1().Login(c.Request.Context(), &rq)
+	// 登录失败次数按照用户名和客户端 IP 分别记录
+	ctx := contextx.WithClientIP(c.Request.Context(), c.ClientIP())
+	resp, err := h.biz.UserV1().Login(ctx, &rq)
 	if err != nil {
 		core.WriteResponse(c, err, nil)
 		return
@@ -65,7 +69,7 @@ func (h *Handler) Login(c *gin.Context) {
 
 // RefreshToken 刷新 JWT Token.
 func (h *Handler) RefreshToken(c *gin.Context) {
-	slog.Info("调用刷新token功能")
+	slog.InfoContext(c.Request.Context(), "调用刷新token功能")
 
 	var rq v1.RefreshTokenRequest
 	if err := c.ShouldBindJSON(&rq); err != nil {
@@ -73,7 +77,10 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// 校验待补充
+	if err := h.val.ValidateRefreshTokenRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
 
 	resp, err := h.biz.UserV1().RefreshToken(c.Request.Context(), &rq)
 	if err != nil {
@@ -84,18 +91,57 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 	core.WriteResponse(c, nil, resp)
 }
 
+// Logout 退出登录, 吊销当前使用的 token.
+func (h *Handler) Logout(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用退出登录功能")
+
+	var rq v1.LogoutRequest
+	// 请求体可以为空
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&rq); err != nil {
+			core.WriteResponse(c, errorsx.ErrBind, nil)
+			return
+		}
+	}
+
+	resp, err := h.biz.UserV1().Logout(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// LogoutAll 退出所有设备, 吊销当前用户已签发的所有 token.
+func (h *Handler) LogoutAll(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用退出所有设备功能")
+
+	resp, err := h.biz.UserV1().LogoutAll(c.Request.Context(), &v1.LogoutAllRequest{})
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
 func (h *Handler) ChangePassword(c *gin.Context) {
-	slog.Info("调用修改密码功能")
+	slog.InfoContext(c.Request.Context(), "调用修改密码功能")
 
 	var rq v1.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&rq); err != nil {
 		core.WriteResponse(c, errorsx.ErrPasswordInvalid, nil)
 		return
 	}
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
 
 	// 校验新旧密码有效性
 	if err := h.val.ValidateChangePasswordRequest(c.Request.Context(), &rq); err != nil {
-		core.WriteResponse(c, err, nil)
+		core.WriteResponse(c, invalidArgument(err), nil)
 		return
 	}
 
@@ -107,3 +153,575 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 
 	core.WriteResponse(c, nil, resp)
 }
+
+// UpdateUser 更新用户信息.
+func (h *Handler) UpdateUser(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用更新用户功能...")
+
+	var rq v1.UpdateUserRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+	// 用户 ID 以路径参数 `:userID` 为准
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	// 版本号从 If-Match 请求头中获取, 由 store 层的条件更新校验
+	version, err := ifMatch(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+	rq.Version = version
+
+	if err := h.val.ValidateUpdateUserRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().Update(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	setETag(c, resp.Version)
+	core.WriteResponse(c, nil, resp)
+}
+
+// DeleteUser 删除用户.
+func (h *Handler) DeleteUser(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用删除用户功能...")
+
+	var rq v1.DeleteUserRequest
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateDeleteUserRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().Delete(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// GetUser 查询用户详情.
+func (h *Handler) GetUser(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用查询用户详情功能...")
+
+	var rq v1.GetUserRequest
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateGetUserRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().Get(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	setETag(c, resp.User.Version)
+	core.WriteResponse(c, nil, resp)
+}
+
+// ListUser 查询用户列表.
+func (h *Handler) ListUser(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用查询用户列表功能...")
+
+	var rq v1.ListUserRequest
+	if err := c.ShouldBindQuery(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().List(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// UpdateUserRole 修改用户角色, 仅管理员可以调用.
+func (h *Handler) UpdateUserRole(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用修改用户角色功能...")
+
+	var rq v1.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateUpdateUserRoleRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().UpdateRole(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// ListTrashUser 查询回收站中的用户列表.
+func (h *Handler) ListTrashUser(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用查询回收站用户列表功能...")
+
+	var rq v1.ListTrashUserRequest
+	if err := c.ShouldBindQuery(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().ListTrash(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// RestoreUser 从回收站中恢复用户.
+func (h *Handler) RestoreUser(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用恢复用户功能...")
+
+	var rq v1.RestoreUserRequest
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().Restore(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// UnlockUser 解除用户的登录锁定.
+func (h *Handler) UnlockUser(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用解锁用户功能...")
+
+	var rq v1.UnlockUserRequest
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().Unlock(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// SendVerificationEmail 重新发送验证邮件.
+func (h *Handler) SendVerificationEmail(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用重新发送验证邮件功能...")
+
+	var rq v1.SendVerificationEmailRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateSendVerificationEmailRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().SendVerificationEmail(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// VerifyEmail 验证邮箱.
+// 验证邮件中的链接可以直接打开(GET, 令牌在查询参数中), 也可以由前端页面调用(POST, 令牌在请求体中).
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用验证邮箱功能...")
+
+	var rq v1.VerifyEmailRequest
+	if err := c.ShouldBind(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateVerifyEmailRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().VerifyEmail(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// RequestPasswordReset 找回密码, 发送重置密码邮件.
+func (h *Handler) RequestPasswordReset(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用找回密码功能...")
+
+	var rq v1.RequestPasswordResetRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateRequestPasswordResetRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().RequestPasswordReset(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// ConfirmPasswordReset 使用重置密码邮件中的令牌重置密码.
+func (h *Handler) ConfirmPasswordReset(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用重置密码功能...")
+
+	var rq v1.ConfirmPasswordResetRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateConfirmPasswordResetRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().ConfirmPasswordReset(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// CompleteLogin 使用两步验证码或恢复码完成登录.
+func (h *Handler) CompleteLogin(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用两步验证登录功能...")
+
+	var rq v1.CompleteLoginRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateCompleteLoginRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	// 验证码错误与密码错误一样按照用户名和客户端 IP 记录失败次数
+	ctx := contextx.WithClientIP(c.Request.Context(), c.ClientIP())
+	resp, err := h.biz.UserV1().CompleteLogin(ctx, &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// EnrollTOTP 绑定验证器应用, 返回 TOTP 密钥和 otpauth URI.
+func (h *Handler) EnrollTOTP(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用绑定验证器应用功能...")
+
+	var rq v1.EnrollTOTPRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateEnrollTOTPRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().EnrollTOTP(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// ConfirmTOTP 确认绑定验证器应用并启用两步验证, 返回恢复码.
+func (h *Handler) ConfirmTOTP(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用启用两步验证功能...")
+
+	var rq v1.ConfirmTOTPRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateConfirmTOTPRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	ctx := contextx.WithClientIP(c.Request.Context(), c.ClientIP())
+	resp, err := h.biz.UserV1().ConfirmTOTP(ctx, &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// DisableTOTP 关闭两步验证.
+func (h *Handler) DisableTOTP(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用关闭两步验证功能...")
+
+	var rq v1.DisableTOTPRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateDisableTOTPRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	ctx := contextx.WithClientIP(c.Request.Context(), c.ClientIP())
+	resp, err := h.biz.UserV1().DisableTOTP(ctx, &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// RegenerateRecoveryCodes 重新生成两步验证恢复码.
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用重新生成恢复码功能...")
+
+	var rq v1.RegenerateRecoveryCodesRequest
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateRegenerateRecoveryCodesRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	ctx := contextx.WithClientIP(c.Request.Context(), c.ClientIP())
+	resp, err := h.biz.UserV1().RegenerateRecoveryCodes(ctx, &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// oidcSessionCookie 为保存 OIDC 会话信息的 cookie 名称, cookie 的路径为回调地址所在的路径, 只在回调时发送.
+const (
+	oidcSessionCookie = "fg_oidc"
+	oidcSessionPath   = "/v1/oidc"
+)
+
+// AuthorizeOIDC 使用外部身份提供方登录, 重定向到身份提供方的授权地址.
+func (h *Handler) AuthorizeOIDC(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用外部身份登录功能...")
+
+	var rq v1.AuthorizeOIDCRequest
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateAuthorizeOIDCRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().AuthorizeOIDC(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	setOIDCSession(c, resp.Session)
+	c.Redirect(http.StatusFound, resp.AuthorizationURL)
+}
+
+// LinkIdentity 为当前用户绑定外部身份, 返回身份提供方的授权地址, 需要在同一个浏览器中打开.
+func (h *Handler) LinkIdentity(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用绑定外部身份功能...")
+
+	var rq v1.LinkIdentityRequest
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateLinkIdentityRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().LinkIdentity(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	setOIDCSession(c, resp.Session)
+	core.WriteResponse(c, nil, resp)
+}
+
+// OIDCCallback 处理外部身份提供方的回调, 完成登录或者绑定外部身份.
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用外部身份登录回调功能...")
+
+	var rq v1.OIDCCallbackRequest
+	if err := c.ShouldBindQuery(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+	// 会话信息只能使用一次, 无论回调是否成功都清除
+	rq.Session, _ = c.Cookie(oidcSessionCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcSessionCookie, "", -1, oidcSessionPath, "", c.Request.TLS != nil, true)
+
+	if err := h.val.ValidateOIDCCallbackRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().OIDCCallback(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// ListIdentities 查询用户绑定的外部身份.
+func (h *Handler) ListIdentities(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用查询外部身份功能...")
+
+	var rq v1.ListIdentitiesRequest
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateListIdentitiesRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().ListIdentities(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// UnlinkIdentity 解除绑定外部身份.
+func (h *Handler) UnlinkIdentity(c *gin.Context) {
+	slog.InfoContext(c.Request.Context(), "调用解除绑定外部身份功能...")
+
+	var rq v1.UnlinkIdentityRequest
+	if err := c.ShouldBindUri(&rq); err != nil {
+		core.WriteResponse(c, errorsx.ErrBind, nil)
+		return
+	}
+
+	if err := h.val.ValidateUnlinkIdentityRequest(c.Request.Context(), &rq); err != nil {
+		core.WriteResponse(c, invalidArgument(err), nil)
+		return
+	}
+
+	resp, err := h.biz.UserV1().UnlinkIdentity(c.Request.Context(), &rq)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	core.WriteResponse(c, nil, resp)
+}
+
+// setOIDCSession 将 OIDC 会话信息保存在 cookie 中, 身份提供方重定向回来时只有发起授权的浏览器带有该 cookie.
+// 身份提供方通过顶层导航重定向回来, SameSite=Lax 的 cookie 会随回调请求发送.
+func setOIDCSession(c *gin.Context, session string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcSessionCookie, session, 0, oidcSessionPath, "", c.Request.TLS != nil, true)
+}
@@ -0,0 +1,36 @@
+package apiserver
+
+import (
+	userv1 "fastgo/internal/apiserver/biz/v1/user"
+	"fastgo/internal/pkg/mailer"
+	genericoptions "fastgo/pkg/options"
+	"os"
+)
+
+// newMailer 根据配置创建发送邮件的 Mailer.
+func newMailer(opts *genericoptions.MailerOptions) (mailer.Mailer, error) {
+	if opts.Backend == genericoptions.MailerBackendSMTP {
+		return mailer.NewSMTP(mailer.SMTPConfig{
+			Host:     opts.Host,
+			Port:     opts.Port,
+			Username: opts.Username,
+			Password: opts.Password,
+			From:     opts.From,
+		})
+	}
+	if opts.Output == "stdout" {
+		return mailer.NewWriter(opts.From, os.Stdout), nil
+	}
+	return mailer.NewFile(opts.From, opts.Output)
+}
+
+// newEmailConfig 根据配置创建邮箱验证和找回密码的配置.
+func newEmailConfig(opts *genericoptions.EmailOptions) userv1.EmailConfig {
+	return userv1.EmailConfig{
+		VerifyURL:        opts.VerifyURL,
+		ResetURL:         opts.ResetURL,
+		VerifyExpiration: opts.VerifyExpiration,
+		ResetExpiration:  opts.ResetExpiration,
+		RequireVerified:  opts.RequireVerified,
+	}
+}
@@ -0,0 +1,34 @@
+// Package migrations 存放 fg-apiserver 的数据库迁移文件.
+//
+// 不同数据库方言的 DDL 不同, 因此每种数据库驱动对应一个子目录, 子目录名与 db.driver 配置项一致.
+// 新增迁移时需要在所有子目录中同时添加相同版本号的迁移文件, 可以使用 `fg-apiserver migrate create <name>` 生成.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"fastgo/pkg/migrate"
+	"gorm.io/gorm"
+)
+
+//go:embed mysql/*.sql sqlite/*.sql
+var files embed.FS
+
+// FS 返回指定数据库驱动对应的迁移文件系统.
+func FS(driver string) (fs.FS, error) {
+	if _, err := fs.Stat(files, driver); err != nil {
+		return nil, fmt.Errorf("no migrations found for database driver '%s'", driver)
+	}
+	return fs.Sub(files, driver)
+}
+
+// NewMigrator 创建一个使用内置迁移文件的 Migrator 实例.
+func NewMigrator(db *gorm.DB, driver string) (*migrate.Migrator, error) {
+	fsys, err := FS(driver)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db, fsys)
+}
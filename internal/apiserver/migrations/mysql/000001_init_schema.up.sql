@@ -0,0 +1,29 @@
+-- 初始化 user 和 post 表
+
+CREATE TABLE IF NOT EXISTS `user` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `userID` varchar(36) NOT NULL DEFAULT '' COMMENT '用户唯一 ID',
+  `username` varchar(255) NOT NULL DEFAULT '' COMMENT '用户名（唯一）',
+  `password` varchar(255) NOT NULL DEFAULT '' COMMENT '用户密码（加密后）',
+  `nickname` varchar(30) NOT NULL DEFAULT '' COMMENT '用户昵称',
+  `email` varchar(256) NOT NULL DEFAULT '' COMMENT '用户电子邮箱地址',
+  `phone` varchar(16) NOT NULL DEFAULT '' COMMENT '用户手机号',
+  `createdAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '用户创建时间',
+  `updatedAt` datetime NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT '用户最后修改时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user_username` (`username`),
+  UNIQUE KEY `uk_user_userID` (`userID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户表';
+
+CREATE TABLE IF NOT EXISTS `post` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `userID` varchar(36) NOT NULL DEFAULT '' COMMENT '用户唯一 ID',
+  `postID` varchar(35) NOT NULL DEFAULT '' COMMENT '博文唯一 ID',
+  `title` varchar(256) NOT NULL DEFAULT '' COMMENT '博文标题',
+  `content` longtext NOT NULL COMMENT '博文内容',
+  `createdAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '博文创建时间',
+  `updatedAt` datetime NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT '博文最后修改时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_post_postID` (`postID`),
+  KEY `idx_post_userID` (`userID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='博文表';
@@ -0,0 +1,16 @@
+-- 创建 refresh_token 表，只保存 refresh token 的哈希值
+
+CREATE TABLE IF NOT EXISTS `refresh_token` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `userID` varchar(36) NOT NULL DEFAULT '' COMMENT '用户唯一 ID',
+  `familyID` varchar(36) NOT NULL DEFAULT '' COMMENT 'token 家族 ID',
+  `tokenHash` char(64) NOT NULL DEFAULT '' COMMENT 'refresh token 哈希值',
+  `expiresAt` datetime NOT NULL COMMENT '过期时间',
+  `revokedAt` datetime DEFAULT NULL COMMENT '吊销时间，轮换或吊销后不可再使用',
+  `createdAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '创建时间',
+  `updatedAt` datetime NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT '最后修改时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_refresh_token_tokenHash` (`tokenHash`),
+  KEY `idx_refresh_token_familyID` (`familyID`),
+  KEY `idx_refresh_token_userID` (`userID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='refresh token 表';
@@ -0,0 +1,13 @@
+-- 创建 revoked_token 表，保存被吊销的 JWT，记录过期后可以删除
+
+CREATE TABLE IF NOT EXISTS `revoked_token` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `jti` varchar(64) NOT NULL DEFAULT '' COMMENT 'token 唯一标识，用户级别的吊销记录为 user:<userID>',
+  `userID` varchar(36) NOT NULL DEFAULT '' COMMENT '用户唯一 ID',
+  `revokedBefore` datetime DEFAULT NULL COMMENT '用户级别吊销时间点，在此之前签发的 token 均被吊销',
+  `expiresAt` datetime NOT NULL COMMENT '吊销记录过期时间',
+  `createdAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_revoked_token_jti` (`jti`),
+  KEY `idx_revoked_token_expiresAt` (`expiresAt`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='JWT 吊销表';
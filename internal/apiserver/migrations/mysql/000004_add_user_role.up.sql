@@ -0,0 +1,3 @@
+-- 为 user 表增加 role 列，已有用户均为普通用户
+
+ALTER TABLE `user` ADD COLUMN `role` varchar(32) NOT NULL DEFAULT 'user' COMMENT '用户角色：admin、user' AFTER `phone`;
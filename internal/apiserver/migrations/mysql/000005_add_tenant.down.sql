@@ -0,0 +1,12 @@
+-- 回滚后用户名恢复为全局唯一，回滚前需要确保不同租户之间没有重名用户
+
+ALTER TABLE `refresh_token` DROP COLUMN `tenantID`;
+
+ALTER TABLE `post`
+  DROP INDEX `idx_post_tenantID_userID`,
+  DROP COLUMN `tenantID`;
+
+ALTER TABLE `user`
+  DROP INDEX `uk_user_tenantID_username`,
+  ADD UNIQUE KEY `uk_user_username` (`username`),
+  DROP COLUMN `tenantID`;
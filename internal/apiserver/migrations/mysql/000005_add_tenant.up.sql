@@ -0,0 +1,14 @@
+-- 为 user、post、refresh_token 表增加租户列，已有数据归属默认租户
+-- 用户名改为在租户内唯一
+
+ALTER TABLE `user`
+  ADD COLUMN `tenantID` varchar(64) NOT NULL DEFAULT 'default' COMMENT '租户 ID' AFTER `id`,
+  DROP INDEX `uk_user_username`,
+  ADD UNIQUE KEY `uk_user_tenantID_username` (`tenantID`, `username`);
+
+ALTER TABLE `post`
+  ADD COLUMN `tenantID` varchar(64) NOT NULL DEFAULT 'default' COMMENT '租户 ID' AFTER `id`,
+  ADD KEY `idx_post_tenantID_userID` (`tenantID`, `userID`);
+
+ALTER TABLE `refresh_token`
+  ADD COLUMN `tenantID` varchar(64) NOT NULL DEFAULT 'default' COMMENT '租户 ID' AFTER `id`;
@@ -0,0 +1 @@
+ALTER TABLE `post` DROP INDEX `ft_post_title_content`;
@@ -0,0 +1,3 @@
+-- 为 post 表的标题和正文创建全文索引，使用 ngram 分词器以支持中日韩文字
+
+ALTER TABLE `post` ADD FULLTEXT INDEX `ft_post_title_content` (`title`, `content`) WITH PARSER ngram;
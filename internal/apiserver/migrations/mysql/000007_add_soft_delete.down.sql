@@ -0,0 +1,5 @@
+-- 回滚前需要先永久删除回收站中的记录，否则这些记录会重新变为可见
+
+ALTER TABLE `post` DROP INDEX `idx_post_deletedAt`, DROP COLUMN `deletedAt`;
+
+ALTER TABLE `user` DROP INDEX `idx_user_deletedAt`, DROP COLUMN `deletedAt`;
@@ -0,0 +1,10 @@
+-- 为 user、post 表增加删除时间列，支持软删除和回收站
+-- 软删除的用户仍然占用用户名，直到被永久删除
+
+ALTER TABLE `user`
+  ADD COLUMN `deletedAt` datetime DEFAULT NULL COMMENT '用户删除时间' AFTER `updatedAt`,
+  ADD KEY `idx_user_deletedAt` (`deletedAt`);
+
+ALTER TABLE `post`
+  ADD COLUMN `deletedAt` datetime DEFAULT NULL COMMENT '博文删除时间' AFTER `updatedAt`,
+  ADD KEY `idx_post_deletedAt` (`deletedAt`);
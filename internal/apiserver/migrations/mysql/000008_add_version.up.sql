@@ -0,0 +1,6 @@
+-- 为 user、post 表增加版本号列，用于乐观并发控制（ETag/If-Match）
+-- 每次更新记录时版本号加 1，已有记录的版本号为 1
+
+ALTER TABLE `user` ADD COLUMN `version` bigint NOT NULL DEFAULT 1 COMMENT '用户版本号' AFTER `role`;
+
+ALTER TABLE `post` ADD COLUMN `version` bigint NOT NULL DEFAULT 1 COMMENT '博文版本号' AFTER `content`;
@@ -0,0 +1,17 @@
+-- 创建 audit_log 表，记录对用户和博客的每一次写操作
+
+CREATE TABLE IF NOT EXISTS `audit_log` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `tenantID` varchar(64) NOT NULL DEFAULT 'default' COMMENT '租户 ID',
+  `actorID` varchar(36) NOT NULL DEFAULT '' COMMENT '操作者的用户 ID，匿名请求和系统任务为空',
+  `requestID` varchar(64) NOT NULL DEFAULT '' COMMENT '请求 ID',
+  `resource` varchar(32) NOT NULL DEFAULT '' COMMENT '资源类型：user、post',
+  `resourceID` varchar(36) NOT NULL DEFAULT '' COMMENT '资源 ID',
+  `action` varchar(32) NOT NULL DEFAULT '' COMMENT '操作：create、update、delete、restore',
+  `diff` text NOT NULL COMMENT '变更前后的字段值（JSON），敏感字段已脱敏',
+  `createdAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '操作时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_audit_log_tenantID_actorID` (`tenantID`, `actorID`),
+  KEY `idx_audit_log_tenantID_resource_resourceID` (`tenantID`, `resource`, `resourceID`),
+  KEY `idx_audit_log_createdAt` (`createdAt`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='审计日志表';
@@ -0,0 +1,11 @@
+-- 创建 password_history 表，记录用户修改密码时被替换的密码哈希
+
+CREATE TABLE IF NOT EXISTS `password_history` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `tenantID` varchar(64) NOT NULL DEFAULT 'default' COMMENT '租户 ID',
+  `userID` varchar(36) NOT NULL DEFAULT '' COMMENT '用户唯一 ID',
+  `password` varchar(255) NOT NULL DEFAULT '' COMMENT '历史密码（加密后）',
+  `createdAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '密码被替换的时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_password_history_tenantID_userID` (`tenantID`, `userID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='历史密码表';
@@ -0,0 +1,2 @@
+DROP INDEX `idx_user_email` ON `user`;
+ALTER TABLE `user` DROP COLUMN `emailVerifiedAt`;
@@ -0,0 +1,6 @@
+-- 为 user 表增加邮箱验证时间列，未验证邮箱的用户为 NULL
+-- 找回密码和重新发送验证邮件时按照邮箱查询用户，因此为 email 列增加索引
+
+ALTER TABLE `user` ADD COLUMN `emailVerifiedAt` datetime DEFAULT NULL COMMENT '邮箱验证时间' AFTER `email`;
+
+CREATE INDEX `idx_user_email` ON `user` (`email`);
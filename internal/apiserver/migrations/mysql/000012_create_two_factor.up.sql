@@ -0,0 +1,26 @@
+-- 创建 two_factor 表和 recovery_code 表，用于 TOTP 两步验证
+-- 每个用户最多一条 two_factor 记录，恢复码只保存哈希值
+
+CREATE TABLE IF NOT EXISTS `two_factor` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `tenantID` varchar(64) NOT NULL DEFAULT 'default' COMMENT '租户 ID',
+  `userID` varchar(36) NOT NULL DEFAULT '' COMMENT '用户唯一 ID',
+  `secret` varchar(64) NOT NULL DEFAULT '' COMMENT 'TOTP 密钥（base32 编码）',
+  `enabledAt` datetime DEFAULT NULL COMMENT '启用时间，确认之前为 NULL',
+  `lastStep` bigint NOT NULL DEFAULT 0 COMMENT '最近一次验证通过的时间步',
+  `createdAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '创建时间',
+  `updatedAt` datetime NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT '最后修改时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_two_factor_tenantID_userID` (`tenantID`, `userID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='两步验证表';
+
+CREATE TABLE IF NOT EXISTS `recovery_code` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `tenantID` varchar(64) NOT NULL DEFAULT 'default' COMMENT '租户 ID',
+  `userID` varchar(36) NOT NULL DEFAULT '' COMMENT '用户唯一 ID',
+  `codeHash` char(64) NOT NULL DEFAULT '' COMMENT '恢复码哈希值',
+  `usedAt` datetime DEFAULT NULL COMMENT '使用时间，使用后不可再使用',
+  `createdAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_recovery_code_tenantID_userID` (`tenantID`, `userID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='两步验证恢复码表';
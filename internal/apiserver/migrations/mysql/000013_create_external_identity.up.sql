@@ -0,0 +1,17 @@
+-- 创建 external_identity 表，记录用户绑定的外部 OIDC 身份
+-- 同一租户中，一个外部身份只能绑定一个用户，每个用户在每个身份提供方中最多绑定一个身份
+
+CREATE TABLE IF NOT EXISTS `external_identity` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `tenantID` varchar(64) NOT NULL DEFAULT 'default' COMMENT '租户 ID',
+  `userID` varchar(36) NOT NULL DEFAULT '' COMMENT '用户唯一 ID',
+  `provider` varchar(64) NOT NULL DEFAULT '' COMMENT '身份提供方名称',
+  `subject` varchar(255) NOT NULL DEFAULT '' COMMENT '用户在身份提供方中的唯一标识',
+  `email` varchar(255) NOT NULL DEFAULT '' COMMENT '身份提供方返回的邮箱',
+  `lastLoginAt` datetime DEFAULT NULL COMMENT '最近一次使用该身份登录的时间',
+  `createdAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '创建时间',
+  `updatedAt` datetime NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT '最后修改时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_external_identity_tenantID_provider_subject` (`tenantID`, `provider`, `subject`),
+  UNIQUE KEY `uk_external_identity_tenantID_userID_provider` (`tenantID`, `userID`, `provider`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='外部身份表';
@@ -0,0 +1 @@
+ALTER TABLE `revoked_token` MODIFY `revokedBefore` datetime DEFAULT NULL COMMENT '用户级别吊销时间点，在此之前签发的 token 均被吊销';
@@ -0,0 +1,3 @@
+-- revoked_token.revokedBefore 精确到毫秒，与 token 中毫秒精度的签发时间（iat_ms）比较
+
+ALTER TABLE `revoked_token` MODIFY `revokedBefore` datetime(3) DEFAULT NULL COMMENT '用户级别吊销时间点，在此之前签发的 token 均被吊销';
@@ -0,0 +1,27 @@
+-- 初始化 user 和 post 表
+
+CREATE TABLE IF NOT EXISTS `user` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `userID` TEXT NOT NULL DEFAULT '',
+  `username` TEXT NOT NULL DEFAULT '',
+  `password` TEXT NOT NULL DEFAULT '',
+  `nickname` TEXT NOT NULL DEFAULT '',
+  `email` TEXT NOT NULL DEFAULT '',
+  `phone` TEXT NOT NULL DEFAULT '',
+  `createdAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updatedAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_user_username` ON `user` (`username`);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_user_userID` ON `user` (`userID`);
+
+CREATE TABLE IF NOT EXISTS `post` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `userID` TEXT NOT NULL DEFAULT '',
+  `postID` TEXT NOT NULL DEFAULT '',
+  `title` TEXT NOT NULL DEFAULT '',
+  `content` TEXT NOT NULL DEFAULT '',
+  `createdAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updatedAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_post_postID` ON `post` (`postID`);
+CREATE INDEX IF NOT EXISTS `idx_post_userID` ON `post` (`userID`);
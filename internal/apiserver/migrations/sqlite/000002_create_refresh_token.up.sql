@@ -0,0 +1,15 @@
+-- 创建 refresh_token 表，只保存 refresh token 的哈希值
+
+CREATE TABLE IF NOT EXISTS `refresh_token` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `userID` TEXT NOT NULL DEFAULT '',
+  `familyID` TEXT NOT NULL DEFAULT '',
+  `tokenHash` TEXT NOT NULL DEFAULT '',
+  `expiresAt` DATETIME NOT NULL,
+  `revokedAt` DATETIME DEFAULT NULL,
+  `createdAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updatedAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_refresh_token_tokenHash` ON `refresh_token` (`tokenHash`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_token_familyID` ON `refresh_token` (`familyID`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_token_userID` ON `refresh_token` (`userID`);
@@ -0,0 +1,12 @@
+-- 创建 revoked_token 表，保存被吊销的 JWT，记录过期后可以删除
+
+CREATE TABLE IF NOT EXISTS `revoked_token` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `jti` TEXT NOT NULL DEFAULT '',
+  `userID` TEXT NOT NULL DEFAULT '',
+  `revokedBefore` DATETIME DEFAULT NULL,
+  `expiresAt` DATETIME NOT NULL,
+  `createdAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_revoked_token_jti` ON `revoked_token` (`jti`);
+CREATE INDEX IF NOT EXISTS `idx_revoked_token_expiresAt` ON `revoked_token` (`expiresAt`);
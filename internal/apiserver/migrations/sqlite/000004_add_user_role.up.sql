@@ -0,0 +1,3 @@
+-- 为 user 表增加 role 列，已有用户均为普通用户
+
+ALTER TABLE `user` ADD COLUMN `role` TEXT NOT NULL DEFAULT 'user';
@@ -0,0 +1,10 @@
+-- 回滚后用户名恢复为全局唯一，回滚前需要确保不同租户之间没有重名用户
+
+ALTER TABLE `refresh_token` DROP COLUMN `tenantID`;
+
+DROP INDEX IF EXISTS `idx_post_tenantID_userID`;
+ALTER TABLE `post` DROP COLUMN `tenantID`;
+
+DROP INDEX IF EXISTS `uk_user_tenantID_username`;
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_user_username` ON `user` (`username`);
+ALTER TABLE `user` DROP COLUMN `tenantID`;
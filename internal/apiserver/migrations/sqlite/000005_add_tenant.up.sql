@@ -0,0 +1,11 @@
+-- 为 user、post、refresh_token 表增加租户列，已有数据归属默认租户
+-- 用户名改为在租户内唯一
+
+ALTER TABLE `user` ADD COLUMN `tenantID` TEXT NOT NULL DEFAULT 'default';
+DROP INDEX IF EXISTS `uk_user_username`;
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_user_tenantID_username` ON `user` (`tenantID`, `username`);
+
+ALTER TABLE `post` ADD COLUMN `tenantID` TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS `idx_post_tenantID_userID` ON `post` (`tenantID`, `userID`);
+
+ALTER TABLE `refresh_token` ADD COLUMN `tenantID` TEXT NOT NULL DEFAULT 'default';
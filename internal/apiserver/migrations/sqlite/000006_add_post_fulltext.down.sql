@@ -0,0 +1 @@
+-- SQLite 使用进程内的倒排索引进行全文检索，无需修改表结构
@@ -0,0 +1,7 @@
+-- 回滚前需要先永久删除回收站中的记录，否则这些记录会重新变为可见
+
+DROP INDEX IF EXISTS `idx_post_deletedAt`;
+ALTER TABLE `post` DROP COLUMN `deletedAt`;
+
+DROP INDEX IF EXISTS `idx_user_deletedAt`;
+ALTER TABLE `user` DROP COLUMN `deletedAt`;
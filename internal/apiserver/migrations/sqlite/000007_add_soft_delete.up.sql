@@ -0,0 +1,8 @@
+-- 为 user、post 表增加删除时间列，支持软删除和回收站
+-- 软删除的用户仍然占用用户名，直到被永久删除
+
+ALTER TABLE `user` ADD COLUMN `deletedAt` DATETIME DEFAULT NULL;
+CREATE INDEX IF NOT EXISTS `idx_user_deletedAt` ON `user` (`deletedAt`);
+
+ALTER TABLE `post` ADD COLUMN `deletedAt` DATETIME DEFAULT NULL;
+CREATE INDEX IF NOT EXISTS `idx_post_deletedAt` ON `post` (`deletedAt`);
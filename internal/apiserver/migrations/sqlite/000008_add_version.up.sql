@@ -0,0 +1,6 @@
+-- 为 user、post 表增加版本号列，用于乐观并发控制（ETag/If-Match）
+-- 每次更新记录时版本号加 1，已有记录的版本号为 1
+
+ALTER TABLE `user` ADD COLUMN `version` INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE `post` ADD COLUMN `version` INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,16 @@
+-- 创建 audit_log 表，记录对用户和博客的每一次写操作
+
+CREATE TABLE IF NOT EXISTS `audit_log` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `tenantID` TEXT NOT NULL DEFAULT 'default',
+  `actorID` TEXT NOT NULL DEFAULT '',
+  `requestID` TEXT NOT NULL DEFAULT '',
+  `resource` TEXT NOT NULL DEFAULT '',
+  `resourceID` TEXT NOT NULL DEFAULT '',
+  `action` TEXT NOT NULL DEFAULT '',
+  `diff` TEXT NOT NULL DEFAULT '{}',
+  `createdAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS `idx_audit_log_tenantID_actorID` ON `audit_log` (`tenantID`, `actorID`);
+CREATE INDEX IF NOT EXISTS `idx_audit_log_tenantID_resource_resourceID` ON `audit_log` (`tenantID`, `resource`, `resourceID`);
+CREATE INDEX IF NOT EXISTS `idx_audit_log_createdAt` ON `audit_log` (`createdAt`);
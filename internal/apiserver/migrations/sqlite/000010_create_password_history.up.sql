@@ -0,0 +1,10 @@
+-- 创建 password_history 表，记录用户修改密码时被替换的密码哈希
+
+CREATE TABLE IF NOT EXISTS `password_history` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `tenantID` TEXT NOT NULL DEFAULT 'default',
+  `userID` TEXT NOT NULL DEFAULT '',
+  `password` TEXT NOT NULL DEFAULT '',
+  `createdAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS `idx_password_history_tenantID_userID` ON `password_history` (`tenantID`, `userID`);
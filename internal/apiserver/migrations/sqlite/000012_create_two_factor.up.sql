@@ -0,0 +1,24 @@
+-- 创建 two_factor 表和 recovery_code 表，用于 TOTP 两步验证
+-- 每个用户最多一条 two_factor 记录，恢复码只保存哈希值
+
+CREATE TABLE IF NOT EXISTS `two_factor` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `tenantID` TEXT NOT NULL DEFAULT 'default',
+  `userID` TEXT NOT NULL DEFAULT '',
+  `secret` TEXT NOT NULL DEFAULT '',
+  `enabledAt` DATETIME DEFAULT NULL,
+  `lastStep` INTEGER NOT NULL DEFAULT 0,
+  `createdAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updatedAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_two_factor_tenantID_userID` ON `two_factor` (`tenantID`, `userID`);
+
+CREATE TABLE IF NOT EXISTS `recovery_code` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `tenantID` TEXT NOT NULL DEFAULT 'default',
+  `userID` TEXT NOT NULL DEFAULT '',
+  `codeHash` TEXT NOT NULL DEFAULT '',
+  `usedAt` DATETIME DEFAULT NULL,
+  `createdAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS `idx_recovery_code_tenantID_userID` ON `recovery_code` (`tenantID`, `userID`);
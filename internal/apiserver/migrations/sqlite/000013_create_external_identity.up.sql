@@ -0,0 +1,16 @@
+-- 创建 external_identity 表，记录用户绑定的外部 OIDC 身份
+-- 同一租户中，一个外部身份只能绑定一个用户，每个用户在每个身份提供方中最多绑定一个身份
+
+CREATE TABLE IF NOT EXISTS `external_identity` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `tenantID` TEXT NOT NULL DEFAULT 'default',
+  `userID` TEXT NOT NULL DEFAULT '',
+  `provider` TEXT NOT NULL DEFAULT '',
+  `subject` TEXT NOT NULL DEFAULT '',
+  `email` TEXT NOT NULL DEFAULT '',
+  `lastLoginAt` DATETIME DEFAULT NULL,
+  `createdAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updatedAt` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_external_identity_tenantID_provider_subject` ON `external_identity` (`tenantID`, `provider`, `subject`);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_external_identity_tenantID_userID_provider` ON `external_identity` (`tenantID`, `userID`, `provider`);
@@ -0,0 +1 @@
+-- SQLite 无需回滚
@@ -0,0 +1,2 @@
+-- revoked_token.revokedBefore 精确到毫秒，与 token 中毫秒精度的签发时间（iat_ms）比较
+-- SQLite 的 DATETIME 以文本保存，本身保留小数秒，无需修改表结构
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+const TableNameAuditLog = "audit_log"
+
+// AuditLog 审计日志表
+// 每条记录对应一次对用户或博客的写操作, Diff 为变更前后的字段值(JSON), 敏感字段已脱敏.
+type AuditLog struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	TenantID   string    `gorm:"column:tenantID;not null;default:default;comment:租户 ID" json:"tenantID"`              // 租户 ID
+	ActorID    string    `gorm:"column:actorID;not null;comment:操作者的用户 ID，匿名请求和系统任务为空" json:"actorID"`                // 操作者的用户 ID，匿名请求和系统任务为空
+	RequestID  string    `gorm:"column:requestID;not null;comment:请求 ID" json:"requestID"`                            // 请求 ID
+	Resource   string    `gorm:"column:resource;not null;comment:资源类型：user、post" json:"resource"`                     // 资源类型：user、post
+	ResourceID string    `gorm:"column:resourceID;not null;comment:资源 ID" json:"resourceID"`                          // 资源 ID
+	Action     string    `gorm:"column:action;not null;comment:操作：create、update、delete、restore" json:"action"`        // 操作：create、update、delete、restore
+	Diff       string    `gorm:"column:diff;not null;comment:变更前后的字段值（JSON），敏感字段已脱敏" json:"diff"`                     // 变更前后的字段值（JSON），敏感字段已脱敏
+	CreatedAt  time.Time `gorm:"column:createdAt;not null;default:current_timestamp();comment:操作时间" json:"createdAt"` // 操作时间
+}
+
+// TableName AuditLog's table name
+func (*AuditLog) TableName() string {
+	return TableNameAuditLog
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+const TableNameExternalIdentity = "external_identity"
+
+// ExternalIdentity 外部身份表
+// 记录用户在外部 OIDC 身份提供方中的身份, 用户可以使用已绑定的外部身份登录. 每个用户在每个身份提供方中最多绑定一个身份.
+type ExternalIdentity struct {
+	ID          int64      `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	TenantID    string     `gorm:"column:tenantID;not null;default:default;comment:租户 ID" json:"tenantID"`                // 租户 ID
+	UserID      string     `gorm:"column:userID;not null;comment:用户唯一 ID" json:"userID"`                                  // 用户唯一 ID
+	Provider    string     `gorm:"column:provider;not null;comment:身份提供方名称" json:"provider"`                              // 身份提供方名称
+	Subject     string     `gorm:"column:subject;not null;comment:用户在身份提供方中的唯一标识" json:"subject"`                         // 用户在身份提供方中的唯一标识
+	Email       string     `gorm:"column:email;not null;comment:身份提供方返回的邮箱" json:"email"`                                 // 身份提供方返回的邮箱
+	LastLoginAt *time.Time `gorm:"column:lastLoginAt;comment:最近一次使用该身份登录的时间" json:"lastLoginAt"`                          // 最近一次使用该身份登录的时间
+	CreatedAt   time.Time  `gorm:"column:createdAt;not null;default:current_timestamp();comment:创建时间" json:"createdAt"`   // 创建时间
+	UpdatedAt   time.Time  `gorm:"column:updatedAt;not null;default:current_timestamp();comment:最后修改时间" json:"updatedAt"` // 最后修改时间
+}
+
+// TableName ExternalIdentity's table name
+func (*ExternalIdentity) TableName() string {
+	return TableNameExternalIdentity
+}
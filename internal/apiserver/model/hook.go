@@ -1,11 +1,18 @@
 package model
 
 import (
+	"fastgo/internal/pkg/known"
 	"fastgo/internal/pkg/rid"
 	"github.com/onexstack/onexstack/pkg/authn"
 	"gorm.io/gorm"
 )
 
+// BeforeCreate 在创建数据库记录前初始化版本号.
+func (m *Post) BeforeCreate(tx *gorm.DB) error {
+	m.Version = 1
+	return nil
+}
+
 // AfterCreate 在创建数据库记录之后生成 postID.
 func (m *Post) AfterCreate(tx *gorm.DB) error {
 	m.PostID = rid.PostID.New(uint64(m.ID))
@@ -27,5 +34,11 @@ func (m *User) BeforeCreate(tx *gorm.DB) error {
 		return err
 	}
 
+	// 新用户默认为普通用户
+	if m.Role == "" {
+		m.Role = known.RoleUser
+	}
+	m.Version = 1
+
 	return nil
 }
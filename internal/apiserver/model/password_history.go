@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+const TableNamePasswordHistory = "password_history"
+
+// PasswordHistory 历史密码表
+// 用户修改密码时记录被替换的密码哈希, 用于禁止重复使用最近使用过的密码.
+type PasswordHistory struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	TenantID  string    `gorm:"column:tenantID;not null;default:default;comment:租户 ID" json:"tenantID"`                  // 租户 ID
+	UserID    string    `gorm:"column:userID;not null;comment:用户唯一 ID" json:"userID"`                                    // 用户唯一 ID
+	Password  string    `gorm:"column:password;not null;comment:历史密码（加密后）" json:"-"`                                     // 历史密码（加密后）
+	CreatedAt time.Time `gorm:"column:createdAt;not null;default:current_timestamp();comment:密码被替换的时间" json:"createdAt"` // 密码被替换的时间
+}
+
+// TableName PasswordHistory's table name
+func (*PasswordHistory) TableName() string {
+	return TableNamePasswordHistory
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+const TableNameRecoveryCode = "recovery_code"
+
+// RecoveryCode 两步验证恢复码表
+// 只保存恢复码的哈希值, 每个恢复码只能使用一次.
+type RecoveryCode struct {
+	ID        int64      `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	TenantID  string     `gorm:"column:tenantID;not null;default:default;comment:租户 ID" json:"tenantID"`              // 租户 ID
+	UserID    string     `gorm:"column:userID;not null;comment:用户唯一 ID" json:"userID"`                                // 用户唯一 ID
+	CodeHash  string     `gorm:"column:codeHash;not null;comment:恢复码哈希值" json:"-"`                                    // 恢复码哈希值
+	UsedAt    *time.Time `gorm:"column:usedAt;comment:使用时间，使用后不可再使用" json:"usedAt"`                                   // 使用时间，使用后不可再使用
+	CreatedAt time.Time  `gorm:"column:createdAt;not null;default:current_timestamp();comment:创建时间" json:"createdAt"` // 创建时间
+}
+
+// TableName RecoveryCode's table name
+func (*RecoveryCode) TableName() string {
+	return TableNameRecoveryCode
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+const TableNameRefreshToken = "refresh_token"
+
+// RefreshToken refresh token 表
+// 同一次登录签发的 refresh token 及其轮换产生的后续 refresh token 属于同一个 token 家族(FamilyID).
+type RefreshToken struct {
+	ID        int64      `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	TenantID  string     `gorm:"column:tenantID;not null;default:default;comment:租户 ID" json:"tenantID"`                // 租户 ID
+	UserID    string     `gorm:"column:userID;not null;comment:用户唯一 ID" json:"userID"`                                  // 用户唯一 ID
+	FamilyID  string     `gorm:"column:familyID;not null;comment:token 家族 ID" json:"familyID"`                          // token 家族 ID
+	TokenHash string     `gorm:"column:tokenHash;not null;comment:refresh token 哈希值" json:"-"`                          // refresh token 哈希值
+	ExpiresAt time.Time  `gorm:"column:expiresAt;not null;comment:过期时间" json:"expiresAt"`                               // 过期时间
+	RevokedAt *time.Time `gorm:"column:revokedAt;comment:吊销时间，轮换或吊销后不可再使用" json:"revokedAt"`                            // 吊销时间，轮换或吊销后不可再使用
+	CreatedAt time.Time  `gorm:"column:createdAt;not null;default:current_timestamp();comment:创建时间" json:"createdAt"`   // 创建时间
+	UpdatedAt time.Time  `gorm:"column:updatedAt;not null;default:current_timestamp();comment:最后修改时间" json:"updatedAt"` // 最后修改时间
+}
+
+// TableName RefreshToken's table name
+func (*RefreshToken) TableName() string {
+	return TableNameRefreshToken
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+)
+
+const TableNameTwoFactor = "two_factor"
+
+// TwoFactor 两步验证表
+// 每个用户最多一条记录. 开始绑定验证器应用时创建记录, 用户使用验证码确认后才启用两步验证.
+type TwoFactor struct {
+	ID        int64      `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	TenantID  string     `gorm:"column:tenantID;not null;default:default;comment:租户 ID" json:"tenantID"`                // 租户 ID
+	UserID    string     `gorm:"column:userID;not null;comment:用户唯一 ID" json:"userID"`                                  // 用户唯一 ID
+	Secret    string     `gorm:"column:secret;not null;comment:TOTP 密钥（base32 编码）" json:"-"`                            // TOTP 密钥（base32 编码）
+	EnabledAt *time.Time `gorm:"column:enabledAt;comment:启用时间，确认之前为 NULL" json:"enabledAt"`                             // 启用时间，确认之前为 NULL
+	LastStep  int64      `gorm:"column:lastStep;not null;default:0;comment:最近一次验证通过的时间步" json:"lastStep"`               // 最近一次验证通过的时间步
+	CreatedAt time.Time  `gorm:"column:createdAt;not null;default:current_timestamp();comment:创建时间" json:"createdAt"`   // 创建时间
+	UpdatedAt time.Time  `gorm:"column:updatedAt;not null;default:current_timestamp();comment:最后修改时间" json:"updatedAt"` // 最后修改时间
+}
+
+// TableName TwoFactor's table name
+func (*TwoFactor) TableName() string {
+	return TableNameTwoFactor
+}
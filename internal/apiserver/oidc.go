@@ -0,0 +1,28 @@
+package apiserver
+
+import (
+	userv1 "fastgo/internal/apiserver/biz/v1/user"
+	"fastgo/internal/pkg/oidc"
+	genericoptions "fastgo/pkg/options"
+	"strings"
+)
+
+// newOIDCConfig 根据配置创建外部身份提供方登录的配置.
+// 身份提供方的元数据在第一次使用时获取, 身份提供方暂时不可用时不影响服务启动.
+func newOIDCConfig(opts *genericoptions.OIDCOptions) (userv1.OIDCConfig, error) {
+	providers := make(map[string]*userv1.OIDCProvider, len(opts.Providers))
+	for _, p := range opts.Providers {
+		provider, err := oidc.NewProvider(oidc.Config{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  strings.TrimSuffix(opts.CallbackURL, "/") + "/" + p.Name + "/callback",
+			Scopes:       p.Scopes,
+		})
+		if err != nil {
+			return userv1.OIDCConfig{}, err
+		}
+		providers[p.Name] = &userv1.OIDCProvider{Provider: provider, AutoProvision: p.AutoProvision}
+	}
+	return userv1.OIDCConfig{Providers: providers, StateExpiration: opts.StateExpiration}, nil
+}
@@ -0,0 +1,37 @@
+package apiserver
+
+import (
+	"fastgo/internal/pkg/password"
+	genericoptions "fastgo/pkg/options"
+)
+
+// newPasswordPolicy 根据配置创建密码策略.
+func newPasswordPolicy(opts *genericoptions.PasswordPolicyOptions) (*password.Policy, error) {
+	policy := &password.Policy{
+		Rules:   []password.Rule{password.Length(opts.MinLength, opts.MaxLength)},
+		History: opts.History,
+	}
+	if opts.RequireUpper {
+		policy.Rules = append(policy.Rules, password.RequireUpper())
+	}
+	if opts.RequireLower {
+		policy.Rules = append(policy.Rules, password.RequireLower())
+	}
+	if opts.RequireDigit {
+		policy.Rules = append(policy.Rules, password.RequireDigit())
+	}
+	if opts.RequireSymbol {
+		policy.Rules = append(policy.Rules, password.RequireSymbol())
+	}
+	if opts.RejectUsername {
+		policy.Rules = append(policy.Rules, password.NotContainUsername())
+	}
+	if opts.BlocklistFile != "" {
+		blocklist, err := password.LoadBlocklist(opts.BlocklistFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.Rules = append(policy.Rules, blocklist)
+	}
+	return policy, nil
+}
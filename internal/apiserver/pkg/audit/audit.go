@@ -0,0 +1,118 @@
+// Package audit 根据写操作前后的记录生成审计日志.
+//
+// 审计日志由 store 层在执行写操作的同一个事务中写入, 记录操作者、请求 ID、资源、操作以及字段级别的变更.
+// 变更以 JSON 保存, 键为字段名(与模型的 json 标签一致), 值为变更前后的字段值, 例如:
+//
+//	{"title":{"before":"hello","after":"hello world"}}
+//
+// 密码等敏感字段的值被替换为 Redacted, 只记录该字段发生了变更.
+// 回收站的定期清理(Purge)是跨租户的系统任务, 不记录审计日志.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/pkg/contextx"
+)
+
+// 资源类型.
+const (
+	ResourceUser = "user"
+	ResourcePost = "post"
+)
+
+// 操作类型.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+// Redacted 为敏感字段脱敏后的值.
+const Redacted = "******"
+
+var (
+	// sensitive 为需要脱敏的字段.
+	sensitive = map[string]bool{"password": true}
+	// ignored 为不记录变更的字段, 这些字段由数据库或 store 层维护, 每次写入都会变化或者与操作本身重复.
+	ignored = map[string]bool{"id": true, "tenantID": true, "version": true, "createdAt": true, "updatedAt": true, "deletedAt": true}
+)
+
+// Change 为一个字段的变更, 创建时 Before 为 nil, 删除时 After 为 nil.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Diff 比较 before 和 after 两条记录, 返回值发生变化的字段.
+// before 为 nil 表示创建, after 为 nil 表示删除, 此时返回另一条记录的所有字段.
+func Diff(before any, after any) map[string]Change {
+	prev, next := fields(before), fields(after)
+	changes := make(map[string]Change)
+	for name := range union(prev, next) {
+		if ignored[name] {
+			continue
+		}
+		b, inPrev := prev[name]
+		a, inNext := next[name]
+		if inPrev && inNext && reflect.DeepEqual(a, b) {
+			continue
+		}
+		if sensitive[name] {
+			b, a = redact(inPrev), redact(inNext)
+		}
+		changes[name] = Change{Before: b, After: a}
+	}
+	return changes
+}
+
+// New 创建一条审计日志, 操作者和请求 ID 从 ctx 中获取, 租户由 where.TenantPlugin 在写入时设置.
+func New(ctx context.Context, resource string, resourceID string, action string, before any, after any) *model.AuditLog {
+	diff, _ := json.Marshal(Diff(before, after))
+	return &model.AuditLog{
+		ActorID:    contextx.UserID(ctx),
+		RequestID:  contextx.RequestID(ctx),
+		Resource:   resource,
+		ResourceID: resourceID,
+		Action:     action,
+		Diff:       string(diff),
+	}
+}
+
+// fields 将记录转换为字段名到字段值的映射, 字段名与 json 标签一致, obj 为 nil 时返回空映射.
+func fields(obj any) map[string]any {
+	m := make(map[string]any)
+	if v := reflect.ValueOf(obj); !v.IsValid() || v.Kind() == reflect.Pointer && v.IsNil() {
+		return m
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return m
+	}
+	_ = json.Unmarshal(data, &m)
+	return m
+}
+
+// union 返回两个映射中所有的键.
+func union(a map[string]any, b map[string]any) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return keys
+}
+
+// redact 返回敏感字段脱敏后的值, 字段不存在时返回 nil.
+func redact(ok bool) any {
+	if !ok {
+		return nil
+	}
+	return Redacted
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/pkg/contextx"
+)
+
+func TestDiff(t *testing.T) {
+	post := &model.Post{ID: 1, TenantID: "acme", UserID: "user-a", PostID: "post-a", Title: "hello", Content: "world", Version: 1, CreatedAt: time.Unix(1, 0)}
+	edited := *post
+	edited.Title = "hello world"
+	edited.Version = 2
+	edited.UpdatedAt = time.Unix(2, 0)
+
+	user := &model.User{ID: 1, UserID: "user-a", Username: "alice", Password: "$2a$10$old", Nickname: "alice"}
+	rehashed := *user
+	rehashed.Password = "$2a$10$new"
+
+	tests := []struct {
+		name   string
+		before any
+		after  any
+		want   map[string]Change
+	}{
+		{
+			name:  "create",
+			after: post,
+			want: map[string]Change{
+				"userID":  {After: "user-a"},
+				"postID":  {After: "post-a"},
+				"title":   {After: "hello"},
+				"content": {After: "world"},
+			},
+		},
+		{
+			name:   "delete",
+			before: post,
+			want: map[string]Change{
+				"userID":  {Before: "user-a"},
+				"postID":  {Before: "post-a"},
+				"title":   {Before: "hello"},
+				"content": {Before: "world"},
+			},
+		},
+		{
+			name:   "update omits unchanged and ignored fields",
+			before: post,
+			after:  &edited,
+			want:   map[string]Change{"title": {Before: "hello", After: "hello world"}},
+		},
+		{name: "nothing changed", before: post, after: post, want: map[string]Change{}},
+		{name: "typed nil", before: (*model.Post)(nil), after: (*model.Post)(nil), want: map[string]Change{}},
+		{
+			name:   "password is redacted",
+			before: user,
+			after:  &rehashed,
+			want:   map[string]Change{"password": {Before: Redacted, After: Redacted}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	ctx := contextx.WithRequestID(contextx.WithUserID(context.Background(), "admin"), "request-a")
+	user := &model.User{UserID: "user-a", Username: "alice", Password: "$2a$10$secret"}
+
+	log := New(ctx, ResourceUser, "user-a", ActionDelete, user, nil)
+	if log.ActorID != "admin" || log.RequestID != "request-a" || log.Resource != ResourceUser || log.ResourceID != "user-a" || log.Action != ActionDelete {
+		t.Errorf("New() = %+v", log)
+	}
+	// 密码即使被删除也不能以明文出现在审计日志中
+	if strings.Contains(log.Diff, "secret") {
+		t.Errorf("Diff = %s, contains the password", log.Diff)
+	}
+	var diff map[string]Change
+	if err := json.Unmarshal([]byte(log.Diff), &diff); err != nil {
+		t.Fatalf("Diff is not valid JSON: %v", err)
+	}
+	if diff["password"].Before != Redacted || diff["username"].Before != "alice" {
+		t.Errorf("Diff = %s", log.Diff)
+	}
+}
@@ -0,0 +1,18 @@
+package conversion
+
+import (
+	"encoding/json"
+
+	"fastgo/internal/apiserver/model"
+	apiv1 "fastgo/pkg/api/apiserver/v1"
+	"github.com/onexstack/onexstack/pkg/core"
+)
+
+// AuditLogodelToAuditLogV1 将模型层的 AuditLog（审计日志模型对象）转换为 Protobuf 层的 AuditLog（v1 审计日志对象）.
+func AuditLogodelToAuditLogV1(auditLogModel *model.AuditLog) *apiv1.AuditLog {
+	var protoAuditLog apiv1.AuditLog
+	_ = core.CopyWithConverters(&protoAuditLog, auditLogModel)
+	// 数据库中的变更为 JSON 字符串, 原样返回
+	protoAuditLog.Diff = json.RawMessage(auditLogModel.Diff)
+	return &protoAuditLog
+}
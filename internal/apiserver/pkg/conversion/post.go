@@ -4,12 +4,19 @@ import (
 	"fastgo/internal/apiserver/model"
 	apiv1 "fastgo/pkg/api/apiserver/v1"
 	"github.com/onexstack/onexstack/pkg/core"
+	"gorm.io/gorm"
 )
 
 // PostodelToPostV1 将模型层的 Post（博客模型对象）转换为 Protobuf 层的 Post（v1 博客对象）.
 func PostodelToPostV1(postModel *model.Post) *apiv1.Post {
 	var protoPost apiv1.Post
 	_ = core.CopyWithConverters(&protoPost, postModel)
+	// gorm.DeletedAt 无法直接复制, 只有软删除的记录才返回删除时间
+	protoPost.DeletedAt = nil
+	if postModel.DeletedAt.Valid {
+		deletedAt := postModel.DeletedAt.Time
+		protoPost.DeletedAt = &deletedAt
+	}
 	return &protoPost
 }
 
@@ -17,5 +24,9 @@ func PostodelToPostV1(postModel *model.Post) *apiv1.Post {
 func PostV1ToPostodel(protoPost *apiv1.Post) *model.Post {
 	var postModel model.Post
 	_ = core.CopyWithConverters(&postModel, protoPost)
+	postModel.DeletedAt = gorm.DeletedAt{}
+	if protoPost.DeletedAt != nil {
+		postModel.DeletedAt = gorm.DeletedAt{Time: *protoPost.DeletedAt, Valid: true}
+	}
 	return &postModel
 }
@@ -4,12 +4,19 @@ import (
 	"fastgo/internal/apiserver/model"
 	apiv1 "fastgo/pkg/api/apiserver/v1"
 	"github.com/onexstack/onexstack/pkg/core"
+	"gorm.io/gorm"
 )
 
 // UserodelToUserV1 将模型层的 User（用户模型对象）转换为 Protobuf 层的 User（v1 用户对象）.
 func UserodelToUserV1(userModel *model.User) *apiv1.User {
 	var protoUser apiv1.User
 	_ = core.CopyWithConverters(&protoUser, userModel)
+	// gorm.DeletedAt 无法直接复制, 只有软删除的记录才返回删除时间
+	protoUser.DeletedAt = nil
+	if userModel.DeletedAt.Valid {
+		deletedAt := userModel.DeletedAt.Time
+		protoUser.DeletedAt = &deletedAt
+	}
 	return &protoUser
 }
 
@@ -17,5 +24,16 @@ func UserodelToUserV1(userModel *model.User) *apiv1.User {
 func UserV1ToUserodel(protoUser *apiv1.User) *model.User {
 	var userModel model.User
 	_ = core.CopyWithConverters(&userModel, protoUser)
+	userModel.DeletedAt = gorm.DeletedAt{}
+	if protoUser.DeletedAt != nil {
+		userModel.DeletedAt = gorm.DeletedAt{Time: *protoUser.DeletedAt, Valid: true}
+	}
 	return &userModel
 }
+
+// ExternalIdentityodelToExternalIdentityV1 将模型层的 ExternalIdentity（外部身份模型对象）转换为 Protobuf 层的 ExternalIdentity（v1 外部身份对象）.
+func ExternalIdentityodelToExternalIdentityV1(identityModel *model.ExternalIdentity) *apiv1.ExternalIdentity {
+	var protoIdentity apiv1.ExternalIdentity
+	_ = core.CopyWithConverters(&protoIdentity, identityModel)
+	return &protoIdentity
+}
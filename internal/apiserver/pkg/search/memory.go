@@ -0,0 +1,201 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/store"
+	where "fastgo/pkg/store"
+)
+
+const (
+	// titleBoost 为标题中的词相对于正文中的词的权重.
+	titleBoost = 2.0
+	// BM25 算法的参数.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// memory 是基于内存倒排索引的 Searcher, 使用 BM25 算法计算相关度.
+type memory struct {
+	store store.IStore
+
+	mu sync.Mutex
+	// indexes 按照租户保存倒排索引, 未注册租户时只有一个键为空字符串的索引.
+	indexes map[string]*index
+}
+
+// index 是一个租户的倒排索引.
+type index struct {
+	docs map[string]*document
+	// postings 记录每个词在每篇博客中的加权词频.
+	postings map[string]map[string]float64
+	// totalLength 为所有博客的加权长度之和, 用于计算平均长度.
+	totalLength float64
+}
+
+// document 为索引中的一篇博客.
+type document struct {
+	post   *model.Post
+	length float64
+	terms  map[string]float64
+}
+
+var _ Searcher = (*memory)(nil)
+
+// NewMemory 创建内存倒排索引, 每个租户的索引在第一次检索时从 store 中加载.
+func NewMemory(store store.IStore) *memory {
+	return &memory{store: store, indexes: make(map[string]*index)}
+}
+
+// Index 将博客加入索引, 租户的索引尚未加载时忽略, 加载时会包含该博客.
+func (m *memory) Index(ctx context.Context, post *model.Post) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if idx, ok := m.indexes[tenant(ctx)]; ok {
+		idx.add(post)
+	}
+	return nil
+}
+
+// Remove 将博客从索引中删除.
+func (m *memory) Remove(ctx context.Context, postIDs ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if idx, ok := m.indexes[tenant(ctx)]; ok {
+		for _, postID := range postIDs {
+			idx.remove(postID)
+		}
+	}
+	return nil
+}
+
+// Search 检索博客, 结果按照 BM25 相关度降序排列, 相关度相同时较新的博客在前.
+func (m *memory) Search(ctx context.Context, q *Query) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	idx, err := m.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := unique(Tokenize(q.Text))
+	scores := make(map[string]float64)
+	n := float64(len(idx.docs))
+	avgLength := idx.totalLength / max(n, 1)
+	for _, term := range terms {
+		postings := idx.postings[term]
+		idf := math.Log(1 + (n-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+		for postID, tf := range postings {
+			doc := idx.docs[postID]
+			if q.UserID != "" && doc.post.UserID != q.UserID {
+				continue
+			}
+			scores[postID] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*doc.length/avgLength))
+		}
+	}
+
+	hits := make([]*Hit, 0, len(scores))
+	for postID, score := range scores {
+		hits = append(hits, &Hit{PostID: postID, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return idx.docs[hits[i].PostID].post.ID > idx.docs[hits[j].PostID].post.ID
+	})
+
+	result := &Result{TotalCount: int64(len(hits))}
+	for _, h := range page(hits, q.Offset, q.Limit) {
+		result.Hits = append(result.Hits, hit(idx.docs[h.PostID].post, terms, h.Score))
+	}
+	return result, nil
+}
+
+// load 返回当前租户的索引, 索引不存在时从 store 中加载该租户的所有博客.
+func (m *memory) load(ctx context.Context) (*index, error) {
+	key := tenant(ctx)
+	if idx, ok := m.indexes[key]; ok {
+		return idx, nil
+	}
+
+	_, posts, err := m.store.Post().List(ctx, where.NewWhere().NoCount())
+	if err != nil {
+		return nil, err
+	}
+	idx := &index{docs: make(map[string]*document), postings: make(map[string]map[string]float64)}
+	for _, post := range posts {
+		idx.add(post)
+	}
+	m.indexes[key] = idx
+	return idx, nil
+}
+
+// add 将博客加入索引, 博客已存在时先删除旧的索引.
+func (idx *index) add(post *model.Post) {
+	idx.remove(post.PostID)
+
+	copied := *post
+	doc := &document{post: &copied, terms: make(map[string]float64)}
+	for _, term := range Tokenize(post.Title) {
+		doc.terms[term] += titleBoost
+		doc.length += titleBoost
+	}
+	for _, term := range Tokenize(post.Content) {
+		doc.terms[term]++
+		doc.length++
+	}
+
+	idx.docs[post.PostID] = doc
+	idx.totalLength += doc.length
+	for term, tf := range doc.terms {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[string]float64)
+		}
+		idx.postings[term][post.PostID] = tf
+	}
+}
+
+// remove 将博客从索引中删除.
+func (idx *index) remove(postID string) {
+	doc, ok := idx.docs[postID]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		delete(idx.postings[term], postID)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	idx.totalLength -= doc.length
+	delete(idx.docs, postID)
+}
+
+// tenant 返回 ctx 中的租户, 未注册租户时返回空字符串.
+func tenant(ctx context.Context) string {
+	if t, ok := where.RegisteredTenant(); ok {
+		return t.ValueFunc(ctx)
+	}
+	return ""
+}
+
+// unique 返回去重后的词, 保持原有顺序.
+func unique(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	ret := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			ret = append(ret, term)
+		}
+	}
+	return ret
+}
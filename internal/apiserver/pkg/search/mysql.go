@@ -0,0 +1,72 @@
+package search
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/pkg/errorsx"
+	"gorm.io/gorm"
+)
+
+// match 为 MySQL 全文检索条件, 需要与 FULLTEXT 索引 `ft_post_title_content` 的列一致.
+const match = "MATCH(`title`, `content`) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+// mysql 是基于 MySQL FULLTEXT 索引的 Searcher, 索引由数据库在写入时维护.
+type mysql struct {
+	db *gorm.DB
+}
+
+var _ Searcher = (*mysql)(nil)
+
+// NewMySQL 创建基于 MySQL FULLTEXT 索引的 Searcher.
+func NewMySQL(db *gorm.DB) *mysql {
+	return &mysql{db: db}
+}
+
+// Index 由数据库维护索引, 无需任何操作.
+func (s *mysql) Index(ctx context.Context, post *model.Post) error {
+	return nil
+}
+
+// Remove 由数据库维护索引, 无需任何操作.
+func (s *mysql) Remove(ctx context.Context, postIDs ...string) error {
+	return nil
+}
+
+// Search 使用自然语言模式检索博客, 结果按照 MySQL 计算的相关度降序排列.
+func (s *mysql) Search(ctx context.Context, q *Query) (*Result, error) {
+	text := strings.TrimSpace(q.Text)
+	// 通过 Model 查询, 使租户隔离插件生效
+	db := s.db.WithContext(ctx).Model(&model.Post{}).Where(match, text)
+	if q.UserID != "" {
+		db = db.Where("userID = ?", q.UserID)
+	}
+
+	var count int64
+	if err := db.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to count search results", "err", err)
+		return nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+
+	var rows []struct {
+		model.Post
+		Score float64
+	}
+	db = db.Select("*, "+match+" AS score", text).Order("score desc").Order("id desc").Offset(q.Offset)
+	if q.Limit > 0 {
+		db = db.Limit(q.Limit)
+	}
+	if err := db.Scan(&rows).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to search posts", "err", err)
+		return nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+
+	terms := unique(Tokenize(text))
+	result := &Result{TotalCount: count, Hits: make([]*Hit, 0, len(rows))}
+	for _, row := range rows {
+		result.Hits = append(result.Hits, hit(&row.Post, terms, row.Score))
+	}
+	return result, nil
+}
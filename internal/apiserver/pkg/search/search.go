@@ -0,0 +1,93 @@
+// Package search 实现了博客的全文检索.
+//
+// 检索通过 Searcher 接口完成, 提供两种实现:
+//   - mysql: 使用 MySQL 的 FULLTEXT 索引(ngram 分词器, 支持中日韩文字), 索引由数据库维护;
+//   - memory: 纯 Go 实现的倒排索引, 适用于 SQLite 和单元测试, 索引保存在进程内存中,
+//     每个租户的索引在第一次检索时从 store 中加载, 之后由 Index/Remove 增量更新.
+//
+// 两种实现都按照相关度降序返回结果, 并使用相同的分词规则生成高亮片段.
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/store"
+	"gorm.io/gorm"
+)
+
+// Searcher 定义了全文检索需要实现的方法.
+type Searcher interface {
+	// Index 将博客加入索引, 博客已存在时更新索引.
+	Index(ctx context.Context, post *model.Post) error
+	// Remove 将博客从索引中删除.
+	Remove(ctx context.Context, postIDs ...string) error
+	// Search 检索博客, 结果按照相关度降序排列.
+	Search(ctx context.Context, q *Query) (*Result, error)
+}
+
+// Query 为检索条件.
+type Query struct {
+	// Text 为检索词, 多个词之间为 OR 关系, 命中的词越多相关度越高.
+	Text string
+	// UserID 不为空时, 只检索该用户的博客.
+	UserID string
+	// Offset 和 Limit 用于分页, Limit 小于等于 0 时返回所有结果.
+	Offset int
+	Limit  int
+}
+
+// Hit 为一条检索结果.
+type Hit struct {
+	// PostID 为命中的博客 ID.
+	PostID string
+	// Score 为相关度, 只能用于同一次检索的结果之间比较.
+	Score float64
+	// Title 为高亮后的标题.
+	Title string
+	// Snippet 为正文中命中检索词的高亮片段.
+	Snippet string
+}
+
+// Result 为检索结果.
+type Result struct {
+	// TotalCount 为命中的博客总数.
+	TotalCount int64
+	// Hits 为分页后的检索结果.
+	Hits []*Hit
+}
+
+// New 根据数据库驱动创建 Searcher, mysql 使用 FULLTEXT 索引, 其他驱动使用内存倒排索引.
+func New(driver string, db *gorm.DB, store store.IStore) (Searcher, error) {
+	switch driver {
+	case "mysql":
+		return NewMySQL(db), nil
+	case "sqlite":
+		return NewMemory(store), nil
+	default:
+		return nil, fmt.Errorf("unsupported search driver: %s", driver)
+	}
+}
+
+// hit 根据博客和检索词生成检索结果.
+func hit(post *model.Post, terms []string, score float64) *Hit {
+	return &Hit{
+		PostID:  post.PostID,
+		Score:   score,
+		Title:   Highlight(post.Title, terms, 0),
+		Snippet: Highlight(post.Content, terms, snippetSize),
+	}
+}
+
+// page 返回 [offset, offset+limit) 范围内的元素.
+func page[T any](items []T, offset int, limit int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[max(offset, 0):]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
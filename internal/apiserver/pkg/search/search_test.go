@@ -0,0 +1,182 @@
+package search
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/apiserver/store/fake"
+	"fastgo/internal/pkg/contextx"
+	where "fastgo/pkg/store"
+)
+
+// createPost 在 store 中创建一篇博客, 博客 ID 由 store 生成.
+func createPost(t *testing.T, ds store.IStore, ctx context.Context, userID string, title string, content string) *model.Post {
+	t.Helper()
+
+	post := &model.Post{UserID: userID, Title: title, Content: content}
+	if err := ds.Post().Create(ctx, post); err != nil {
+		t.Fatalf("Post().Create() error = %v", err)
+	}
+	return post
+}
+
+// search 执行检索并返回命中的博客 ID.
+func search(t *testing.T, s Searcher, ctx context.Context, q *Query) []string {
+	t.Helper()
+
+	result, err := s.Search(ctx, q)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	var ids []string
+	for _, h := range result.Hits {
+		ids = append(ids, h.PostID)
+	}
+	return ids
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "Hello, World!", want: []string{"hello", "world"}},
+		{text: "Go1.24 release", want: []string{"go1", "24", "release"}},
+		{text: "数据库", want: []string{"数据", "据库"}},
+		{text: "中", want: []string{"中"}},
+		{text: "MySQL数据库索引", want: []string{"mysql", "数据", "据库", "库索", "索引"}},
+		{text: "東京とソウル", want: []string{"東京", "京と", "とソ", "ソウ", "ウル"}},
+		{text: "  ", want: []string{}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	long := "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. " +
+		"Golang appears here. Ut enim ad minim veniam, quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat."
+
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		size  int
+		want  string
+	}{
+		{name: "case insensitive", text: "Go and go", terms: []string{"go"}, want: "<em>Go</em> and <em>go</em>"},
+		{name: "no hit", text: "rust", terms: []string{"go"}, want: "rust"},
+		{
+			name:  "html is escaped",
+			text:  "<script>alert('go')</script>",
+			terms: []string{"go", "script"},
+			want:  "&lt;<em>script</em>&gt;alert(&#39;<em>go</em>&#39;)&lt;/<em>script</em>&gt;",
+		},
+		{name: "overlapping cjk bigrams are merged", text: "分布式数据库", terms: []string{"数据", "据库"}, want: "分布式<em>数据库</em>"},
+		{
+			name:  "snippet around the first hit",
+			text:  long,
+			terms: []string{"golang"},
+			size:  40,
+			want:  "...a aliqua. <em>Golang</em> appears here. Ut enim a...",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, tt.terms, tt.size); got != tt.want {
+				t.Errorf("Highlight() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemorySearch(t *testing.T) {
+	ctx := context.Background()
+	ds := fake.NewStore()
+	a := createPost(t, ds, ctx, "user-a", "Cooking", "let's go shopping and cook dinner together tonight")
+	b := createPost(t, ds, ctx, "user-a", "Go concurrency", "goroutines and channels in go")
+	c := createPost(t, ds, ctx, "user-b", "Rust", "ownership and borrowing")
+	d := createPost(t, ds, ctx, "user-b", "分布式数据库", "<script>alert('数据库')</script>")
+	e := createPost(t, ds, ctx, "user-b", "Rust", "ownership and borrowing")
+	s := NewMemory(ds)
+
+	tests := []struct {
+		name string
+		q    *Query
+		want []string
+	}{
+		{name: "title hits rank higher", q: &Query{Text: "go"}, want: []string{b.PostID, a.PostID}},
+		{name: "more terms rank higher", q: &Query{Text: "cook channels go"}, want: []string{b.PostID, a.PostID}},
+		{name: "ties prefer newer posts", q: &Query{Text: "ownership"}, want: []string{e.PostID, c.PostID}},
+		{name: "cjk", q: &Query{Text: "数据库"}, want: []string{d.PostID}},
+		{name: "single user", q: &Query{Text: "go", UserID: "user-b"}},
+		{name: "paging", q: &Query{Text: "go", Offset: 1, Limit: 1}, want: []string{a.PostID}},
+		{name: "no hit", q: &Query{Text: "python"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := search(t, s, ctx, tt.q); !slices.Equal(got, tt.want) {
+				t.Errorf("Search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 高亮片段中的 HTML 被转义
+	result, err := s.Search(ctx, &Query{Text: "数据库"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if hit := result.Hits[0]; hit.Title != "分布式<em>数据库</em>" || hit.Snippet != "&lt;script&gt;alert(&#39;<em>数据库</em>&#39;)&lt;/script&gt;" {
+		t.Errorf("Search() hit = %+v", hit)
+	}
+
+	// 索引加载后由 Index/Remove 增量更新
+	f := createPost(t, ds, ctx, "user-b", "Go generics", "type parameters")
+	_ = s.Index(ctx, f)
+	_ = s.Remove(ctx, a.PostID)
+	if got, want := search(t, s, ctx, &Query{Text: "go"}), []string{b.PostID, f.PostID}; !slices.Equal(got, want) {
+		t.Errorf("Search() after Index() and Remove() = %v, want %v", got, want)
+	}
+}
+
+func TestMemoryTenantIsolation(t *testing.T) {
+	where.RegisterTenant("tenantID", func(ctx context.Context) string {
+		if tenantID := contextx.TenantID(ctx); tenantID != "" {
+			return tenantID
+		}
+		return "default"
+	})
+	t.Cleanup(func() { where.RegisterTenant("", nil) })
+
+	acme := contextx.WithTenantID(context.Background(), "acme")
+	umbrella := contextx.WithTenantID(context.Background(), "umbrella")
+	ds := fake.NewStore()
+	a := createPost(t, ds, acme, "user-a", "Go at acme", "")
+	b := createPost(t, ds, umbrella, "user-b", "Go at umbrella", "")
+	s := NewMemory(ds)
+
+	if got, want := search(t, s, acme, &Query{Text: "go"}), []string{a.PostID}; !slices.Equal(got, want) {
+		t.Errorf("Search() in acme = %v, want %v", got, want)
+	}
+	if got, want := search(t, s, umbrella, &Query{Text: "go"}), []string{b.PostID}; !slices.Equal(got, want) {
+		t.Errorf("Search() in umbrella = %v, want %v", got, want)
+	}
+
+	// 新博客只加入所属租户的索引
+	c := createPost(t, ds, acme, "user-a", "More go at acme", "")
+	_ = s.Index(acme, c)
+	if got, want := search(t, s, acme, &Query{Text: "go"}), []string{a.PostID, c.PostID}; !slices.Equal(got, want) {
+		t.Errorf("Search() in acme after Index() = %v, want %v", got, want)
+	}
+	if got, want := search(t, s, umbrella, &Query{Text: "go"}), []string{b.PostID}; !slices.Equal(got, want) {
+		t.Errorf("Search() in umbrella after Index() in acme = %v, want %v", got, want)
+	}
+	if got := search(t, s, context.Background(), &Query{Text: "go"}); len(got) != 0 {
+		t.Errorf("Search() in the default tenant = %v, want none", got)
+	}
+}
@@ -0,0 +1,160 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// snippetSize 为高亮片段的最大字符数.
+	snippetSize = 120
+	// highlightStart 和 highlightEnd 用于标记命中的检索词.
+	highlightStart = "<em>"
+	highlightEnd   = "</em>"
+)
+
+// token 为分词结果, start 和 end 为该词在原文中的字节偏移.
+type token struct {
+	term  string
+	start int
+	end   int
+}
+
+// Tokenize 对文本分词, 返回去重前的所有词.
+// 字母和数字组成的单词转换为小写; 中日韩文字与 MySQL 的 ngram 分词器(ngram_token_size=2)一样按照二元组分词,
+// 只有一个字时作为一个词.
+func Tokenize(text string) []string {
+	tokens := tokenize(text)
+	terms := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		terms = append(terms, t.term)
+	}
+	return terms
+}
+
+// tokenize 对文本分词, 并记录每个词在原文中的位置.
+func tokenize(text string) []token {
+	var (
+		tokens []token
+		// word 为当前单词的起始偏移, cjk 为当前中日韩文字序列中每个字的偏移
+		word = -1
+		cjk  []int
+	)
+	flushWord := func(end int) {
+		if word >= 0 {
+			tokens = append(tokens, token{term: strings.ToLower(text[word:end]), start: word, end: end})
+			word = -1
+		}
+	}
+	flushCJK := func(end int) {
+		switch len(cjk) {
+		case 0:
+		case 1:
+			tokens = append(tokens, token{term: text[cjk[0]:end], start: cjk[0], end: end})
+		default:
+			for i := 0; i+1 < len(cjk); i++ {
+				next := end
+				if i+2 < len(cjk) {
+					next = cjk[i+2]
+				}
+				tokens = append(tokens, token{term: text[cjk[i]:next], start: cjk[i], end: next})
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for i, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord(i)
+			cjk = append(cjk, i)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK(i)
+			if word < 0 {
+				word = i
+			}
+		default:
+			flushWord(i)
+			flushCJK(i)
+		}
+	}
+	flushWord(len(text))
+	flushCJK(len(text))
+	return tokens
+}
+
+// isCJK 判断字符是否为中日韩文字.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// Highlight 使用 <em></em> 标记 text 中命中 terms 的部分, 其余部分进行 HTML 转义.
+// size 大于 0 时, 只返回第一个命中位置附近最多 size 个字符的片段, 片段被截断时添加省略号.
+func Highlight(text string, terms []string, size int) string {
+	want := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		want[term] = true
+	}
+
+	// 合并相邻或重叠的命中区间, 例如中文二元组 "数据" 和 "据库"
+	var spans [][2]int
+	for _, t := range tokenize(text) {
+		if !want[t.term] {
+			continue
+		}
+		if n := len(spans); n > 0 && t.start <= spans[n-1][1] {
+			spans[n-1][1] = max(spans[n-1][1], t.end)
+			continue
+		}
+		spans = append(spans, [2]int{t.start, t.end})
+	}
+
+	start, end := 0, len(text)
+	if size > 0 && utf8.RuneCountInString(text) > size {
+		// 让第一个命中位置位于片段的前四分之一处
+		if len(spans) > 0 {
+			start = backward(text, spans[0][0], size/4)
+		}
+		end = forward(text, start, size)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	pos := start
+	for _, span := range spans {
+		if span[1] <= start || span[0] >= end {
+			continue
+		}
+		s, e := max(span[0], start), min(span[1], end)
+		b.WriteString(html.EscapeString(text[pos:s]))
+		b.WriteString(highlightStart + html.EscapeString(text[s:e]) + highlightEnd)
+		pos = e
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+// backward 返回 text 中 offset 之前第 n 个字符的偏移.
+func backward(text string, offset int, n int) int {
+	for ; n > 0 && offset > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(text[:offset])
+		offset -= size
+	}
+	return offset
+}
+
+// forward 返回 text 中 offset 之后第 n 个字符的偏移.
+func forward(text string, offset int, n int) int {
+	for ; n > 0 && offset < len(text); n-- {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+	return offset
+}
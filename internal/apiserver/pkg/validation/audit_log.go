@@ -0,0 +1,26 @@
+package validation
+
+import (
+	"context"
+	"errors"
+
+	"fastgo/internal/apiserver/pkg/audit"
+	v1 "fastgo/pkg/api/apiserver/v1"
+)
+
+// ValidateListAuditLogRequest 用于校验审计日志列表请求的输入有效性.
+func (v *Validator) ValidateListAuditLogRequest(ctx context.Context, rq *v1.ListAuditLogRequest) error {
+	if rq.Resource != "" && rq.Resource != audit.ResourceUser && rq.Resource != audit.ResourcePost {
+		return errors.New("Resource must be one of: user, post")
+	}
+	if rq.ResourceID != "" && rq.Resource == "" {
+		return errors.New("ResourceID must be used together with resource")
+	}
+	if !rq.Since.IsZero() && !rq.Until.IsZero() && !rq.Since.Before(rq.Until) {
+		return errors.New("Since must be earlier than until")
+	}
+	if rq.Limit < 0 || rq.Limit > 100 {
+		return errors.New("Limit must be between 0 and 100")
+	}
+	return nil
+}
@@ -2,6 +2,10 @@ package validation
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
 	v1 "fastgo/pkg/api/apiserver/v1"
 )
 
@@ -12,3 +16,17 @@ func (v *Validator) ValidateCreatePostRequest(ctx context.Context, rq *v1.Create
 func (v *Validator) ValidateUpdatePostRequest(ctx context.Context, rq *v1.UpdatePostRequest) error {
 	return nil
 }
+
+// ValidateSearchPostRequest 用于校验全文检索请求的输入有效性.
+func (v *Validator) ValidateSearchPostRequest(ctx context.Context, rq *v1.SearchPostRequest) error {
+	if strings.TrimSpace(rq.Q) == "" {
+		return errors.New("Search query cannot be empty")
+	}
+	if utf8.RuneCountInString(rq.Q) > 256 {
+		return errors.New("Search query cannot exceed 256 characters")
+	}
+	if rq.Limit < 0 || rq.Limit > 100 {
+		return errors.New("Limit must be between 0 and 100")
+	}
+	return nil
+}
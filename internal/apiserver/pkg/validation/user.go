@@ -3,7 +3,14 @@ package validation
 import (
 	"context"
 	"errors"
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/known"
 	v1 "fastgo/pkg/api/apiserver/v1"
+	where "fastgo/pkg/store"
+	"fastgo/pkg/token"
+	"net/mail"
+	"strings"
 )
 
 // ValidateCreateUserRequest 用于校验创建用户请求的输入有效性.
@@ -21,8 +28,8 @@ func (v *Validator) ValidateCreateUserRequest(ctx context.Context, rq *v1.Create
 	if rq.Password == "" {
 		return errors.New("Password cannot be empty")
 	}
-	if len(rq.Password) < 8 || len(rq.Password) > 64 {
-		return errors.New("Password must be between 8 and 64 characters")
+	if err := v.validatePassword(rq.Password, rq.Username); err != nil {
+		return err
 	}
 
 	// 验证昵称
@@ -36,6 +43,9 @@ func (v *Validator) ValidateCreateUserRequest(ctx context.Context, rq *v1.Create
 	if rq.Email == "" {
 		return errors.New("Email cannot be empty")
 	}
+	if err := validateEmail(rq.Email); err != nil {
+		return err
+	}
 
 	// 验证手机号
 	if rq.Phone == "" {
@@ -46,8 +56,68 @@ func (v *Validator) ValidateCreateUserRequest(ctx context.Context, rq *v1.Create
 }
 
 // ValidateUpdateUserRequest 用于校验修改用户信息请求的输入有效性.
-// 待补充...
+// 只允许用户修改自己的信息, 管理员可以修改任意用户的信息.
 func (v *Validator) ValidateUpdateUserRequest(ctx context.Context, rq *v1.UpdateUserRequest) error {
+	if err := validateUserID(ctx, rq.UserID); err != nil {
+		return err
+	}
+
+	// 验证用户名
+	if rq.Username != nil && (len(*rq.Username) < 4 || len(*rq.Username) > 32) {
+		return errorsx.ErrInvalidArgument.WithMessage("Username must be between 4 and 32 characters")
+	}
+
+	// 验证昵称
+	if rq.Nickname != nil && len(*rq.Nickname) > 32 {
+		return errorsx.ErrInvalidArgument.WithMessage("Nickname cannot exceed 32 characters")
+	}
+
+	// 验证email
+	if rq.Email != nil {
+		if err := validateEmail(*rq.Email); err != nil {
+			return errorsx.ErrInvalidArgument.WithMessage("%s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// ValidateDeleteUserRequest 用于校验删除用户请求的输入有效性.
+// 只允许用户删除自己, 管理员可以删除任意用户.
+func (v *Validator) ValidateDeleteUserRequest(ctx context.Context, rq *v1.DeleteUserRequest) error {
+	return validateUserID(ctx, rq.UserID)
+}
+
+// ValidateGetUserRequest 用于校验查询用户详情请求的输入有效性.
+// 只允许用户查询自己的详情, 管理员可以查询任意用户的详情.
+func (v *Validator) ValidateGetUserRequest(ctx context.Context, rq *v1.GetUserRequest) error {
+	return validateUserID(ctx, rq.UserID)
+}
+
+// validateUserID 校验路径参数中的 userID 是否为当前登录用户的 ID, 管理员可以操作任意用户.
+// 当前登录用户的 ID 和角色分别由认证中间件和授权中间件注入 ctx.
+func validateUserID(ctx context.Context, userID string) error {
+	if userID == "" {
+		return errorsx.ErrPermissionDenied
+	}
+	if userID != contextx.UserID(ctx) && contextx.Role(ctx) != known.RoleAdmin {
+		return errorsx.ErrPermissionDenied
+	}
+	return nil
+}
+
+// ValidateUpdateUserRoleRequest 用于校验修改用户角色请求的输入有效性.
+func (v *Validator) ValidateUpdateUserRoleRequest(ctx context.Context, rq *v1.UpdateUserRoleRequest) error {
+	if rq.UserID == "" {
+		return errorsx.ErrInvalidArgument.WithMessage("UserID cannot be empty")
+	}
+	if rq.Role != known.RoleAdmin && rq.Role != known.RoleUser {
+		return errorsx.ErrInvalidArgument.WithMessage("Role must be one of: %s, %s", known.RoleAdmin, known.RoleUser)
+	}
+	// 防止管理员误操作撤销自己的管理员角色, 导致系统中没有管理员
+	if rq.UserID == contextx.UserID(ctx) && rq.Role != known.RoleAdmin {
+		return errorsx.ErrInvalidArgument.WithMessage("Cannot revoke the admin role of yourself")
+	}
 	return nil
 }
 
@@ -73,9 +143,22 @@ func (v *Validator) ValidateLoginRequest(ctx context.Context, rq *v1.LoginReques
 	return nil
 }
 
+// ValidateRefreshTokenRequest 用于校验刷新令牌请求的输入有效性.
+func (v *Validator) ValidateRefreshTokenRequest(ctx context.Context, rq *v1.RefreshTokenRequest) error {
+	if rq.RefreshToken == "" {
+		return errors.New("Refresh token cannot be empty")
+	}
+	return nil
+}
+
 // ValidateChangePasswordRequest 用于校验修改密码请求的密码有效性.
 // 对旧密码和新密码进行校验.
 func (v *Validator) ValidateChangePasswordRequest(ctx context.Context, rq *v1.ChangePasswordRequest) error {
+	// 只允许修改自己的密码, 管理员也不例外(需要校验旧密码)
+	if rq.UserID == "" || rq.UserID != contextx.UserID(ctx) {
+		return errorsx.ErrPermissionDenied
+	}
+
 	// 验证旧密码有效性
 	if rq.OldPassword == "" {
 		return errors.New("Password cannot be empty")
@@ -83,17 +166,201 @@ func (v *Validator) ValidateChangePasswordRequest(ctx context.Context, rq *v1.Ch
 	if len(rq.OldPassword) < 8 || len(rq.OldPassword) > 64 {
 		return errors.New("Password must be between 8 and 64 characters")
 	}
-	// 验证新密码有效性
+	// 验证新旧密码不相同
+	if rq.OldPassword == rq.NewPassword {
+		return errors.New("新旧密码不应该相同")
+	}
+	// 验证新密码满足密码策略, 需要查询用户名
 	if rq.NewPassword == "" {
 		return errors.New("Password cannot be empty")
 	}
-	if len(rq.NewPassword) < 8 || len(rq.NewPassword) > 64 {
-		return errors.New("Password must be between 8 and 64 characters")
+	userModel, err := v.store.User().Get(ctx, where.F("userID", rq.UserID))
+	if err != nil {
+		return err
 	}
-	// 验证新旧密码不相同
-	if rq.OldPassword == rq.NewPassword {
-		return errors.New("新旧密码不应该相同")
+	return v.validatePassword(rq.NewPassword, userModel.Username)
+}
+
+// validatePassword 按照密码策略校验用户 username 的新密码 password.
+// 返回的错误中 Message 为所有不满足的规则的描述, Metadata 中的每一项对应一条不满足的规则, 键为规则名称.
+// 每次创建新的错误, 避免修改全局的 errorsx.ErrPasswordPolicy.
+func (v *Validator) validatePassword(password string, username string) error {
+	violations := v.policy.Check(password, username)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(violations))
+	kvs := make([]string, 0, 2*len(violations))
+	for _, violation := range violations {
+		messages = append(messages, violation.Message)
+		kvs = append(kvs, violation.Rule, violation.Message)
+	}
+	return errorsx.New(errorsx.ErrPasswordPolicy.Code, errorsx.ErrPasswordPolicy.Reason, "%s", strings.Join(messages, "; ")).KV(kvs...)
+}
+
+// ValidateSendVerificationEmailRequest 用于校验重新发送验证邮件请求的输入有效性.
+func (v *Validator) ValidateSendVerificationEmailRequest(ctx context.Context, rq *v1.SendVerificationEmailRequest) error {
+	return validateEmail(rq.Email)
+}
+
+// ValidateVerifyEmailRequest 用于校验验证邮箱请求的输入有效性.
+func (v *Validator) ValidateVerifyEmailRequest(ctx context.Context, rq *v1.VerifyEmailRequest) error {
+	if rq.Token == "" {
+		return errors.New("Token cannot be empty")
 	}
+	return nil
+}
 
+// ValidateRequestPasswordResetRequest 用于校验找回密码请求的输入有效性.
+func (v *Validator) ValidateRequestPasswordResetRequest(ctx context.Context, rq *v1.RequestPasswordResetRequest) error {
+	return validateEmail(rq.Email)
+}
+
+// ValidateConfirmPasswordResetRequest 用于校验重置密码请求的输入有效性.
+// 新密码需要满足密码策略, 因此根据令牌查询用户名, 令牌的状态由 BIZ 层校验.
+func (v *Validator) ValidateConfirmPasswordResetRequest(ctx context.Context, rq *v1.ConfirmPasswordResetRequest) error {
+	if rq.Token == "" {
+		return errors.New("Token cannot be empty")
+	}
+	if rq.NewPassword == "" {
+		return errors.New("Password cannot be empty")
+	}
+
+	claims, err := token.ParseAction(rq.Token, known.ActionResetPassword)
+	if err != nil {
+		return errorsx.ErrPasswordResetTokenInvalid
+	}
+	if claims.Tenant != "" {
+		ctx = contextx.WithTenantID(ctx, claims.Tenant)
+	}
+	userModel, err := v.store.User().Get(ctx, where.F("userID", claims.Subject))
+	if err != nil {
+		if errors.Is(err, errorsx.ErrUserNotFound) {
+			return errorsx.ErrPasswordResetTokenInvalid
+		}
+		return err
+	}
+	return v.validatePassword(rq.NewPassword, userModel.Username)
+}
+
+// validateEmail 校验 email 是一个不带显示名称的邮箱地址, 例如 `user@example.com`.
+func validateEmail(email string) error {
+	if len(email) > 254 {
+		return errors.New("Email cannot exceed 254 characters")
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return errors.New("Invalid email address")
+	}
+	return nil
+}
+
+// ValidateCompleteLoginRequest 用于校验使用两步验证码完成登录请求的输入有效性.
+func (v *Validator) ValidateCompleteLoginRequest(ctx context.Context, rq *v1.CompleteLoginRequest) error {
+	if rq.ChallengeToken == "" {
+		return errors.New("Challenge token cannot be empty")
+	}
+	return validateTwoFactorCode(rq.Code)
+}
+
+// ValidateEnrollTOTPRequest 用于校验绑定验证器应用请求的输入有效性.
+// 只能为自己绑定验证器应用, 管理员也不例外.
+func (v *Validator) ValidateEnrollTOTPRequest(ctx context.Context, rq *v1.EnrollTOTPRequest) error {
+	if rq.UserID == "" || rq.UserID != contextx.UserID(ctx) {
+		return errorsx.ErrPermissionDenied
+	}
+	if rq.Password == "" {
+		return errors.New("Password cannot be empty")
+	}
+	return nil
+}
+
+// ValidateConfirmTOTPRequest 用于校验确认绑定验证器应用请求的输入有效性.
+func (v *Validator) ValidateConfirmTOTPRequest(ctx context.Context, rq *v1.ConfirmTOTPRequest) error {
+	if rq.UserID == "" || rq.UserID != contextx.UserID(ctx) {
+		return errorsx.ErrPermissionDenied
+	}
+	return validateTwoFactorCode(rq.Code)
+}
+
+// ValidateDisableTOTPRequest 用于校验关闭两步验证请求的输入有效性.
+// 管理员可以关闭任意用户的两步验证, 此时不需要验证码.
+func (v *Validator) ValidateDisableTOTPRequest(ctx context.Context, rq *v1.DisableTOTPRequest) error {
+	if err := validateUserID(ctx, rq.UserID); err != nil {
+		return err
+	}
+	if rq.UserID != contextx.UserID(ctx) && rq.Code == "" {
+		return nil
+	}
+	return validateTwoFactorCode(rq.Code)
+}
+
+// ValidateRegenerateRecoveryCodesRequest 用于校验重新生成恢复码请求的输入有效性.
+func (v *Validator) ValidateRegenerateRecoveryCodesRequest(ctx context.Context, rq *v1.RegenerateRecoveryCodesRequest) error {
+	if rq.UserID == "" || rq.UserID != contextx.UserID(ctx) {
+		return errorsx.ErrPermissionDenied
+	}
+	return validateTwoFactorCode(rq.Code)
+}
+
+// validateTwoFactorCode 校验 code 是一个验证码或者恢复码.
+func validateTwoFactorCode(code string) error {
+	if code == "" {
+		return errors.New("Code cannot be empty")
+	}
+	if len(code) > 32 {
+		return errors.New("Code cannot exceed 32 characters")
+	}
+	return nil
+}
+
+// ValidateAuthorizeOIDCRequest 用于校验使用外部身份提供方登录请求的输入有效性.
+func (v *Validator) ValidateAuthorizeOIDCRequest(ctx context.Context, rq *v1.AuthorizeOIDCRequest) error {
+	if rq.Provider == "" {
+		return errors.New("Provider cannot be empty")
+	}
+	return nil
+}
+
+// ValidateLinkIdentityRequest 用于校验绑定外部身份请求的输入有效性.
+// 只能为自己绑定外部身份, 管理员也不例外.
+func (v *Validator) ValidateLinkIdentityRequest(ctx context.Context, rq *v1.LinkIdentityRequest) error {
+	if rq.UserID == "" || rq.UserID != contextx.UserID(ctx) {
+		return errorsx.ErrPermissionDenied
+	}
+	if rq.Provider == "" {
+		return errors.New("Provider cannot be empty")
+	}
+	return nil
+}
+
+// ValidateOIDCCallbackRequest 用于校验外部身份提供方回调请求的输入有效性.
+// state 和会话信息在 biz 层校验, 校验失败时统一返回 ErrOIDCStateInvalid.
+func (v *Validator) ValidateOIDCCallbackRequest(ctx context.Context, rq *v1.OIDCCallbackRequest) error {
+	if rq.Provider == "" {
+		return errors.New("Provider cannot be empty")
+	}
+	if rq.State == "" || rq.Session == "" {
+		return errorsx.ErrOIDCStateInvalid
+	}
+	if rq.Code == "" && rq.Error == "" {
+		return errors.New("Code cannot be empty")
+	}
+	return nil
+}
+
+// ValidateListIdentitiesRequest 用于校验查询外部身份请求的输入有效性.
+func (v *Validator) ValidateListIdentitiesRequest(ctx context.Context, rq *v1.ListIdentitiesRequest) error {
+	return validateUserID(ctx, rq.UserID)
+}
+
+// ValidateUnlinkIdentityRequest 用于校验解除绑定外部身份请求的输入有效性.
+func (v *Validator) ValidateUnlinkIdentityRequest(ctx context.Context, rq *v1.UnlinkIdentityRequest) error {
+	if err := validateUserID(ctx, rq.UserID); err != nil {
+		return err
+	}
+	if rq.Provider == "" {
+		return errors.New("Provider cannot be empty")
+	}
 	return nil
 }
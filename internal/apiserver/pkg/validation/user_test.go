@@ -0,0 +1,59 @@
+package validation
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/password"
+)
+
+func TestValidatePassword(t *testing.T) {
+	v := NewValidator(nil, &password.Policy{Rules: []password.Rule{
+		password.Length(8, 64),
+		password.RequireDigit(),
+		password.RequireSymbol(),
+		password.NotContainUsername(),
+	}})
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{name: "valid", password: "C0rrect-horse"},
+		{name: "single rule", password: "Correct-horse", want: []string{"digit"}},
+		{name: "several rules", password: "alice", want: []string{"digit", "length", "symbol", "username"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.validatePassword(tt.password, "alice")
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("validatePassword() error = %v", err)
+				}
+				return
+			}
+
+			// 每条不满足的规则对应 Metadata 中的一项, 键为规则名称
+			got := errorsx.FromError(err)
+			if got.Reason != errorsx.ErrPasswordPolicy.Reason {
+				t.Fatalf("validatePassword() error = %v, want %s", err, errorsx.ErrPasswordPolicy.Reason)
+			}
+			if keys := slices.Sorted(maps.Keys(got.Metadata)); !slices.Equal(keys, tt.want) {
+				t.Errorf("Metadata keys = %v, want %v", keys, tt.want)
+			}
+			for _, rule := range tt.want {
+				if got.Metadata[rule] == "" {
+					t.Errorf("Metadata[%s] is empty", rule)
+				}
+			}
+		})
+	}
+
+	// 不修改预定义的错误
+	if len(errorsx.ErrPasswordPolicy.Metadata) != 0 {
+		t.Errorf("ErrPasswordPolicy.Metadata = %v, want empty", errorsx.ErrPasswordPolicy.Metadata)
+	}
+}
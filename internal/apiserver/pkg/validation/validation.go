@@ -1,6 +1,9 @@
 package validation
 
-import "fastgo/internal/apiserver/store"
+import (
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/password"
+)
 
 // 验证逻辑的实现结构体.
 type Validator struct {
@@ -8,9 +11,11 @@ type Validator struct {
 	// 这里只是一个举例，如果验证时，有其他依赖的客户端/服务/资源等，
 	// 都可以一并注入进来
 	store store.IStore
+	// policy 为创建用户和修改密码时校验新密码的密码策略
+	policy *password.Policy
 }
 
 // 创建一个新的 Validator 实例.
-func NewValidator(store store.IStore) *Validator {
-	return &Validator{store: store}
+func NewValidator(store store.IStore, policy *password.Policy) *Validator {
+	return &Validator{store: store, policy: policy}
 }
@@ -0,0 +1,39 @@
+package apiserver
+
+import (
+	"fastgo/internal/pkg/middleware"
+	"fastgo/internal/pkg/ratelimit"
+	genericoptions "fastgo/pkg/options"
+	"github.com/gin-gonic/gin"
+)
+
+// 限流的路由分组名称, 与配置中 rate-limit.groups 的键一致.
+const (
+	rateLimitLogin     = "login"
+	rateLimitRegister  = "register"
+	rateLimitEmail     = "email"
+	rateLimitUsers     = "users"
+	rateLimitPosts     = "posts"
+	rateLimitAuditLogs = "audit-logs"
+)
+
+// newRateLimiter 返回按照路由分组的限流规则创建限流中间件的函数, 未配置限流规则的路由分组不限流.
+func newRateLimiter(limiter ratelimit.Limiter, opts *genericoptions.RateLimitOptions) func(group string) gin.HandlerFunc {
+	return func(group string) gin.HandlerFunc {
+		rule := opts.Rule(group)
+		if rule == nil {
+			return func(c *gin.Context) { c.Next() }
+		}
+
+		var key middleware.RateLimitKeyFunc
+		switch rule.Key {
+		case genericoptions.RateLimitKeyUser:
+			key = middleware.KeyByUser
+		case genericoptions.RateLimitKeyAPIKey:
+			key = middleware.KeyByAPIKey(opts.APIKeyHeader, opts.APIKeys)
+		default:
+			key = middleware.KeyByIP
+		}
+		return middleware.RateLimit(limiter, group, ratelimit.Rule{Rate: rule.Rate, Burst: rule.Burst}, key)
+	}
+}
@@ -4,16 +4,29 @@ import (
 	"context"
 	"errors"
 	"fastgo/internal/apiserver/biz"
+	userv12 "fastgo/internal/apiserver/biz/v1/user"
 	"fastgo/internal/apiserver/handler"
+	"fastgo/internal/apiserver/migrations"
+	"fastgo/internal/apiserver/pkg/search"
 	"fastgo/internal/apiserver/pkg/validation"
 	store2 "fastgo/internal/apiserver/store"
 	"fastgo/internal/pkg/core"
 	"fastgo/internal/pkg/errorsx"
 	"fastgo/internal/pkg/known"
+	"fastgo/internal/pkg/lockout"
+	"fastgo/internal/pkg/mailer"
+	"fastgo/internal/pkg/metrics"
 	"fastgo/internal/pkg/middleware"
+	"fastgo/internal/pkg/password"
+	"fastgo/internal/pkg/ratelimit"
+	"fastgo/internal/pkg/revocation"
 	genericoptions "fastgo/pkg/options"
+	where "fastgo/pkg/store"
 	"fastgo/pkg/token"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"log/slog"
 	"net/http"
 	"os"
@@ -25,16 +38,52 @@ import (
 // Config 配置结构体，用于存储应用相关的配置.
 // 不用 viper.Get，是因为这种方式能更加清晰的知道应用提供了哪些配置项.
 type Config struct {
-	MySQLOptions *genericoptions.MySQLOptions
-	Addr         string
-	JWTKey       string
-	Expiration   time.Duration
+	DBOptions         *genericoptions.DBOptions
+	MySQLOptions      *genericoptions.MySQLOptions
+	SQLiteOptions     *genericoptions.SQLiteOptions
+	Addr              string
+	JWTKey            string
+	JWTOptions        *genericoptions.JWTOptions
+	Expiration        time.Duration
+	RefreshExpiration time.Duration
+	// RevocationBackend 为 token 吊销列表的存储后端, 支持 memory 和 db.
+	RevocationBackend string
+	TenantOptions     *genericoptions.TenantOptions
+	// TrashRetention 为回收站的保留时间, 0 表示不自动清理.
+	TrashRetention time.Duration
+	// TrustedProxies 为受信任的反向代理 IP 或 CIDR, 为空时不信任任何代理.
+	TrustedProxies []string
+	// AccessLogOptions 为 HTTP 访问日志配置.
+	AccessLogOptions *genericoptions.AccessLogOptions
+	// MetricsOptions 为 Prometheus 监控指标配置.
+	MetricsOptions *genericoptions.MetricsOptions
+	// TracingOptions 为 OpenTelemetry 链路追踪配置.
+	TracingOptions *genericoptions.TracingOptions
+	// RateLimitOptions 为限流配置.
+	RateLimitOptions *genericoptions.RateLimitOptions
+	// LockoutOptions 为登录的暴力破解防护配置.
+	LockoutOptions *genericoptions.LockoutOptions
+	// PasswordPolicyOptions 为密码策略配置.
+	PasswordPolicyOptions *genericoptions.PasswordPolicyOptions
+	// MailerOptions 为发送邮件的配置.
+	MailerOptions *genericoptions.MailerOptions
+	// EmailOptions 为邮箱验证和找回密码的配置.
+	EmailOptions *genericoptions.EmailOptions
+	// TwoFactorOptions 为两步验证的配置.
+	TwoFactorOptions *genericoptions.TwoFactorOptions
+	// OIDCOptions 为外部身份提供方登录的配置.
+	OIDCOptions *genericoptions.OIDCOptions
 }
 
 // Server 定义一个服务器结构体类型.
 type Server struct {
 	cfg *Config
 	srv *http.Server
+	// metricsSrv 为单独监听的监控指标服务, 未配置单独的监听地址时为 nil.
+	metricsSrv *http.Server
+	// tracerProvider 为链路追踪的 TracerProvider, 未启用链路追踪时为 nil.
+	tracerProvider *sdktrace.TracerProvider
+	store          store2.IStore
 }
 
 // Run 运行应用.
@@ -43,6 +92,13 @@ func (s *Server) Run() error {
 	//fmt.Printf("Read MySQL host from config: %s\n", s.cfg.MySQLOptions.Addr)
 	//select {} //调用 select 语句，阻塞防止进程退出
 
+	// 定期清理回收站, 服务退出时停止
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	if s.cfg.TrashRetention > 0 {
+		go purgeTrash(purgeCtx, s.store, s.cfg.TrashRetention)
+	}
+
 	slog.Info("Start to listening the incoming requests on http address", "addr", s.cfg.Addr)
 	go func() {
 		// s.srv是一个http服务实例,调用方法开始监听客户端请求
@@ -52,6 +108,15 @@ func (s *Server) Run() error {
 			os.Exit(1)
 		}
 	}()
+	if s.metricsSrv != nil {
+		slog.Info("Start to listening the metrics requests on http address", "addr", s.metricsSrv.Addr)
+		go func() {
+			if err := s.metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error(err.Error())
+				os.Exit(1)
+			}
+		}()
+	}
 
 	// 实现优雅关闭
 	// 创建一个os.Singal类型的channel, 用于接收系统信号
@@ -74,6 +139,19 @@ func (s *Server) Run() error {
 		slog.Error("Insecure Server forced to shutdown", "err", err)
 		return err
 	}
+	if s.metricsSrv != nil {
+		if err := s.metricsSrv.Shutdown(ctx); err != nil {
+			slog.Error("Metrics Server forced to shutdown", "err", err)
+			return err
+		}
+	}
+	// 导出尚未导出的 span
+	if s.tracerProvider != nil {
+		if err := s.tracerProvider.Shutdown(ctx); err != nil {
+			slog.Error("Failed to shutdown tracer provider", "err", err)
+			return err
+		}
+	}
 
 	// 正常关闭
 	slog.Info("Server exited")
@@ -84,17 +162,138 @@ func (s *Server) Run() error {
 func (cfg *Config) NewServer() (*Server, error) {
 	// 创建gin引擎.
 	engine := gin.New()
+	// 只信任配置的反向代理, 否则客户端可以伪造 X-Forwarded-For 绕过按 IP 的限流和登录锁定
+	if err := engine.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
 
-	// 初始化数据库连接
-	db, err := cfg.MySQLOptions.NewDB()
+	// 根据配置的数据库驱动初始化数据库连接
+	db, err := cfg.DBOptions.NewDB(cfg.MySQLOptions, cfg.SQLiteOptions)
 	if err != nil {
 		return nil, err
 	}
+
+	// 执行未执行的数据库迁移
+	if cfg.DBOptions.AutoMigrate {
+		migrator, err := migrations.NewMigrator(db, cfg.DBOptions.Driver)
+		if err != nil {
+			return nil, err
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range applied {
+			slog.Info("Applied database migration", "version", m.Version, "name", m.Name)
+		}
+	}
+	// 启用租户隔离, 需要在创建 store 之前完成
+	if err := EnableTenant(db, cfg.TenantOptions); err != nil {
+		return nil, err
+	}
+	// 启用链路追踪, 接受上游请求头中的 W3C traceparent, 数据库操作作为请求的子 span 记录
+	var tracerProvider *sdktrace.TracerProvider
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg.TracingOptions.Enabled() {
+		tracerProvider, err = cfg.TracingOptions.NewTracerProvider(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		otel.SetTracerProvider(tracerProvider)
+		if err := db.Use(where.NewTracingPlugin(cfg.DBOptions.Driver)); err != nil {
+			return nil, err
+		}
+		slog.Info("Tracing enabled", "exporter", cfg.TracingOptions.Exporter)
+	}
 	store := store2.NewStore(db)
-	cfg.InstallRESTAPI(engine, store)
 
-	// 初始化 token 包的签名密钥、认证 key 和 Token 默认超时时间
-	token.Init(cfg.JWTKey, known.XUserID, cfg.Expiration)
+	// 注册数据库连接池监控指标
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if err := metrics.RegisterDB(sqlDB, cfg.DBOptions.Driver); err != nil {
+		return nil, err
+	}
+
+	// 创建 token 吊销列表
+	revoker, err := revocation.New(cfg.RevocationBackend, db)
+	if err != nil {
+		return nil, err
+	}
+	// 创建博客全文检索, MySQL 使用 FULLTEXT 索引, SQLite 使用内存倒排索引
+	searcher, err := search.New(cfg.DBOptions.Driver, db, store)
+	if err != nil {
+		return nil, err
+	}
+	// 创建限流后端
+	limiter, err := ratelimit.New(cfg.RateLimitOptions.Backend)
+	if err != nil {
+		return nil, err
+	}
+	// 创建登录的暴力破解防护
+	lockoutStore, err := lockout.NewStore(cfg.LockoutOptions.Backend)
+	if err != nil {
+		return nil, err
+	}
+	guard := lockout.New(lockoutStore, lockout.Policy{
+		MaxFailures:   cfg.LockoutOptions.MaxFailures,
+		MaxIPFailures: cfg.LockoutOptions.MaxIPFailures,
+		Window:        cfg.LockoutOptions.Window,
+		Duration:      cfg.LockoutOptions.Duration,
+		BaseDelay:     cfg.LockoutOptions.BaseDelay,
+		MaxDelay:      cfg.LockoutOptions.MaxDelay,
+	})
+	// 创建密码策略
+	policy, err := newPasswordPolicy(cfg.PasswordPolicyOptions)
+	if err != nil {
+		return nil, err
+	}
+	// 创建发送验证邮件和重置密码邮件的 Mailer
+	mailer, err := newMailer(cfg.MailerOptions)
+	if err != nil {
+		return nil, err
+	}
+	// 创建外部身份提供方
+	oidcConfig, err := newOIDCConfig(cfg.OIDCOptions)
+	if err != nil {
+		return nil, err
+	}
+	// 访问日志中间件需要在注册路由之前安装
+	accessLog, err := cfg.AccessLogOptions.Writer()
+	if err != nil {
+		return nil, err
+	}
+	// 为每个请求生成请求 ID, 审计日志和访问日志通过请求 ID 关联到具体的请求
+	engine.Use(middleware.RequestID(), middleware.AccessLog(cfg.AccessLogOptions.Format, accessLog), middleware.Metrics())
+	if tracerProvider != nil {
+		engine.Use(middleware.Tracing())
+	}
+
+	// 未配置单独的监听地址时, 通过 API 服务暴露监控指标
+	var metricsSrv *http.Server
+	if cfg.MetricsOptions.Addr == "" {
+		engine.GET(cfg.MetricsOptions.Path, gin.WrapH(metrics.Handler()))
+	} else {
+		mux := http.NewServeMux()
+		mux.Handle(cfg.MetricsOptions.Path, metrics.Handler())
+		metricsSrv = &http.Server{Addr: cfg.MetricsOptions.Addr, Handler: mux}
+	}
+
+	cfg.InstallRESTAPI(engine, store, revoker, searcher, limiter, guard, policy, mailer, oidcConfig)
+
+	// 初始化 token 包的签名密钥、认证 key、Token 和 refresh token 默认超时时间
+	token.Init(cfg.JWTKey, known.XUserID, cfg.Expiration, cfg.RefreshExpiration)
+
+	// 配置了非对称签名密钥时, 使用 RS256/EdDSA 签发 token, 公钥通过 JWKS 接口发布
+	if cfg.JWTOptions != nil && cfg.JWTOptions.Enabled() {
+		signingKey, verificationKeys, err := cfg.JWTOptions.Keys()
+		if err != nil {
+			return nil, err
+		}
+		token.InitKeys(signingKey, verificationKeys...)
+		slog.Info("Using asymmetric JWT signing key", "kid", signingKey.ID, "alg", signingKey.Method.Alg())
+	}
 
 	//// gin.Recovery() 中间件，用来捕获任何 panic，并恢复
 	//mws := []gin.HandlerFunc{gin.Recovery(), mw.NoCache, mw.Cors, mw.RequestID()}
@@ -102,7 +301,7 @@ func (cfg *Config) NewServer() (*Server, error) {
 	//engine.Use(mws...)
 	//// 注册 404 Handler.
 	//engine.NoRoute(func(c *gin.Context) {
-	//	core.WriteResponse(c, errorsx.ErrNotFound.WithMessage("Page not found"), nil)
+	//	core.WriteResponse(c, errorsx.New(errorsx.ErrNotFound.Code, errorsx.ErrNotFound.Reason, "Page not found"), nil)
 	//})
 	//// 注册 /healthz handler.
 	//// 请求方法: GET; 请求路径: /healthz; 请求返回: {"status":"ok"}
@@ -115,15 +314,21 @@ func (cfg *Config) NewServer() (*Server, error) {
 	httpsrv := &http.Server{Addr: cfg.Addr, Handler: engine}
 
 	return &Server{
-		cfg: cfg,
-		srv: httpsrv,
+		cfg:            cfg,
+		srv:            httpsrv,
+		metricsSrv:     metricsSrv,
+		tracerProvider: tracerProvider,
+		store:          store,
 	}, nil
 }
 
-func (cfg *Config) InstallRESTAPI(engine *gin.Engine, store store2.IStore) {
+func (cfg *Config) InstallRESTAPI(engine *gin.Engine, store store2.IStore, revoker revocation.Revoker, searcher search.Searcher, limiter ratelimit.Limiter, guard *lockout.Guard, policy *password.Policy, mailer mailer.Mailer, oidcConfig userv12.OIDCConfig) {
+	// 从请求头中获取租户, 已认证的请求由认证中间件使用 token 中的租户覆盖
+	engine.Use(middleware.Tenant(cfg.TenantOptions.Header))
+
 	// 注册 404 Handler
 	engine.NoRoute(func(c *gin.Context) {
-		core.WriteResponse(c, nil, errorsx.ErrNotFound.WithMessage("Page not found"))
+		core.WriteResponse(c, errorsx.New(errorsx.ErrNotFound.Code, errorsx.ErrNotFound.Reason, "Page not found"), nil)
 	})
 
 	// 注册 /healthz handler.
@@ -131,19 +336,42 @@ func (cfg *Config) InstallRESTAPI(engine *gin.Engine, store store2.IStore) {
 		core.WriteResponse(c, nil, map[string]string{"status": "ok"})
 	})
 
+	// 发布校验 token 使用的公钥, 其他服务无需持有签名密钥即可校验 token
+	engine.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		core.WriteResponse(c, nil, token.JWKS())
+	})
+
 	// 创建业务处理器Handler
-	handler := handler.NewHandler(biz.NewBiz(store), validation.NewValidator(store))
+	handler := handler.NewHandler(biz.NewBiz(store, revoker, searcher, guard, policy, mailer, newEmailConfig(cfg.EmailOptions), newTwoFactorConfig(cfg.TwoFactorOptions), oidcConfig), validation.NewValidator(store, policy))
+
+	// limit 按照路由分组的限流规则限流, 按照用户 ID 限流时需要在 authMiddlewares 之后使用
+	limit := newRateLimiter(limiter, cfg.RateLimitOptions)
 
 	// 注册用户登录和令牌刷新接口
-	engine.POST("/login", handler.Login)
-	// 刷新令牌, 延长令牌有效时间
-	// 需要注意, 先进行令牌有效性的验证, 再刷新令牌
-	engine.PUT("/refresh-token", middleware.Authn(), handler.RefreshToken)
+	engine.POST("/login", limit(rateLimitLogin), handler.Login)
+	// 启用两步验证的用户使用登录时返回的挑战令牌和验证码完成登录
+	engine.POST("/login/2fa", limit(rateLimitLogin), handler.CompleteLogin)
+	// 使用 refresh token 换取新的令牌, 延长登录有效时间
+	// 此时 access token 可能已经过期, 因此不经过认证中间件, 由 refresh token 本身完成认证
+	engine.PUT("/refresh-token", limit(rateLimitLogin), handler.RefreshToken)
 
 	// gin.HandlerFunc类型的切片
 	// 是用来处理HTTP请求的函数类型, 作用是为路由分组添加中间件.
 	// authMiddlewares := []gin.HandlerFunc{AuthMiddleware()}
-	authMiddlewares := []gin.HandlerFunc{middleware.Authn()}
+	authMiddlewares := []gin.HandlerFunc{middleware.Authn(revoker, cfg.TenantOptions.Default)}
+
+	// authorize 根据访问策略对 resource 的 verb 操作进行授权, 需要在 authMiddlewares 之后使用
+	authorizer := newAuthorizer(store)
+	authorize := func(resource string, verb string) gin.HandlerFunc {
+		return middleware.Authz(authorizer, resource, verb)
+	}
+
+	// 退出登录, 吊销当前 token; 退出所有设备, 吊销当前用户已签发的所有 token
+	logout := engine.Group("", authMiddlewares...)
+	{
+		logout.POST("/logout", handler.Logout)
+		logout.POST("/logout-all", handler.LogoutAll)
+	}
 
 	// 注册 v1 版本 API 路由分组
 	v1 := engine.Group("/v1")
@@ -151,28 +379,81 @@ func (cfg *Config) InstallRESTAPI(engine *gin.Engine, store store2.IStore) {
 		// 用户模块相关路由
 		userv1 := v1.Group("/users")
 		{
-			userv1.POST("", handler.CreateUser)                           // 创建用户
-			userv1.Use(authMiddlewares...)                                // 进行
-			userv1.PUT(":userID/change-password", handler.ChangePassword) // 修改密码
-			// 更新用户信息
-			// 删除用户
-			// 查询用户详情
-			// 查询用户列表
+			userv1.POST("", limit(rateLimitRegister), handler.CreateUser)                                                       // 创建用户
+			userv1.Use(authMiddlewares...)                                                                                      // 进行
+			userv1.Use(limit(rateLimitUsers))                                                                                   // 按照用户限流
+			userv1.PUT(":userID/change-password", authorize(resourceUsers, verbChangePassword), handler.ChangePassword)         // 修改密码
+			userv1.PUT(":userID/role", authorize(resourceUsers, verbUpdateRole), handler.UpdateUserRole)                        // 修改用户角色
+			userv1.PUT(":userID", authorize(resourceUsers, verbUpdate), handler.UpdateUser)                                     // 更新用户信息
+			userv1.DELETE(":userID", authorize(resourceUsers, verbDelete), handler.DeleteUser)                                  // 删除用户
+			userv1.POST(":userID/restore", authorize(resourceUsers, verbRestore), handler.RestoreUser)                          // 从回收站恢复用户
+			userv1.POST(":userID/unlock", authorize(resourceUsers, verbUnlock), handler.UnlockUser)                             // 解除用户登录锁定
+			userv1.POST(":userID/2fa/enroll", authorize(resourceUsers, verbTwoFactor), handler.EnrollTOTP)                      // 绑定验证器应用
+			userv1.POST(":userID/2fa/confirm", authorize(resourceUsers, verbTwoFactor), handler.ConfirmTOTP)                    // 启用两步验证
+			userv1.POST(":userID/2fa/disable", authorize(resourceUsers, verbTwoFactor), handler.DisableTOTP)                    // 关闭两步验证
+			userv1.POST(":userID/2fa/recovery-codes", authorize(resourceUsers, verbTwoFactor), handler.RegenerateRecoveryCodes) // 重新生成恢复码
+			userv1.POST(":userID/identities/:provider", authorize(resourceUsers, verbIdentity), handler.LinkIdentity)           // 绑定外部身份
+			userv1.DELETE(":userID/identities/:provider", authorize(resourceUsers, verbIdentity), handler.UnlinkIdentity)       // 解除绑定外部身份
+			userv1.GET(":userID/identities", authorize(resourceUsers, verbIdentity), handler.ListIdentities)                    // 查询绑定的外部身份
+			userv1.GET("trash", authorize(resourceUsers, verbListTrash), handler.ListTrashUser)                                 // 查询回收站用户列表
+			userv1.GET(":userID", authorize(resourceUsers, verbGet), handler.GetUser)                                           // 查询用户详情
+			userv1.GET("", authorize(resourceUsers, verbList), handler.ListUser)                                                // 查询用户列表
+		}
+		// 邮箱验证和找回密码相关路由
+		// 不经过认证中间件, 由邮件链接中的一次性令牌完成认证
+		emailv1 := v1.Group("", limit(rateLimitEmail))
+		{
+			emailv1.POST("/email-verification", handler.SendVerificationEmail)    // 重新发送验证邮件
+			emailv1.GET("/email-verification/confirm", handler.VerifyEmail)       // 验证邮箱(打开邮件中的链接)
+			emailv1.POST("/email-verification/confirm", handler.VerifyEmail)      // 验证邮箱
+			emailv1.POST("/password-reset", handler.RequestPasswordReset)         // 找回密码, 发送重置密码邮件
+			emailv1.POST("/password-reset/confirm", handler.ConfirmPasswordReset) // 重置密码
+		}
+		// 外部身份提供方登录相关路由
+		// 不经过认证中间件, 由身份提供方完成认证, 与登录共用限流配置
+		oidcv1 := v1.Group("/oidc", limit(rateLimitLogin))
+		{
+			oidcv1.GET(":provider/authorize", handler.AuthorizeOIDC) // 跳转到身份提供方登录
+			oidcv1.GET(":provider/callback", handler.OIDCCallback)   // 身份提供方登录完成后的回调
 		}
 		// 博客模块相关路由
 		// 所有以/v1/posts开头的路由都会先经过authMiddlewares里的中间件处理. 只有通过了身份验证中间件的验证, 请求才会被转发到对应的处理函数.
-		// postv1 := v1.Group("/posts", authMiddlewares...)
+		postv1 := v1.Group("/posts", authMiddlewares...)
+		postv1.Use(limit(rateLimitPosts))
 		{
-			// 创建博客
-			// 更新博客
-			// 删除博客
-			// 查询博客详情
-			// 查询博客列表
+			postv1.POST("", authorize(resourcePosts, verbCreate), handler.CreatePost)                  // 创建博客
+			postv1.PUT(":postID", authorize(resourcePosts, verbUpdate), handler.UpdatePost)            // 更新博客
+			postv1.DELETE("", authorize(resourcePosts, verbDelete), handler.DeletePost)                // 批量删除博客
+			postv1.POST(":postID/restore", authorize(resourcePosts, verbRestore), handler.RestorePost) // 从回收站恢复博客
+			postv1.GET("trash", authorize(resourcePosts, verbListTrash), handler.ListTrashPost)        // 查询回收站博客列表
+			postv1.GET(":postID", authorize(resourcePosts, verbGet), handler.GetPost)                  // 查询博客详情
+			postv1.GET("", authorize(resourcePosts, verbList), handler.ListPost)                       // 查询博客列表
 		}
+		// 审计日志相关路由
+		auditlogv1 := v1.Group("/audit-logs", authMiddlewares...)
+		auditlogv1.Use(limit(rateLimitAuditLogs))
+		{
+			auditlogv1.GET("", authorize(resourceAuditLogs, verbList), handler.ListAuditLog) // 查询审计日志列表
+		}
+		// 全文检索博客, 路径中的 `:search` 为自定义方法, 不是路径参数
+		v1.GET("/posts:method", append(authMiddlewares, limit(rateLimitPosts), customMethod("search"), authorize(resourcePosts, verbSearch), handler.SearchPost)...)
 	}
 
 }
 
+// customMethod 匹配形如 `/v1/posts:search` 的自定义方法.
+// gin 不支持在路径中使用冒号, 因此将 `:method` 注册为路径参数, 参数值不是 `:name` 时返回 404.
+func customMethod(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Param("method") != ":"+name {
+			core.WriteResponse(c, errorsx.ErrNotFound.WithMessage("Page not found"), nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // AuthMiddleware 是一个简单的身份验证中间件
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
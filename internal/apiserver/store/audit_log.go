@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+	"log/slog"
+)
+
+// AuditLogStore 定义了审计日志在 store 层实现的方法.
+// 审计日志只能追加和查询, 不能修改或删除.
+type AuditLogStore interface {
+	Create(ctx context.Context, obj *model.AuditLog) error
+	List(ctx context.Context, opts *where.Options) (int64, []*model.AuditLog, error)
+}
+
+type auditLogStore struct {
+	store *datastore
+}
+
+var _ AuditLogStore = (*auditLogStore)(nil)
+
+// newAuditLogStore 创建 auditLogStore 的实例.
+func newAuditLogStore(store *datastore) *auditLogStore {
+	return &auditLogStore{store: store}
+}
+
+// Create 插入一条审计日志, ctx 中有事务时与被审计的写操作在同一个事务中写入.
+func (s *auditLogStore) Create(ctx context.Context, obj *model.AuditLog) error {
+	if err := s.store.DB(ctx).Create(obj).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to insert audit log into database", "err", err, "resource", obj.Resource, "resourceID", obj.ResourceID)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// List 返回审计日志列表和总数, 按照 `id desc` 排序.
+// nolint: nonamedreturns
+func (s *auditLogStore) List(ctx context.Context, opts *where.Options) (count int64, ret []*model.AuditLog, err error) {
+	err = s.store.DB(ctx, opts).Order("id desc").Find(&ret).Offset(-1).Limit(-1).Count(&count).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list audit logs from database", "err", err, "conditions", opts)
+		err = errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return
+}
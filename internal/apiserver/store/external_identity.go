@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+	"gorm.io/gorm"
+	"log/slog"
+)
+
+// ExternalIdentityStore 定义了外部身份模块在 store 层实现的方法.
+type ExternalIdentityStore interface {
+	Create(ctx context.Context, obj *model.ExternalIdentity) error
+	Update(ctx context.Context, obj *model.ExternalIdentity) error
+	Delete(ctx context.Context, opts *where.Options) error
+	Get(ctx context.Context, opts *where.Options) (*model.ExternalIdentity, error)
+	List(ctx context.Context, opts *where.Options) (int64, []*model.ExternalIdentity, error)
+}
+
+type externalIdentityStore struct {
+	store *datastore
+}
+
+var _ ExternalIdentityStore = (*externalIdentityStore)(nil)
+
+// newExternalIdentityStore 创建 externalIdentityStore 的实例.
+func newExternalIdentityStore(store *datastore) *externalIdentityStore {
+	return &externalIdentityStore{store: store}
+}
+
+// Create 插入一条外部身份记录.
+func (s *externalIdentityStore) Create(ctx context.Context, obj *model.ExternalIdentity) error {
+	if err := s.store.DB(ctx).Create(obj).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to insert external identity into database", "err", err, "userID", obj.UserID, "provider", obj.Provider)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Update 更新外部身份记录.
+func (s *externalIdentityStore) Update(ctx context.Context, obj *model.ExternalIdentity) error {
+	if err := s.store.DB(ctx).Save(obj).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to update external identity in database", "err", err, "id", obj.ID)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Delete 根据条件删除外部身份记录.
+func (s *externalIdentityStore) Delete(ctx context.Context, opts *where.Options) error {
+	err := s.store.DB(ctx, opts).Delete(new(model.ExternalIdentity)).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.ErrorContext(ctx, "Failed to delete external identity from database", "err", err, "conditions", opts)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Get 根据条件查询外部身份记录.
+func (s *externalIdentityStore) Get(ctx context.Context, opts *where.Options) (*model.ExternalIdentity, error) {
+	var obj model.ExternalIdentity
+	if err := s.store.DB(ctx, opts).First(&obj).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorsx.ErrExternalIdentityNotFound
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve external identity from database", "err", err, "conditions", opts)
+		return nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return &obj, nil
+}
+
+// List 返回外部身份列表和总数.
+// nolint: nonamedreturns
+func (s *externalIdentityStore) List(ctx context.Context, opts *where.Options) (count int64, ret []*model.ExternalIdentity, err error) {
+	err = s.store.DB(ctx, opts).Order("id desc").Find(&ret).Offset(-1).Limit(-1).Count(&count).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list external identities from database", "err", err, "conditions", opts)
+		err = errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return
+}
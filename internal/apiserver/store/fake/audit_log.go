@@ -0,0 +1,44 @@
+package fake
+
+import (
+	"context"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/pkg/audit"
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+)
+
+// auditLogStore 是 store.AuditLogStore 的内存实现.
+type auditLogStore struct {
+	ds *datastore
+}
+
+var _ store.AuditLogStore = (*auditLogStore)(nil)
+
+// Create 插入一条审计日志.
+func (s *auditLogStore) Create(ctx context.Context, obj *model.AuditLog) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	s.ds.auditLogs.insert(ctx, obj)
+	return nil
+}
+
+// List 返回审计日志列表和总数.
+func (s *auditLogStore) List(ctx context.Context, opts *where.Options) (int64, []*model.AuditLog, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	count, ret, err := s.ds.auditLogs.find(ctx, opts)
+	if err != nil {
+		return 0, nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return count, ret, nil
+}
+
+// audit 与数据库实现一样, 为用户和博客的写操作记录审计日志, 调用方需要持有 ds.mu 写锁.
+func (ds *datastore) audit(ctx context.Context, resource string, resourceID string, action string, before any, after any) {
+	ds.auditLogs.insert(ctx, audit.New(ctx, resource, resourceID, action, before, after))
+}
@@ -0,0 +1,83 @@
+package fake
+
+import (
+	"context"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+)
+
+// externalIdentityStore 是 store.ExternalIdentityStore 的内存实现.
+type externalIdentityStore struct {
+	ds *datastore
+}
+
+var _ store.ExternalIdentityStore = (*externalIdentityStore)(nil)
+
+// Create 插入一条外部身份记录, 并校验租户内 (provider, subject) 和 (userID, provider) 的唯一性.
+func (s *externalIdentityStore) Create(ctx context.Context, obj *model.ExternalIdentity) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	for _, row := range s.ds.externalIdentities.rows {
+		if !s.ds.externalIdentities.inTenant(ctx, row) || row.Provider != obj.Provider {
+			continue
+		}
+		if row.Subject == obj.Subject || row.UserID == obj.UserID {
+			return errorsx.ErrDBWrite.WithMessage("duplicate external identity for provider %s", obj.Provider)
+		}
+	}
+	s.ds.externalIdentities.insert(ctx, obj)
+	return nil
+}
+
+// Update 更新外部身份记录.
+func (s *externalIdentityStore) Update(ctx context.Context, obj *model.ExternalIdentity) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	if !s.ds.externalIdentities.update(ctx, obj) {
+		s.ds.externalIdentities.insert(ctx, obj)
+	}
+	return nil
+}
+
+// Delete 根据条件删除外部身份记录.
+func (s *externalIdentityStore) Delete(ctx context.Context, opts *where.Options) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	if err := s.ds.externalIdentities.remove(ctx, opts); err != nil {
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Get 根据条件查询外部身份记录.
+func (s *externalIdentityStore) Get(ctx context.Context, opts *where.Options) (*model.ExternalIdentity, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	obj, err := s.ds.externalIdentities.first(ctx, opts)
+	if err != nil {
+		return nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	if obj == nil {
+		return nil, errorsx.ErrExternalIdentityNotFound
+	}
+	return obj, nil
+}
+
+// List 返回外部身份列表和总数.
+func (s *externalIdentityStore) List(ctx context.Context, opts *where.Options) (int64, []*model.ExternalIdentity, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	count, ret, err := s.ds.externalIdentities.find(ctx, opts)
+	if err != nil {
+		return 0, nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return count, ret, nil
+}
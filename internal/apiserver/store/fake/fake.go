@@ -0,0 +1,155 @@
+// Package fake 提供了 store.IStore 的内存实现, 用于在没有数据库的情况下对 BIZ 层进行单元测试.
+//
+// 内存实现支持 where.Options 中的 Filters、Offset/Limit、Sorts、AfterID/AfterValues 以及形如 `title like ?` 的简单查询条件,
+// List 返回的记录与数据库实现一样按照 Sorts 和 `id desc` 排序. 与 where.TenantPlugin 一样, 注册租户后自动按照租户隔离数据.
+// 与 GORM 一样, 表中有 deletedAt 列时 Delete 只进行软删除, 软删除的记录只能通过 ListTrash 查询.
+// 与数据库实现一样, 用户和博客的写操作会记录审计日志.
+//
+// 示例:
+//
+//	store := fake.NewStore()
+//	guard := lockout.New(lockout.NewMemory(), lockout.Policy{MaxFailures: 5, MaxIPFailures: 20, Window: time.Minute, Duration: time.Minute})
+//	userBiz := user.New(store, revocation.NewMemory(), guard, &password.Policy{}, mailer.NewWriter("fastgo", io.Discard),
+//		user.EmailConfig{VerifyURL: "http://localhost/verify", ResetURL: "http://localhost/reset", VerifyExpiration: time.Hour, ResetExpiration: time.Hour},
+//		user.TwoFactorConfig{Issuer: "fastgo", ChallengeExpiration: time.Minute, RecoveryCodes: 10, Skew: 1},
+//		user.OIDCConfig{})
+package fake
+
+import (
+	"context"
+	"sync"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/store"
+	where "fastgo/pkg/store"
+	"gorm.io/gorm"
+)
+
+// transactionKey 用于在 context.Context 中标记当前处于事务中.
+type transactionKey struct{}
+
+// datastore 是 store.IStore 的内存实现.
+type datastore struct {
+	// mu 保护内存表中的数据.
+	mu sync.RWMutex
+	// txMu 保证同一时刻只有一个事务在执行, 使事务回滚时不会覆盖其他事务的写入.
+	txMu sync.Mutex
+
+	users           *table[model.User]
+	posts           *table[model.Post]
+	refreshTokens   *table[model.RefreshToken]
+	auditLogs       *table[model.AuditLog]
+	passwordHistory *table[model.PasswordHistory]
+	twoFactors      *table[model.TwoFactor]
+	recoveryCodes   *table[model.RecoveryCode]
+
+	externalIdentities *table[model.ExternalIdentity]
+
+	// tables 为所有内存表, 用于事务回滚.
+	tables []snapshotter
+}
+
+// 确保 datastore 实现了 store.IStore 接口.
+var _ store.IStore = (*datastore)(nil)
+
+// NewStore 创建一个空的内存 IStore 实例.
+// 与 store.NewStore 不同, 每次调用都会返回一个新的实例, 便于测试用例之间相互隔离.
+func NewStore() *datastore {
+	ds := &datastore{
+		users:           newTable[model.User](),
+		posts:           newTable[model.Post](),
+		refreshTokens:   newTable[model.RefreshToken](),
+		auditLogs:       newTable[model.AuditLog](),
+		passwordHistory: newTable[model.PasswordHistory](),
+		twoFactors:      newTable[model.TwoFactor](),
+		recoveryCodes:   newTable[model.RecoveryCode](),
+
+		externalIdentities: newTable[model.ExternalIdentity](),
+	}
+	ds.tables = []snapshotter{ds.users, ds.posts, ds.refreshTokens, ds.auditLogs, ds.passwordHistory, ds.twoFactors, ds.recoveryCodes, ds.externalIdentities}
+	return ds
+}
+
+// DB 内存实现没有数据库实例, 始终返回 nil.
+func (ds *datastore) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
+	return nil
+}
+
+// TX 在事务中执行 fn, fn 返回错误或者发生 panic 时, 回滚 fn 中的所有写入.
+// 嵌套调用 TX 时, 内层事务直接复用外层事务.
+func (ds *datastore) TX(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(transactionKey{}).(bool); ok {
+		return fn(ctx)
+	}
+
+	ds.txMu.Lock()
+	defer ds.txMu.Unlock()
+
+	// 保存事务开始前的快照
+	ds.mu.RLock()
+	restores := make([]func(), 0, len(ds.tables))
+	for _, t := range ds.tables {
+		restores = append(restores, t.snapshot())
+	}
+	ds.mu.RUnlock()
+
+	rollback := func() {
+		ds.mu.Lock()
+		for _, restore := range restores {
+			restore()
+		}
+		ds.mu.Unlock()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			rollback()
+			panic(r)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, transactionKey{}, true)); err != nil {
+		rollback()
+	}
+	return err
+}
+
+// User 返回一个实现了 UserStore 接口的实例.
+func (ds *datastore) User() store.UserStore {
+	return &userStore{ds: ds}
+}
+
+// Post 返回一个实现了 PostStore 接口的实例.
+func (ds *datastore) Post() store.PostStore {
+	return &postStore{ds: ds}
+}
+
+// RefreshToken 返回一个实现了 RefreshTokenStore 接口的实例.
+func (ds *datastore) RefreshToken() store.RefreshTokenStore {
+	return &refreshTokenStore{ds: ds}
+}
+
+// AuditLog 返回一个实现了 AuditLogStore 接口的实例.
+func (ds *datastore) AuditLog() store.AuditLogStore {
+	return &auditLogStore{ds: ds}
+}
+
+// PasswordHistory 返回一个实现了 PasswordHistoryStore 接口的实例.
+func (ds *datastore) PasswordHistory() store.PasswordHistoryStore {
+	return &passwordHistoryStore{ds: ds}
+}
+
+// TwoFactor 返回一个实现了 TwoFactorStore 接口的实例.
+func (ds *datastore) TwoFactor() store.TwoFactorStore {
+	return &twoFactorStore{ds: ds}
+}
+
+// RecoveryCode 返回一个实现了 RecoveryCodeStore 接口的实例.
+func (ds *datastore) RecoveryCode() store.RecoveryCodeStore {
+	return &recoveryCodeStore{ds: ds}
+}
+
+// ExternalIdentity 返回一个实现了 ExternalIdentityStore 接口的实例.
+func (ds *datastore) ExternalIdentity() store.ExternalIdentityStore {
+	return &externalIdentityStore{ds: ds}
+}
@@ -0,0 +1,194 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+)
+
+func TestTX(t *testing.T) {
+	errAbort := errors.New("abort")
+
+	tests := []struct {
+		name      string
+		fn        func(ds *datastore, ctx context.Context) error
+		wantErr   error
+		wantPanic bool
+		want      []string
+	}{
+		{
+			name: "commit",
+			fn: func(ds *datastore, ctx context.Context) error {
+				return ds.Post().Create(ctx, &model.Post{UserID: "user-a", Title: "committed"})
+			},
+			want: []string{"committed", "existing"},
+		},
+		{
+			name: "rollback on error",
+			fn: func(ds *datastore, ctx context.Context) error {
+				if err := ds.Post().Create(ctx, &model.Post{UserID: "user-a", Title: "rolled back"}); err != nil {
+					return err
+				}
+				return errAbort
+			},
+			wantErr: errAbort,
+			want:    []string{"existing"},
+		},
+		{
+			name: "rollback on panic",
+			fn: func(ds *datastore, ctx context.Context) error {
+				_ = ds.Post().Create(ctx, &model.Post{UserID: "user-a", Title: "rolled back"})
+				panic(errAbort)
+			},
+			wantPanic: true,
+			want:      []string{"existing"},
+		},
+		{
+			name: "nested transaction joins the outer one",
+			fn: func(ds *datastore, ctx context.Context) error {
+				_ = ds.TX(ctx, func(ctx context.Context) error {
+					return ds.Post().Create(ctx, &model.Post{UserID: "user-a", Title: "rolled back"})
+				})
+				return errAbort
+			},
+			wantErr: errAbort,
+			want:    []string{"existing"},
+		},
+		{
+			name: "rollback restores updates and deletes",
+			fn: func(ds *datastore, ctx context.Context) error {
+				post, err := ds.Post().Get(ctx, where.F("title", "existing"))
+				if err != nil {
+					return err
+				}
+				post.Title = "renamed"
+				if err := ds.Post().Update(ctx, post); err != nil {
+					return err
+				}
+				if err := ds.Post().Delete(ctx, where.F("userID", "user-a")); err != nil {
+					return err
+				}
+				return errAbort
+			},
+			wantErr: errAbort,
+			want:    []string{"existing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := NewStore()
+			ctx := context.Background()
+			if err := ds.Post().Create(ctx, &model.Post{UserID: "user-a", Title: "existing"}); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			func() {
+				defer func() {
+					if r := recover(); (r != nil) != tt.wantPanic {
+						t.Errorf("TX() panic = %v, want panic %v", r, tt.wantPanic)
+					}
+				}()
+				if err := ds.TX(ctx, func(ctx context.Context) error { return tt.fn(ds, ctx) }); !errors.Is(err, tt.wantErr) {
+					t.Errorf("TX() error = %v, want %v", err, tt.wantErr)
+				}
+			}()
+
+			_, posts, err := ds.Post().List(ctx, where.F("userID", "user-a"))
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var got []string
+			for _, post := range posts {
+				got = append(got, post.Title)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("posts after TX() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPurgeUsers(t *testing.T) {
+	ds := NewStore()
+	ctx := context.Background()
+
+	var userIDs []string
+	for _, username := range []string{"deleted", "kept"} {
+		user := &model.User{Username: username, Password: "Passw0rd!x"}
+		if err := ds.User().Create(ctx, user); err != nil {
+			t.Fatalf("User().Create() error = %v", err)
+		}
+		userIDs = append(userIDs, user.UserID)
+
+		creates := []error{
+			ds.Post().Create(ctx, &model.Post{UserID: user.UserID, Title: username}),
+			ds.RefreshToken().Create(ctx, &model.RefreshToken{UserID: user.UserID, TokenHash: username}),
+			ds.TwoFactor().Create(ctx, &model.TwoFactor{UserID: user.UserID}),
+			ds.RecoveryCode().Create(ctx, &model.RecoveryCode{UserID: user.UserID, CodeHash: username}),
+			ds.ExternalIdentity().Create(ctx, &model.ExternalIdentity{UserID: user.UserID, Provider: "example", Subject: username}),
+			ds.PasswordHistory().Create(ctx, &model.PasswordHistory{UserID: user.UserID}),
+		}
+		if err := errors.Join(creates...); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	if err := ds.User().Delete(ctx, where.F("userID", userIDs[0])); err != nil {
+		t.Fatalf("User().Delete() error = %v", err)
+	}
+
+	purged, err := ds.User().Purge(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Purge() = %d, want 1", purged)
+	}
+
+	// 被永久删除的用户的关联记录同时删除, 其他用户的记录不受影响
+	counts := map[string]func(userID string) (int64, error){
+		"post": func(userID string) (int64, error) {
+			count, _, err := ds.Post().List(ctx, where.F("userID", userID))
+			return count, err
+		},
+		"refresh token": func(userID string) (int64, error) {
+			count, _, err := ds.RefreshToken().List(ctx, where.F("userID", userID))
+			return count, err
+		},
+		"recovery code": func(userID string) (int64, error) {
+			count, _, err := ds.RecoveryCode().List(ctx, where.F("userID", userID))
+			return count, err
+		},
+		"external identity": func(userID string) (int64, error) {
+			count, _, err := ds.ExternalIdentity().List(ctx, where.F("userID", userID))
+			return count, err
+		},
+		"password history": func(userID string) (int64, error) {
+			count, _, err := ds.PasswordHistory().List(ctx, where.F("userID", userID))
+			return count, err
+		},
+		"two factor": func(userID string) (int64, error) {
+			_, err := ds.TwoFactor().Get(ctx, where.F("userID", userID))
+			if errors.Is(err, errorsx.ErrTwoFactorNotEnrolled) {
+				return 0, nil
+			}
+			return 1, err
+		},
+	}
+	for name, count := range counts {
+		for i, want := range []int64{0, 1} {
+			got, err := count(userIDs[i])
+			if err != nil {
+				t.Fatalf("%s of %s error = %v", name, userIDs[i], err)
+			}
+			if got != want {
+				t.Errorf("%s count of %s = %d, want %d", name, userIDs[i], got, want)
+			}
+		}
+	}
+}
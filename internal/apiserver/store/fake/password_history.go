@@ -0,0 +1,62 @@
+package fake
+
+import (
+	"context"
+	"sort"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+)
+
+// passwordHistoryStore 是 store.PasswordHistoryStore 的内存实现.
+type passwordHistoryStore struct {
+	ds *datastore
+}
+
+var _ store.PasswordHistoryStore = (*passwordHistoryStore)(nil)
+
+// Create 插入一条历史密码记录.
+func (s *passwordHistoryStore) Create(ctx context.Context, obj *model.PasswordHistory) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	s.ds.passwordHistory.insert(ctx, obj)
+	return nil
+}
+
+// Prune 只保留用户最近被替换的 keep 个历史密码, 删除更早的记录.
+func (s *passwordHistoryStore) Prune(ctx context.Context, userID string, keep int) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	var ids []int64
+	for _, row := range s.ds.passwordHistory.rows {
+		if row.UserID == userID && s.ds.passwordHistory.inTenant(ctx, row) {
+			ids = append(ids, row.ID)
+		}
+	}
+	if len(ids) <= keep {
+		return nil
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+	oldest := ids[keep]
+	s.ds.passwordHistory.drop(func(row *model.PasswordHistory) bool {
+		return row.UserID == userID && row.ID <= oldest && s.ds.passwordHistory.inTenant(ctx, row)
+	})
+	return nil
+}
+
+// List 返回历史密码列表和总数.
+func (s *passwordHistoryStore) List(ctx context.Context, opts *where.Options) (int64, []*model.PasswordHistory, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	count, ret, err := s.ds.passwordHistory.find(ctx, opts)
+	if err != nil {
+		return 0, nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return count, ret, nil
+}
@@ -0,0 +1,131 @@
+package fake
+
+import (
+	"context"
+	"time"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/pkg/audit"
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/rid"
+	where "fastgo/pkg/store"
+)
+
+// postStore 是 store.PostStore 的内存实现.
+type postStore struct {
+	ds *datastore
+}
+
+var _ store.PostStore = (*postStore)(nil)
+
+// Create 插入一条博客记录, 与数据库实现一样, 插入后生成 postID.
+func (s *postStore) Create(ctx context.Context, obj *model.Post) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	// BeforeCreate 不依赖 *gorm.DB, 可以直接复用
+	_ = obj.BeforeCreate(nil)
+	s.ds.posts.insert(ctx, obj)
+	obj.PostID = rid.PostID.New(uint64(obj.ID))
+	s.ds.posts.update(ctx, obj)
+	s.ds.audit(ctx, audit.ResourcePost, obj.PostID, audit.ActionCreate, nil, obj)
+	return nil
+}
+
+// Update 更新博客记录, 与数据库实现一样, 版本号不一致时返回 ErrVersionConflict.
+func (s *postStore) Update(ctx context.Context, obj *model.Post) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	before, _ := s.ds.posts.first(ctx, where.F("id", obj.ID))
+	if !s.ds.posts.compareAndUpdate(ctx, obj) {
+		return errorsx.ErrVersionConflict
+	}
+	s.ds.audit(ctx, audit.ResourcePost, obj.PostID, audit.ActionUpdate, before, obj)
+	return nil
+}
+
+// Delete 根据条件删除博客记录.
+func (s *postStore) Delete(ctx context.Context, opts *where.Options) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	_, deleted, err := s.ds.posts.find(ctx, opts)
+	if err != nil {
+		return errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	if err := s.ds.posts.remove(ctx, opts); err != nil {
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	for _, obj := range deleted {
+		s.ds.audit(ctx, audit.ResourcePost, obj.PostID, audit.ActionDelete, obj, nil)
+	}
+	return nil
+}
+
+// Get 根据条件查询博客记录.
+func (s *postStore) Get(ctx context.Context, opts *where.Options) (*model.Post, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	obj, err := s.ds.posts.first(ctx, opts)
+	if err != nil {
+		return nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	if obj == nil {
+		return nil, errorsx.ErrPostNotFound
+	}
+	return obj, nil
+}
+
+// List 返回博客列表和总数.
+func (s *postStore) List(ctx context.Context, opts *where.Options) (int64, []*model.Post, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	count, ret, err := s.ds.posts.find(ctx, opts)
+	if err != nil {
+		return 0, nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return count, ret, nil
+}
+
+// ListTrash 返回回收站中满足条件的博客列表和总数.
+func (s *postStore) ListTrash(ctx context.Context, opts *where.Options) (int64, []*model.Post, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	count, ret, err := s.ds.posts.findTrash(ctx, opts)
+	if err != nil {
+		return 0, nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return count, ret, nil
+}
+
+// Restore 恢复回收站中满足条件的博客.
+func (s *postStore) Restore(ctx context.Context, opts *where.Options) (int64, error) {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	_, deleted, err := s.ds.posts.findTrash(ctx, opts)
+	if err != nil {
+		return 0, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	restored, err := s.ds.posts.restore(ctx, opts)
+	if err != nil {
+		return 0, errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	for _, obj := range deleted {
+		s.ds.audit(ctx, audit.ResourcePost, obj.PostID, audit.ActionRestore, nil, nil)
+	}
+	return restored, nil
+}
+
+// Purge 永久删除所有租户中在 before 之前被删除的博客.
+func (s *postStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	return s.ds.posts.purge(before), nil
+}
@@ -0,0 +1,70 @@
+package fake
+
+import (
+	"context"
+	"time"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+)
+
+// recoveryCodeStore 是 store.RecoveryCodeStore 的内存实现.
+type recoveryCodeStore struct {
+	ds *datastore
+}
+
+var _ store.RecoveryCodeStore = (*recoveryCodeStore)(nil)
+
+// Create 插入一条恢复码记录.
+func (s *recoveryCodeStore) Create(ctx context.Context, obj *model.RecoveryCode) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	s.ds.recoveryCodes.insert(ctx, obj)
+	return nil
+}
+
+// Delete 根据条件删除恢复码记录.
+func (s *recoveryCodeStore) Delete(ctx context.Context, opts *where.Options) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	if err := s.ds.recoveryCodes.remove(ctx, opts); err != nil {
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// List 返回恢复码列表和总数.
+func (s *recoveryCodeStore) List(ctx context.Context, opts *where.Options) (int64, []*model.RecoveryCode, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	count, ret, err := s.ds.recoveryCodes.find(ctx, opts)
+	if err != nil {
+		return 0, nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return count, ret, nil
+}
+
+// Use 将满足条件且尚未使用的恢复码标记为已使用, 返回是否有恢复码被标记.
+func (s *recoveryCodeStore) Use(ctx context.Context, opts *where.Options) (bool, error) {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	now := time.Now()
+	used := false
+	for _, row := range s.ds.recoveryCodes.rows {
+		ok, err := s.ds.recoveryCodes.match(ctx, row, opts)
+		if err != nil {
+			return false, errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+		}
+		if ok && row.UsedAt == nil {
+			row.UsedAt = &now
+			used = true
+		}
+	}
+	return used, nil
+}
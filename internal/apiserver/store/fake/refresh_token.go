@@ -0,0 +1,100 @@
+package fake
+
+import (
+	"context"
+	"time"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+)
+
+// refreshTokenStore 是 store.RefreshTokenStore 的内存实现.
+type refreshTokenStore struct {
+	ds *datastore
+}
+
+var _ store.RefreshTokenStore = (*refreshTokenStore)(nil)
+
+// Create 插入一条 refresh token 记录, 并校验 tokenHash 的唯一性.
+func (s *refreshTokenStore) Create(ctx context.Context, obj *model.RefreshToken) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	if s.ds.refreshTokens.exists(ctx, "tokenHash", obj.TokenHash, 0) {
+		return errorsx.ErrDBWrite.WithMessage("duplicate refresh token")
+	}
+	s.ds.refreshTokens.insert(ctx, obj)
+	return nil
+}
+
+// Update 更新 refresh token 记录.
+func (s *refreshTokenStore) Update(ctx context.Context, obj *model.RefreshToken) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	if !s.ds.refreshTokens.update(ctx, obj) {
+		s.ds.refreshTokens.insert(ctx, obj)
+	}
+	return nil
+}
+
+// Delete 根据条件删除 refresh token 记录.
+func (s *refreshTokenStore) Delete(ctx context.Context, opts *where.Options) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	if err := s.ds.refreshTokens.remove(ctx, opts); err != nil {
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Get 根据条件查询 refresh token 记录.
+func (s *refreshTokenStore) Get(ctx context.Context, opts *where.Options) (*model.RefreshToken, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	obj, err := s.ds.refreshTokens.first(ctx, opts)
+	if err != nil {
+		return nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	if obj == nil {
+		return nil, errorsx.ErrRefreshTokenInvalid
+	}
+	return obj, nil
+}
+
+// List 返回 refresh token 列表和总数.
+func (s *refreshTokenStore) List(ctx context.Context, opts *where.Options) (int64, []*model.RefreshToken, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	count, ret, err := s.ds.refreshTokens.find(ctx, opts)
+	if err != nil {
+		return 0, nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return count, ret, nil
+}
+
+// Revoke 吊销所有满足条件且尚未吊销的 refresh token.
+func (s *refreshTokenStore) Revoke(ctx context.Context, opts *where.Options) (int64, error) {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	now := time.Now()
+	var revoked int64
+	for _, row := range s.ds.refreshTokens.rows {
+		ok, err := s.ds.refreshTokens.match(ctx, row, opts)
+		if err != nil {
+			return 0, errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+		}
+		if ok && row.RevokedAt == nil {
+			row.RevokedAt = &now
+			row.UpdatedAt = now
+			revoked++
+		}
+	}
+	return revoked, nil
+}
@@ -0,0 +1,546 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	where "fastgo/pkg/store"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// queryRegexp 匹配形如 `title like ?`、`title like ? escape '!'`、`createdAt >= ?` 的简单查询条件.
+var queryRegexp = regexp.MustCompile(`(?i)^\s*` + "`?" + `(\w+)` + "`?" + `\s*(=|!=|<>|>=|<=|>|<|like)\s*\?(?:\s+escape\s+'(.)')?\s*$`)
+
+// table 是一张内存表, 按照主键 ID 升序保存记录.
+// table 本身不是并发安全的, 由 datastore 负责加锁.
+type table[T any] struct {
+	schema *schema.Schema
+	nextID int64
+	rows   []*T
+}
+
+// newTable 创建一张内存表, 通过 GORM 解析模型的字段和列名, 使过滤条件与数据库中的列名保持一致.
+func newTable[T any]() *table[T] {
+	s, err := schema.Parse(new(T), &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		panic(fmt.Sprintf("fake: failed to parse schema of %T: %v", new(T), err))
+	}
+	return &table[T]{schema: s}
+}
+
+// snapshotter 由支持事务回滚的内存表实现.
+type snapshotter interface {
+	// snapshot 保存当前数据的快照, 返回的函数用于将数据恢复到快照时的状态.
+	snapshot() (restore func())
+}
+
+// snapshot 深拷贝整张表, 用于事务回滚.
+func (t *table[T]) snapshot() func() {
+	nextID := t.nextID
+	rows := make([]*T, 0, len(t.rows))
+	for _, row := range t.rows {
+		rows = append(rows, copyOf(row))
+	}
+	return func() {
+		t.nextID, t.rows = nextID, rows
+	}
+}
+
+// insert 插入一条记录, 并为其分配自增 ID 和创建时间.
+// 与 where.TenantPlugin 一致, 表中有租户列时写入当前租户.
+func (t *table[T]) insert(ctx context.Context, obj *T) {
+	t.nextID++
+	now := time.Now()
+	t.set(obj, "id", t.nextID)
+	if field, value := t.tenant(ctx); field != nil {
+		_ = field.Set(ctx, reflect.ValueOf(obj).Elem(), value)
+	}
+	if t.get(obj, "createdAt").(time.Time).IsZero() {
+		t.set(obj, "createdAt", now)
+	}
+	if t.schema.LookUpField("updatedAt") != nil {
+		t.set(obj, "updatedAt", now)
+	}
+	t.rows = append(t.rows, copyOf(obj))
+}
+
+// update 根据主键 ID 替换当前租户的一条记录, 记录不存在时返回 false.
+func (t *table[T]) update(ctx context.Context, obj *T) bool {
+	id := t.get(obj, "id")
+	for i, row := range t.rows {
+		if t.get(row, "id") == id && t.inTenant(ctx, row) {
+			t.set(obj, "updatedAt", time.Now())
+			t.rows[i] = copyOf(obj)
+			return true
+		}
+	}
+	return false
+}
+
+// compareAndUpdate 与 update 相同, 但只更新未删除且 version 列与 obj 一致的记录, 更新后 obj 的 version 加 1.
+// 记录不存在或版本号不一致时返回 false.
+func (t *table[T]) compareAndUpdate(ctx context.Context, obj *T) bool {
+	id, version := t.get(obj, "id"), t.get(obj, "version").(int64)
+	for i, row := range t.rows {
+		if t.get(row, "id") != id || !t.inTenant(ctx, row) {
+			continue
+		}
+		if _, deleted := t.deletedAt(row); deleted || t.get(row, "version").(int64) != version {
+			return false
+		}
+		t.set(obj, "version", version+1)
+		t.set(obj, "updatedAt", time.Now())
+		t.rows[i] = copyOf(obj)
+		return true
+	}
+	return false
+}
+
+// remove 删除所有满足条件的记录.
+// 与 GORM 一致, 表中有 deletedAt 列时只设置删除时间(软删除), 记录由 purge 永久删除.
+func (t *table[T]) remove(ctx context.Context, opts *where.Options) error {
+	if t.schema.LookUpField("deletedAt") != nil {
+		now := time.Now()
+		for _, row := range t.rows {
+			ok, err := t.match(ctx, row, opts)
+			if err != nil {
+				return err
+			}
+			if ok {
+				t.set(row, "deletedAt", gorm.DeletedAt{Time: now, Valid: true})
+			}
+		}
+		return nil
+	}
+
+	kept := t.rows[:0]
+	for _, row := range t.rows {
+		ok, err := t.match(ctx, row, opts)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			kept = append(kept, row)
+		}
+	}
+	// 清理被删除记录的引用
+	for i := len(kept); i < len(t.rows); i++ {
+		t.rows[i] = nil
+	}
+	t.rows = kept
+	return nil
+}
+
+// findTrash 返回回收站中满足条件的记录总数以及分页后的记录, 记录按照删除时间降序排列.
+func (t *table[T]) findTrash(ctx context.Context, opts *where.Options) (int64, []*T, error) {
+	var matched []*T
+	for _, row := range t.rows {
+		ok, err := t.matchScope(ctx, row, opts, true)
+		if err != nil {
+			return 0, nil, err
+		}
+		if ok {
+			matched = append(matched, copyOf(row))
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, _ := t.deletedAt(matched[i])
+		b, _ := t.deletedAt(matched[j])
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return t.get(matched[i], "id").(int64) > t.get(matched[j], "id").(int64)
+	})
+	count := int64(len(matched))
+	return count, page(matched, opts), nil
+}
+
+// restore 恢复回收站中满足条件的记录, 返回恢复的记录数.
+func (t *table[T]) restore(ctx context.Context, opts *where.Options) (int64, error) {
+	var restored int64
+	for _, row := range t.rows {
+		ok, err := t.matchScope(ctx, row, opts, true)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			t.set(row, "deletedAt", gorm.DeletedAt{})
+			restored++
+		}
+	}
+	return restored, nil
+}
+
+// purge 永久删除所有租户中在 before 之前被删除的记录, 返回删除的记录数.
+func (t *table[T]) purge(before time.Time) int64 {
+	return t.drop(func(row *T) bool {
+		deletedAt, ok := t.deletedAt(row)
+		return ok && deletedAt.Before(before)
+	})
+}
+
+// dropUsers 永久删除所有租户中属于 userIDs 中用户的记录, 包括软删除的记录, 返回删除的记录数.
+func (t *table[T]) dropUsers(userIDs map[string]bool) int64 {
+	return t.drop(func(row *T) bool {
+		userID, _ := t.get(row, "userID").(string)
+		return userIDs[userID]
+	})
+}
+
+// drop 永久删除所有租户中满足 pred 的记录, 返回删除的记录数.
+func (t *table[T]) drop(pred func(row *T) bool) int64 {
+	kept := t.rows[:0]
+	for _, row := range t.rows {
+		if !pred(row) {
+			kept = append(kept, row)
+		}
+	}
+	purged := int64(len(t.rows) - len(kept))
+	for i := len(kept); i < len(t.rows); i++ {
+		t.rows[i] = nil
+	}
+	t.rows = kept
+	return purged
+}
+
+// exists 判断当前租户中 column 列的值为 value 的记录是否存在, 用于模拟租户内的唯一索引.
+func (t *table[T]) exists(ctx context.Context, column string, value any, exceptID int64) bool {
+	for _, row := range t.rows {
+		if t.get(row, "id").(int64) != exceptID && t.inTenant(ctx, row) && equal(t.get(row, column), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// find 返回满足条件的记录总数以及分页后的记录, 记录按照 `id desc` 排序, 与数据库实现保持一致.
+// opts.SkipCount 为 true 时, 记录总数返回 -1.
+func (t *table[T]) find(ctx context.Context, opts *where.Options) (int64, []*T, error) {
+	var matched []*T
+	for _, row := range t.rows {
+		ok, err := t.match(ctx, row, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		if ok {
+			matched = append(matched, copyOf(row))
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return t.less(matched[i], matched[j], opts)
+	})
+
+	count := int64(len(matched))
+	if opts == nil {
+		return count, matched, nil
+	}
+	if opts.SkipCount {
+		count = -1
+	}
+
+	// 游标分页时, 总数不受游标的影响
+	if opts.AfterID > 0 {
+		kept := matched[:0]
+		for _, row := range matched {
+			if t.after(row, opts) {
+				kept = append(kept, row)
+			}
+		}
+		matched = kept
+	}
+
+	return count, page(matched, opts), nil
+}
+
+// page 返回 opts.Offset 和 opts.Limit 指定范围内的记录.
+func page[T any](rows []*T, opts *where.Options) []*T {
+	if opts == nil {
+		return rows
+	}
+	if opts.Offset > 0 {
+		if opts.Offset >= len(rows) {
+			return nil
+		}
+		rows = rows[opts.Offset:]
+	}
+	if opts.Limit >= 0 && opts.Limit < len(rows) {
+		rows = rows[:opts.Limit]
+	}
+	return rows
+}
+
+// less 判断记录 a 是否排在记录 b 之前, 先按照 opts.Sorts 排序, 最后按照 `id desc` 排序.
+func (t *table[T]) less(a *T, b *T, opts *where.Options) bool {
+	if opts != nil {
+		for _, s := range opts.Sorts {
+			c, _ := compare(t.get(a, s.Column), t.get(b, s.Column))
+			if c != 0 {
+				return (c < 0) != s.Desc
+			}
+		}
+	}
+	return t.get(a, "id").(int64) > t.get(b, "id").(int64)
+}
+
+// after 判断记录是否排在游标 (opts.AfterValues..., opts.AfterID) 之后.
+// 与 where.Options 一样, AfterValues 与 Sorts 的个数不一致时只使用主键作为游标.
+func (t *table[T]) after(row *T, opts *where.Options) bool {
+	if len(opts.AfterValues) != len(opts.Sorts) {
+		return t.get(row, "id").(int64) < opts.AfterID
+	}
+	for i, s := range opts.Sorts {
+		c, _ := compare(t.get(row, s.Column), opts.AfterValues[i])
+		if c != 0 {
+			return (c > 0) != s.Desc
+		}
+	}
+	return t.get(row, "id").(int64) < opts.AfterID
+}
+
+// first 返回第一条满足条件的记录, 记录按照主键升序排列, 与 GORM 的 First 方法保持一致.
+func (t *table[T]) first(ctx context.Context, opts *where.Options) (*T, error) {
+	for _, row := range t.rows {
+		ok, err := t.match(ctx, row, opts)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return copyOf(row), nil
+		}
+	}
+	return nil, nil
+}
+
+// match 判断记录是否属于当前租户、未被软删除并且满足 opts 中的过滤条件.
+// 支持 Filters(等值或 IN 查询) 和形如 `column op ?` 的简单 Queries, 不支持 Clauses.
+func (t *table[T]) match(ctx context.Context, row *T, opts *where.Options) (bool, error) {
+	return t.matchScope(ctx, row, opts, false)
+}
+
+// matchScope 与 match 相同, trash 为 true 时只匹配已被软删除的记录.
+func (t *table[T]) matchScope(ctx context.Context, row *T, opts *where.Options, trash bool) (bool, error) {
+	if !t.inTenant(ctx, row) {
+		return false, nil
+	}
+	if _, deleted := t.deletedAt(row); deleted != trash {
+		return false, nil
+	}
+	if opts == nil {
+		return true, nil
+	}
+	if len(opts.Clauses) > 0 {
+		return false, fmt.Errorf("fake: clauses are not supported")
+	}
+
+	for key, value := range opts.Filters {
+		column, ok := key.(string)
+		if !ok {
+			return false, fmt.Errorf("fake: unsupported filter key %v", key)
+		}
+		field := t.schema.LookUpField(column)
+		if field == nil {
+			return false, fmt.Errorf("fake: unknown column %s", column)
+		}
+		if !matchValue(t.value(row, field), value) {
+			return false, nil
+		}
+	}
+
+	for _, query := range opts.Queries {
+		ok, err := t.matchQuery(row, query)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// matchQuery 判断记录是否满足单个查询条件.
+func (t *table[T]) matchQuery(row *T, query where.Query) (bool, error) {
+	str, _ := query.Query.(string)
+	matches := queryRegexp.FindStringSubmatch(str)
+	if matches == nil || len(query.Args) != 1 {
+		return false, fmt.Errorf("fake: unsupported query %v", query.Query)
+	}
+
+	field := t.schema.LookUpField(matches[1])
+	if field == nil {
+		return false, fmt.Errorf("fake: unknown column %s", matches[1])
+	}
+	actual, expected := t.value(row, field), query.Args[0]
+
+	switch op := strings.ToLower(matches[2]); op {
+	case "=":
+		return equal(actual, expected), nil
+	case "!=", "<>":
+		return !equal(actual, expected), nil
+	case "like":
+		return like(fmt.Sprint(actual), fmt.Sprint(expected), matches[3]), nil
+	default:
+		c, ok := compare(actual, expected)
+		if !ok {
+			return false, fmt.Errorf("fake: cannot compare %T with %T", actual, expected)
+		}
+		switch op {
+		case ">":
+			return c > 0, nil
+		case ">=":
+			return c >= 0, nil
+		case "<":
+			return c < 0, nil
+		default:
+			return c <= 0, nil
+		}
+	}
+}
+
+// tenant 返回租户列和当前租户, 未注册租户或者表中没有租户列时返回 nil.
+func (t *table[T]) tenant(ctx context.Context) (*schema.Field, string) {
+	tenant, ok := where.RegisteredTenant()
+	if !ok || where.TenantSkipped(ctx) {
+		return nil, ""
+	}
+	field := t.schema.LookUpField(tenant.Key)
+	if field == nil {
+		return nil, ""
+	}
+	return field, tenant.ValueFunc(ctx)
+}
+
+// inTenant 判断记录是否属于当前租户.
+func (t *table[T]) inTenant(ctx context.Context, row *T) bool {
+	field, value := t.tenant(ctx)
+	return field == nil || equal(t.value(row, field), value)
+}
+
+// deletedAt 返回记录的删除时间, 表中没有 deletedAt 列或者记录未被删除时返回 false.
+func (t *table[T]) deletedAt(row *T) (time.Time, bool) {
+	field := t.schema.LookUpField("deletedAt")
+	if field == nil {
+		return time.Time{}, false
+	}
+	deletedAt, _ := t.value(row, field).(gorm.DeletedAt)
+	return deletedAt.Time, deletedAt.Valid
+}
+
+// get 返回记录中 column 列的值.
+func (t *table[T]) get(row *T, column string) any {
+	return t.value(row, t.schema.LookUpField(column))
+}
+
+// set 设置记录中 column 列的值.
+func (t *table[T]) set(row *T, column string, value any) {
+	_ = t.schema.LookUpField(column).Set(context.Background(), reflect.ValueOf(row).Elem(), value)
+}
+
+// value 返回记录中某个字段的值.
+func (t *table[T]) value(row *T, field *schema.Field) any {
+	return field.ReflectValueOf(context.Background(), reflect.ValueOf(row).Elem()).Interface()
+}
+
+// copyOf 返回记录的浅拷贝, 避免调用方修改内存表中保存的记录.
+func copyOf[T any](row *T) *T {
+	c := *row
+	return &c
+}
+
+// matchValue 判断 actual 是否等于 expected; expected 为切片时判断 actual 是否在切片中, 与 GORM 的 IN 查询一致.
+func matchValue(actual any, expected any) bool {
+	v := reflect.ValueOf(expected)
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < v.Len(); i++ {
+			if equal(actual, v.Index(i).Interface()) {
+				return true
+			}
+		}
+		return false
+	}
+	return equal(actual, expected)
+}
+
+// equal 判断两个值是否相等, 数值类型按照字面值比较.
+func equal(a any, b any) bool {
+	if c, ok := compare(a, b); ok {
+		return c == 0
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// compare 比较两个值的大小, 支持数值、字符串和时间类型.
+func compare(a any, b any) (int, bool) {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		if !ok {
+			return 0, false
+		}
+		return ta.Compare(tb), true
+	}
+
+	fa, aok := toFloat(a)
+	fb, bok := toFloat(b)
+	if aok && bok {
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	sa, aok := a.(string)
+	sb, bok := b.(string)
+	if aok && bok {
+		return strings.Compare(sa, sb), true
+	}
+	return 0, false
+}
+
+// toFloat 将数值类型转换为 float64.
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// like 实现 SQL LIKE 匹配, `%` 匹配任意多个字符, `_` 匹配单个字符.
+// escape 不为空时, escape 之后的字符按照字面值匹配.
+func like(s string, pattern string, escape string) bool {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		case escape != "" && string(r) == escape:
+			escaped = true
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()).MatchString(s)
+}
@@ -0,0 +1,80 @@
+package fake
+
+import (
+	"context"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+)
+
+// twoFactorStore 是 store.TwoFactorStore 的内存实现.
+type twoFactorStore struct {
+	ds *datastore
+}
+
+var _ store.TwoFactorStore = (*twoFactorStore)(nil)
+
+// Create 插入一条两步验证记录, 并校验租户内 userID 的唯一性.
+func (s *twoFactorStore) Create(ctx context.Context, obj *model.TwoFactor) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	if s.ds.twoFactors.exists(ctx, "userID", obj.UserID, 0) {
+		return errorsx.ErrDBWrite.WithMessage("duplicate two-factor for user %s", obj.UserID)
+	}
+	s.ds.twoFactors.insert(ctx, obj)
+	return nil
+}
+
+// Update 更新两步验证记录.
+func (s *twoFactorStore) Update(ctx context.Context, obj *model.TwoFactor) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	if !s.ds.twoFactors.update(ctx, obj) {
+		s.ds.twoFactors.insert(ctx, obj)
+	}
+	return nil
+}
+
+// Delete 根据条件删除两步验证记录.
+func (s *twoFactorStore) Delete(ctx context.Context, opts *where.Options) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	if err := s.ds.twoFactors.remove(ctx, opts); err != nil {
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Get 根据条件查询两步验证记录.
+func (s *twoFactorStore) Get(ctx context.Context, opts *where.Options) (*model.TwoFactor, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	obj, err := s.ds.twoFactors.first(ctx, opts)
+	if err != nil {
+		return nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	if obj == nil {
+		return nil, errorsx.ErrTwoFactorNotEnrolled
+	}
+	return obj, nil
+}
+
+// Advance 将记录 id 最近一次验证通过的时间步更新为 step, 只有 step 大于已记录的时间步时才会更新.
+func (s *twoFactorStore) Advance(ctx context.Context, id int64, step int64) (bool, error) {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	for _, row := range s.ds.twoFactors.rows {
+		if row.ID == id && s.ds.twoFactors.inTenant(ctx, row) && row.LastStep < step {
+			row.LastStep = step
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,158 @@
+package fake
+
+import (
+	"context"
+	"time"
+
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/pkg/audit"
+	"fastgo/internal/apiserver/store"
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/rid"
+	where "fastgo/pkg/store"
+)
+
+// userStore 是 store.UserStore 的内存实现.
+type userStore struct {
+	ds *datastore
+}
+
+var _ store.UserStore = (*userStore)(nil)
+
+// Create 插入一条用户记录.
+// 与数据库实现一样, 插入前加密密码, 插入后生成 userID, 并校验 username 的唯一性.
+func (s *userStore) Create(ctx context.Context, obj *model.User) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	if s.ds.users.exists(ctx, "username", obj.Username, 0) {
+		return errorsx.ErrDBWrite.WithMessage("duplicate username %s", obj.Username)
+	}
+
+	// BeforeCreate 不依赖 *gorm.DB, 可以直接复用
+	if err := obj.BeforeCreate(nil); err != nil {
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+
+	s.ds.users.insert(ctx, obj)
+	obj.UserID = rid.UserID.New(uint64(obj.ID))
+	s.ds.users.update(ctx, obj)
+	s.ds.audit(ctx, audit.ResourceUser, obj.UserID, audit.ActionCreate, nil, obj)
+	return nil
+}
+
+// Update 更新用户记录, 与数据库实现一样, 版本号不一致时返回 ErrVersionConflict.
+func (s *userStore) Update(ctx context.Context, obj *model.User) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	if s.ds.users.exists(ctx, "username", obj.Username, obj.ID) {
+		return errorsx.ErrDBWrite.WithMessage("duplicate username %s", obj.Username)
+	}
+	before, _ := s.ds.users.first(ctx, where.F("id", obj.ID))
+	if !s.ds.users.compareAndUpdate(ctx, obj) {
+		return errorsx.ErrVersionConflict
+	}
+	s.ds.audit(ctx, audit.ResourceUser, obj.UserID, audit.ActionUpdate, before, obj)
+	return nil
+}
+
+// Delete 根据条件删除用户记录.
+func (s *userStore) Delete(ctx context.Context, opts *where.Options) error {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	_, deleted, err := s.ds.users.find(ctx, opts)
+	if err != nil {
+		return errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	if err := s.ds.users.remove(ctx, opts); err != nil {
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	for _, obj := range deleted {
+		s.ds.audit(ctx, audit.ResourceUser, obj.UserID, audit.ActionDelete, obj, nil)
+	}
+	return nil
+}
+
+// Get 根据条件查询用户记录.
+func (s *userStore) Get(ctx context.Context, opts *where.Options) (*model.User, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	obj, err := s.ds.users.first(ctx, opts)
+	if err != nil {
+		return nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	if obj == nil {
+		return nil, errorsx.ErrUserNotFound
+	}
+	return obj, nil
+}
+
+// List 返回用户列表和总数.
+func (s *userStore) List(ctx context.Context, opts *where.Options) (int64, []*model.User, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	count, ret, err := s.ds.users.find(ctx, opts)
+	if err != nil {
+		return 0, nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return count, ret, nil
+}
+
+// ListTrash 返回回收站中满足条件的用户列表和总数.
+func (s *userStore) ListTrash(ctx context.Context, opts *where.Options) (int64, []*model.User, error) {
+	s.ds.mu.RLock()
+	defer s.ds.mu.RUnlock()
+
+	count, ret, err := s.ds.users.findTrash(ctx, opts)
+	if err != nil {
+		return 0, nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return count, ret, nil
+}
+
+// Restore 恢复回收站中满足条件的用户.
+func (s *userStore) Restore(ctx context.Context, opts *where.Options) (int64, error) {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	_, deleted, err := s.ds.users.findTrash(ctx, opts)
+	if err != nil {
+		return 0, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	restored, err := s.ds.users.restore(ctx, opts)
+	if err != nil {
+		return 0, errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	for _, obj := range deleted {
+		s.ds.audit(ctx, audit.ResourceUser, obj.UserID, audit.ActionRestore, nil, nil)
+	}
+	return restored, nil
+}
+
+// Purge 永久删除所有租户中在 before 之前被删除的用户, 与数据库实现一样同时删除这些用户的关联记录.
+func (s *userStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	s.ds.mu.Lock()
+	defer s.ds.mu.Unlock()
+
+	userIDs := map[string]bool{}
+	for _, row := range s.ds.users.rows {
+		if deletedAt, ok := s.ds.users.deletedAt(row); ok && deletedAt.Before(before) {
+			userIDs[row.UserID] = true
+		}
+	}
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	s.ds.posts.dropUsers(userIDs)
+	s.ds.refreshTokens.dropUsers(userIDs)
+	s.ds.twoFactors.dropUsers(userIDs)
+	s.ds.recoveryCodes.dropUsers(userIDs)
+	s.ds.externalIdentities.dropUsers(userIDs)
+	s.ds.passwordHistory.dropUsers(userIDs)
+	return s.ds.users.dropUsers(userIDs), nil
+}
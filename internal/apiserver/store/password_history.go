@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+	"log/slog"
+)
+
+// PasswordHistoryStore 定义了历史密码在 store 层实现的方法.
+type PasswordHistoryStore interface {
+	Create(ctx context.Context, obj *model.PasswordHistory) error
+	List(ctx context.Context, opts *where.Options) (int64, []*model.PasswordHistory, error)
+	Prune(ctx context.Context, userID string, keep int) error
+}
+
+type passwordHistoryStore struct {
+	store *datastore
+}
+
+var _ PasswordHistoryStore = (*passwordHistoryStore)(nil)
+
+// newPasswordHistoryStore 创建 passwordHistoryStore 的实例.
+func newPasswordHistoryStore(store *datastore) *passwordHistoryStore {
+	return &passwordHistoryStore{store: store}
+}
+
+// Create 插入一条历史密码记录.
+func (s *passwordHistoryStore) Create(ctx context.Context, obj *model.PasswordHistory) error {
+	if err := s.store.DB(ctx).Create(obj).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to insert password history into database", "err", err, "userID", obj.UserID)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Prune 只保留用户最近被替换的 keep 个历史密码, 删除更早的记录.
+func (s *passwordHistoryStore) Prune(ctx context.Context, userID string, keep int) error {
+	var ids []int64
+	err := s.store.DB(ctx).Model(new(model.PasswordHistory)).Where("userID = ?", userID).Order("id desc").Limit(keep).Pluck("id", &ids).Error
+	if err == nil {
+		db := s.store.DB(ctx).Where("userID = ?", userID)
+		if len(ids) > 0 {
+			db = db.Where("id NOT IN ?", ids)
+		}
+		err = db.Delete(new(model.PasswordHistory)).Error
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to prune password history from database", "err", err, "userID", userID)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// List 返回历史密码列表和总数, 按照 `id desc` 排序, 即最近被替换的密码在前.
+// nolint: nonamedreturns
+func (s *passwordHistoryStore) List(ctx context.Context, opts *where.Options) (count int64, ret []*model.PasswordHistory, err error) {
+	err = s.store.DB(ctx, opts).Order("id desc").Find(&ret).Offset(-1).Limit(-1).Count(&count).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list password history from database", "err", err, "conditions", opts)
+		err = errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return
+}
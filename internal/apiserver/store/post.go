@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/pkg/audit"
 	"fastgo/internal/pkg/errorsx"
 	where "fastgo/pkg/store"
 	"gorm.io/gorm"
 	"log/slog"
+	"time"
 )
 
 // PostStore 定义了 post 模块在 store 层实现的方法.
@@ -23,6 +25,12 @@ type PostStore interface {
 
 // PostExpansion 定义了用户操作的附加方法.
 type PostExpansion interface {
+	// ListTrash 返回回收站中满足条件的博客列表和总数, 按照删除时间降序排列.
+	ListTrash(ctx context.Context, opts *where.Options) (int64, []*model.Post, error)
+	// Restore 恢复回收站中满足条件的博客, 返回恢复的记录数.
+	Restore(ctx context.Context, opts *where.Options) (int64, error)
+	// Purge 永久删除所有租户中在 before 之前被删除的博客, 返回删除的记录数.
+	Purge(ctx context.Context, before time.Time) (int64, error)
 }
 
 type postStore struct {
@@ -36,59 +44,165 @@ func newPostStore(store *datastore) *postStore {
 	return &postStore{store: store}
 }
 
-// Create 插入一条用户记录.
+// Create 插入一条博客记录, 并在同一个事务中记录审计日志.
 func (s *postStore) Create(ctx context.Context, obj *model.Post) error {
-	// 调用`s.store.DB(ctx)`尝试从context中获取事务, 若没有事务则获取`*gorm.DB`类型的实例
-	// 调用`*gorm.DB`提供的`Create`方法进行数据库插入记录
-	if err := s.store.DB(ctx).Create(&obj).Error; err != nil {
-		slog.Error("Failed to insert post into database", "err", err, "post", obj)
-		// 项目`internal/pkg/errorsx`对DB错误进行封装, 防止直接输出未过滤的敏感信息
-		return errorsx.ErrDBWrite.WithMessage(err.Error())
-	}
-	return nil
+	return s.store.TX(ctx, func(ctx context.Context) error {
+		// 调用`s.store.DB(ctx)`尝试从context中获取事务, 若没有事务则获取`*gorm.DB`类型的实例
+		// 调用`*gorm.DB`提供的`Create`方法进行数据库插入记录
+		if err := s.store.DB(ctx).Create(&obj).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to insert post into database", "err", err, "post", obj)
+			// 项目`internal/pkg/errorsx`对DB错误进行封装, 防止直接输出未过滤的敏感信息
+			return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+		}
+		return s.store.AuditLog().Create(ctx, audit.New(ctx, audit.ResourcePost, obj.PostID, audit.ActionCreate, nil, obj))
+	})
 }
 
-// Delete 根据条件删除用户记录.
+// Delete 根据条件将博客移入回收站(软删除), 回收站中的记录由 Purge 永久删除.
+// 每条被删除的记录都在同一个事务中记录审计日志.
 func (s *postStore) Delete(ctx context.Context, opts *where.Options) error {
-	err := s.store.DB(ctx).Delete(new(model.Post)).Error
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		slog.Error("Failed to delete post from database", "err", err, "conditions", opts)
-		return errorsx.ErrDBWrite.WithMessage(err.Error())
-	}
-	return nil
+	return s.store.TX(ctx, func(ctx context.Context) error {
+		// 删除前查询将被删除的记录, 用于记录审计日志
+		var deleted []*model.Post
+		if err := s.store.DB(ctx, opts).Find(&deleted).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to retrieve posts to delete from database", "err", err, "conditions", opts)
+			return errorsx.ErrDBRead.WithMessage("%s", err.Error())
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+
+		err := s.store.DB(ctx, opts).Delete(new(model.Post)).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.ErrorContext(ctx, "Failed to delete post from database", "err", err, "conditions", opts)
+			return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+		}
+		for _, obj := range deleted {
+			if err := s.store.AuditLog().Create(ctx, audit.New(ctx, audit.ResourcePost, obj.PostID, audit.ActionDelete, obj, nil)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// List 返回用户列表和总数.
+// List 返回用户列表和总数, opts.SkipCount 为 true 时总数为 -1.
 // nolint: nonamedreturns
 func (s *postStore) List(ctx context.Context, opts *where.Options) (count int64, ret []*model.Post, err error) {
 	// 通过`s.store.DB`的可变入参传入查询条件
 	// 后续表示 : 按数据库字段`id`降序排列、
-	err = s.store.DB(ctx, opts).Order("id desc").Find(&ret).Offset(-1).Limit(-1).Count(&count).Error
+	db := s.store.DB(ctx, opts).Order("id desc").Find(&ret)
+	switch {
+	case opts.SkipCount:
+		// 调用方不需要总数时, 省去额外的 COUNT 查询, 总数返回 -1
+		count = -1
+	case opts.AfterID > 0:
+		// 游标分页时, 总数不受游标的影响
+		if db.Error == nil {
+			db = s.store.DB(ctx, opts.Total()).Model(&model.Post{}).Count(&count)
+		}
+	default:
+		db = db.Offset(-1).Limit(-1).Count(&count)
+	}
+	err = db.Error
 	if err != nil {
-		slog.Error("Failed to list posts from database", "err", err, "conditions", opts)
-		err = errorsx.ErrDBRead.WithMessage(err.Error())
+		slog.ErrorContext(ctx, "Failed to list posts from database", "err", err, "conditions", opts)
+		err = errorsx.ErrDBRead.WithMessage("%s", err.Error())
 	}
 	return
 }
 
-// Update 更新帖子数据库记录.
+// Update 更新博客数据库记录, 只有数据库中的版本号与 obj.Version 一致时才会更新, 更新后 obj.Version 加 1.
+// 记录已被其他请求修改(版本号不一致)时返回 ErrVersionConflict. 更新前后的字段变更在同一个事务中记录到审计日志.
 func (s *postStore) Update(ctx context.Context, obj *model.Post) error {
-	if err := s.store.DB(ctx).Save(obj).Error; err != nil {
-		slog.Error("Failed to update post in database", "err", err, "post", obj)
-		return errorsx.ErrDBWrite.WithMessage(err.Error())
-	}
-	return nil
+	return s.store.TX(ctx, func(ctx context.Context) error {
+		// 更新前查询当前记录, 用于记录审计日志
+		var before model.Post
+		if err := s.store.DB(ctx).Where("id = ?", obj.ID).First(&before).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errorsx.ErrVersionConflict
+			}
+			slog.ErrorContext(ctx, "Failed to retrieve post from database", "err", err, "post", obj)
+			return errorsx.ErrDBRead.WithMessage("%s", err.Error())
+		}
+
+		version := obj.Version
+		obj.Version++
+		// 指定 Select 后, 没有更新任何记录时 Save 不会退化为插入
+		db := s.store.DB(ctx).Select("*").Where("version = ?", version).Save(obj)
+		if err := db.Error; err != nil {
+			obj.Version = version
+			slog.ErrorContext(ctx, "Failed to update post in database", "err", err, "post", obj)
+			return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+		}
+		if db.RowsAffected == 0 {
+			obj.Version = version
+			return errorsx.ErrVersionConflict
+		}
+		return s.store.AuditLog().Create(ctx, audit.New(ctx, audit.ResourcePost, obj.PostID, audit.ActionUpdate, &before, obj))
+	})
 }
 
 // Get 根据条件查询帖子记录.
 func (s *postStore) Get(ctx context.Context, opts *where.Options) (*model.Post, error) {
 	var obj model.Post
 	if err := s.store.DB(ctx, opts).First(&obj).Error; err != nil {
-		slog.Error("Failed to retrieve post from database", "err", err, "conditions", opts)
+		slog.ErrorContext(ctx, "Failed to retrieve post from database", "err", err, "conditions", opts)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errorsx.ErrPostNotFound
 		}
-		return nil, errorsx.ErrDBRead.WithMessage(err.Error())
+		return nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
 	}
 	return &obj, nil
 }
+
+// ListTrash 返回回收站中满足条件的博客列表和总数, 按照删除时间降序排列.
+// nolint: nonamedreturns
+func (s *postStore) ListTrash(ctx context.Context, opts *where.Options) (count int64, ret []*model.Post, err error) {
+	err = s.store.DB(ctx, opts).Unscoped().Where("deletedAt IS NOT NULL").Order("deletedAt desc").Order("id desc").
+		Find(&ret).Offset(-1).Limit(-1).Count(&count).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list deleted posts from database", "err", err, "conditions", opts)
+		err = errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return
+}
+
+// Restore 恢复回收站中满足条件的博客, 返回恢复的记录数, 每条被恢复的记录都在同一个事务中记录审计日志.
+// nolint: nonamedreturns
+func (s *postStore) Restore(ctx context.Context, opts *where.Options) (restored int64, err error) {
+	err = s.store.TX(ctx, func(ctx context.Context) error {
+		var deleted []*model.Post
+		if err := s.store.DB(ctx, opts).Unscoped().Where("deletedAt IS NOT NULL").Find(&deleted).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to retrieve deleted posts from database", "err", err, "conditions", opts)
+			return errorsx.ErrDBRead.WithMessage("%s", err.Error())
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+
+		db := s.store.DB(ctx, opts).Unscoped().Model(new(model.Post)).Where("deletedAt IS NOT NULL").UpdateColumn("deletedAt", nil)
+		if err := db.Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to restore posts in database", "err", err, "conditions", opts)
+			return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+		}
+		for _, obj := range deleted {
+			if err := s.store.AuditLog().Create(ctx, audit.New(ctx, audit.ResourcePost, obj.PostID, audit.ActionRestore, nil, nil)); err != nil {
+				return err
+			}
+		}
+		restored = db.RowsAffected
+		return nil
+	})
+	return restored, err
+}
+
+// Purge 永久删除所有租户中在 before 之前被删除的博客, 返回删除的记录数.
+func (s *postStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	db := s.store.DB(where.SkipTenant(ctx)).Unscoped().Where("deletedAt < ?", before).Delete(new(model.Post))
+	if err := db.Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to purge deleted posts from database", "err", err, "before", before)
+		return 0, errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return db.RowsAffected, nil
+}
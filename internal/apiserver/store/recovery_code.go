@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+	"gorm.io/gorm"
+	"log/slog"
+	"time"
+)
+
+// RecoveryCodeStore 定义了两步验证恢复码模块在 store 层实现的方法.
+type RecoveryCodeStore interface {
+	Create(ctx context.Context, obj *model.RecoveryCode) error
+	Delete(ctx context.Context, opts *where.Options) error
+	List(ctx context.Context, opts *where.Options) (int64, []*model.RecoveryCode, error)
+
+	RecoveryCodeExpansion
+}
+
+// RecoveryCodeExpansion 定义了恢复码操作的附加方法.
+type RecoveryCodeExpansion interface {
+	// Use 将满足条件且尚未使用的恢复码标记为已使用, 返回是否有恢复码被标记.
+	Use(ctx context.Context, opts *where.Options) (bool, error)
+}
+
+type recoveryCodeStore struct {
+	store *datastore
+}
+
+var _ RecoveryCodeStore = (*recoveryCodeStore)(nil)
+
+// newRecoveryCodeStore 创建 recoveryCodeStore 的实例.
+func newRecoveryCodeStore(store *datastore) *recoveryCodeStore {
+	return &recoveryCodeStore{store: store}
+}
+
+// Create 插入一条恢复码记录.
+func (s *recoveryCodeStore) Create(ctx context.Context, obj *model.RecoveryCode) error {
+	if err := s.store.DB(ctx).Create(obj).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to insert recovery code into database", "err", err, "userID", obj.UserID)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Delete 根据条件删除恢复码记录.
+func (s *recoveryCodeStore) Delete(ctx context.Context, opts *where.Options) error {
+	err := s.store.DB(ctx, opts).Delete(new(model.RecoveryCode)).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.ErrorContext(ctx, "Failed to delete recovery codes from database", "err", err, "conditions", opts)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// List 返回恢复码列表和总数.
+// nolint: nonamedreturns
+func (s *recoveryCodeStore) List(ctx context.Context, opts *where.Options) (count int64, ret []*model.RecoveryCode, err error) {
+	err = s.store.DB(ctx, opts).Order("id desc").Find(&ret).Offset(-1).Limit(-1).Count(&count).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list recovery codes from database", "err", err, "conditions", opts)
+		err = errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return
+}
+
+// Use 将满足条件且尚未使用的恢复码标记为已使用, 返回是否有恢复码被标记.
+func (s *recoveryCodeStore) Use(ctx context.Context, opts *where.Options) (bool, error) {
+	db := s.store.DB(ctx, opts).Model(new(model.RecoveryCode)).Where("usedAt IS NULL").Update("usedAt", time.Now())
+	if err := db.Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to use recovery code in database", "err", err, "conditions", opts)
+		return false, errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return db.RowsAffected > 0, nil
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+	"gorm.io/gorm"
+	"log/slog"
+	"time"
+)
+
+// RefreshTokenStore 定义了 refresh token 模块在 store 层实现的方法.
+type RefreshTokenStore interface {
+	Create(ctx context.Context, obj *model.RefreshToken) error
+	Update(ctx context.Context, obj *model.RefreshToken) error
+	Delete(ctx context.Context, opts *where.Options) error
+	Get(ctx context.Context, opts *where.Options) (*model.RefreshToken, error)
+	List(ctx context.Context, opts *where.Options) (int64, []*model.RefreshToken, error)
+
+	RefreshTokenExpansion
+}
+
+// RefreshTokenExpansion 定义了 refresh token 操作的附加方法.
+type RefreshTokenExpansion interface {
+	// Revoke 吊销所有满足条件且尚未吊销的 refresh token, 返回吊销的记录数.
+	Revoke(ctx context.Context, opts *where.Options) (int64, error)
+}
+
+type refreshTokenStore struct {
+	store *datastore
+}
+
+var _ RefreshTokenStore = (*refreshTokenStore)(nil)
+
+// newRefreshTokenStore 创建 refreshTokenStore 的实例.
+func newRefreshTokenStore(store *datastore) *refreshTokenStore {
+	return &refreshTokenStore{store: store}
+}
+
+// Create 插入一条 refresh token 记录.
+func (s *refreshTokenStore) Create(ctx context.Context, obj *model.RefreshToken) error {
+	if err := s.store.DB(ctx).Create(obj).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to insert refresh token into database", "err", err, "userID", obj.UserID)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Delete 根据条件删除 refresh token 记录.
+func (s *refreshTokenStore) Delete(ctx context.Context, opts *where.Options) error {
+	err := s.store.DB(ctx, opts).Delete(new(model.RefreshToken)).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.ErrorContext(ctx, "Failed to delete refresh token from database", "err", err, "conditions", opts)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// List 返回 refresh token 列表和总数.
+// nolint: nonamedreturns
+func (s *refreshTokenStore) List(ctx context.Context, opts *where.Options) (count int64, ret []*model.RefreshToken, err error) {
+	err = s.store.DB(ctx, opts).Order("id desc").Find(&ret).Offset(-1).Limit(-1).Count(&count).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list refresh tokens from database", "err", err, "conditions", opts)
+		err = errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return
+}
+
+// Update 更新 refresh token 记录.
+func (s *refreshTokenStore) Update(ctx context.Context, obj *model.RefreshToken) error {
+	if err := s.store.DB(ctx).Save(obj).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to update refresh token in database", "err", err, "id", obj.ID)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Get 根据条件查询 refresh token 记录.
+func (s *refreshTokenStore) Get(ctx context.Context, opts *where.Options) (*model.RefreshToken, error) {
+	var obj model.RefreshToken
+	if err := s.store.DB(ctx, opts).First(&obj).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorsx.ErrRefreshTokenInvalid
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve refresh token from database", "err", err, "conditions", opts)
+		return nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return &obj, nil
+}
+
+// Revoke 吊销所有满足条件且尚未吊销的 refresh token, 返回吊销的记录数.
+// 条件更新, 并发吊销同一个 refresh token 时只有一个请求的吊销记录数为 1.
+func (s *refreshTokenStore) Revoke(ctx context.Context, opts *where.Options) (int64, error) {
+	result := s.store.DB(ctx, opts).Model(new(model.RefreshToken)).Where("revokedAt IS NULL").Update("revokedAt", time.Now())
+	if result.Error != nil {
+		slog.ErrorContext(ctx, "Failed to revoke refresh tokens in database", "err", result.Error, "conditions", opts)
+		return 0, errorsx.ErrDBWrite.WithMessage("%s", result.Error.Error())
+	}
+	return result.RowsAffected, nil
+}
@@ -22,6 +22,12 @@ type IStore interface {
 
 	User() UserStore
 	Post() PostStore
+	RefreshToken() RefreshTokenStore
+	AuditLog() AuditLogStore
+	PasswordHistory() PasswordHistoryStore
+	TwoFactor() TwoFactorStore
+	RecoveryCode() RecoveryCodeStore
+	ExternalIdentity() ExternalIdentityStore
 }
 
 // transactionKey 用于在 context.Context 中存储事务上下文的键.
@@ -58,6 +64,8 @@ func (store *datastore) DB(ctx context.Context, wheres ...where.Where) *gorm.DB
 	if tx, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
 		db = tx
 	}
+	// 将 ctx 传递给 GORM, 使 GORM 插件(例如租户隔离)可以从 ctx 中获取请求信息
+	db = db.WithContext(ctx)
 
 	// 遍历所有传入的条件并逐一叠加到数据库查询对象上
 	for _, whr := range wheres {
@@ -69,8 +77,12 @@ func (store *datastore) DB(ctx context.Context, wheres ...where.Where) *gorm.DB
 
 // TX 返回一个新的事务实例.
 // TX方法将`*gorm.DB`类型实例注入context
+// 嵌套调用 TX 时, 内层事务直接复用外层事务.
 // nolint: fatcontext
 func (store *datastore) TX(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
 	// *gorm.DB.Transcation方法会自动:1.开始事务 2.根据返回值提交/回滚 3.处理panic(异常时回滚)
 	return store.core.WithContext(ctx).Transaction(
 		func(tx *gorm.DB) error {
@@ -91,3 +103,33 @@ func (store *datastore) User() UserStore {
 func (store *datastore) Post() PostStore {
 	return newPostStore(store)
 }
+
+// RefreshToken 返回一个实现了 RefreshTokenStore 接口的实例.
+func (store *datastore) RefreshToken() RefreshTokenStore {
+	return newRefreshTokenStore(store)
+}
+
+// AuditLog 返回一个实现了 AuditLogStore 接口的实例.
+func (store *datastore) AuditLog() AuditLogStore {
+	return newAuditLogStore(store)
+}
+
+// PasswordHistory 返回一个实现了 PasswordHistoryStore 接口的实例.
+func (store *datastore) PasswordHistory() PasswordHistoryStore {
+	return newPasswordHistoryStore(store)
+}
+
+// TwoFactor 返回一个实现了 TwoFactorStore 接口的实例.
+func (store *datastore) TwoFactor() TwoFactorStore {
+	return newTwoFactorStore(store)
+}
+
+// RecoveryCode 返回一个实现了 RecoveryCodeStore 接口的实例.
+func (store *datastore) RecoveryCode() RecoveryCodeStore {
+	return newRecoveryCodeStore(store)
+}
+
+// ExternalIdentity 返回一个实现了 ExternalIdentityStore 接口的实例.
+func (store *datastore) ExternalIdentity() ExternalIdentityStore {
+	return newExternalIdentityStore(store)
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"fastgo/internal/apiserver/migrations"
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestStore 创建一个使用临时 SQLite 数据库的 datastore, 并执行所有迁移.
+func newTestStore(t *testing.T) *datastore {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "fastgo.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	migrator, err := migrations.NewMigrator(db, "sqlite")
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	return &datastore{core: db}
+}
+
+// wantError 校验 err 的 Reason 与 want 一致, want 为 nil 时校验 err 为 nil.
+func wantError(t *testing.T, err error, want *errorsx.ErrorX) {
+	t.Helper()
+
+	if want == nil {
+		if err != nil {
+			t.Fatalf("error = %v, want nil", err)
+		}
+		return
+	}
+	if got := errorsx.FromError(err); got == nil || got.Reason != want.Reason {
+		t.Fatalf("error = %v, want %s", err, want.Reason)
+	}
+}
+
+func TestPostUpdateVersion(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	if err := s.Post().Create(ctx, &model.Post{UserID: "user-a", Title: "v1"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	first, _ := s.Post().Get(ctx, where.F("title", "v1"))
+	second, _ := s.Post().Get(ctx, where.F("title", "v1"))
+
+	first.Title = "v2"
+	wantError(t, s.Post().Update(ctx, first), nil)
+	if first.Version != 2 {
+		t.Errorf("Version after Update() = %d, want 2", first.Version)
+	}
+
+	// 基于旧版本的修改不能覆盖其他请求的修改
+	second.Title = "lost update"
+	wantError(t, s.Post().Update(ctx, second), errorsx.ErrVersionConflict)
+	if second.Version != 1 {
+		t.Errorf("Version after a conflicting Update() = %d, want 1", second.Version)
+	}
+	got, _ := s.Post().Get(ctx, where.F("postID", first.PostID))
+	if got.Title != "v2" || got.Version != 2 {
+		t.Errorf("post = %s version %d, want v2 version 2", got.Title, got.Version)
+	}
+
+	// 已删除的记录不会被重新插入
+	missing := &model.Post{ID: 100, PostID: "post-missing", UserID: "user-a", Version: 1}
+	wantError(t, s.Post().Update(ctx, missing), errorsx.ErrVersionConflict)
+	if _, err := s.Post().Get(ctx, where.F("postID", "post-missing")); err == nil {
+		t.Error("Update() of a missing post inserted it")
+	}
+}
+
+func TestUserUpdateVersion(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	if err := s.User().Create(ctx, &model.User{Username: "alice", Password: "x", Nickname: "alice", Email: "alice@example.com", Phone: "13800000000"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	first, _ := s.User().Get(ctx, where.F("username", "alice"))
+	second, _ := s.User().Get(ctx, where.F("username", "alice"))
+
+	first.Nickname = "Alice"
+	wantError(t, s.User().Update(ctx, first), nil)
+	second.Email = "mallory@example.com"
+	wantError(t, s.User().Update(ctx, second), errorsx.ErrVersionConflict)
+
+	got, _ := s.User().Get(ctx, where.F("username", "alice"))
+	if got.Nickname != "Alice" || got.Email != "alice@example.com" || got.Version != 2 {
+		t.Errorf("user = %+v, want the first update only", got)
+	}
+}
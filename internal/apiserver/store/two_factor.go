@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fastgo/internal/apiserver/model"
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+	"gorm.io/gorm"
+	"log/slog"
+)
+
+// TwoFactorStore 定义了两步验证模块在 store 层实现的方法.
+type TwoFactorStore interface {
+	Create(ctx context.Context, obj *model.TwoFactor) error
+	Update(ctx context.Context, obj *model.TwoFactor) error
+	Delete(ctx context.Context, opts *where.Options) error
+	Get(ctx context.Context, opts *where.Options) (*model.TwoFactor, error)
+
+	TwoFactorExpansion
+}
+
+// TwoFactorExpansion 定义了两步验证操作的附加方法.
+type TwoFactorExpansion interface {
+	// Advance 将记录 id 最近一次验证通过的时间步更新为 step.
+	// 只有 step 大于已记录的时间步时才会更新, 返回是否更新成功, 用于防止同一个验证码被重复使用.
+	Advance(ctx context.Context, id int64, step int64) (bool, error)
+}
+
+type twoFactorStore struct {
+	store *datastore
+}
+
+var _ TwoFactorStore = (*twoFactorStore)(nil)
+
+// newTwoFactorStore 创建 twoFactorStore 的实例.
+func newTwoFactorStore(store *datastore) *twoFactorStore {
+	return &twoFactorStore{store: store}
+}
+
+// Create 插入一条两步验证记录.
+func (s *twoFactorStore) Create(ctx context.Context, obj *model.TwoFactor) error {
+	if err := s.store.DB(ctx).Create(obj).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to insert two-factor into database", "err", err, "userID", obj.UserID)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Update 更新两步验证记录.
+func (s *twoFactorStore) Update(ctx context.Context, obj *model.TwoFactor) error {
+	if err := s.store.DB(ctx).Save(obj).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to update two-factor in database", "err", err, "id", obj.ID)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Delete 根据条件删除两步验证记录.
+func (s *twoFactorStore) Delete(ctx context.Context, opts *where.Options) error {
+	err := s.store.DB(ctx, opts).Delete(new(model.TwoFactor)).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.ErrorContext(ctx, "Failed to delete two-factor from database", "err", err, "conditions", opts)
+		return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return nil
+}
+
+// Get 根据条件查询两步验证记录.
+func (s *twoFactorStore) Get(ctx context.Context, opts *where.Options) (*model.TwoFactor, error) {
+	var obj model.TwoFactor
+	if err := s.store.DB(ctx, opts).First(&obj).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorsx.ErrTwoFactorNotEnrolled
+		}
+		slog.ErrorContext(ctx, "Failed to retrieve two-factor from database", "err", err, "conditions", opts)
+		return nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return &obj, nil
+}
+
+// Advance 将记录 id 最近一次验证通过的时间步更新为 step, 只有 step 大于已记录的时间步时才会更新.
+func (s *twoFactorStore) Advance(ctx context.Context, id int64, step int64) (bool, error) {
+	db := s.store.DB(ctx).Model(new(model.TwoFactor)).Where("id = ? AND lastStep < ?", id, step).Update("lastStep", step)
+	if err := db.Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to advance two-factor step in database", "err", err, "id", id)
+		return false, errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return db.RowsAffected > 0, nil
+}
@@ -8,10 +8,12 @@ import (
 	"context"
 	"errors"
 	"fastgo/internal/apiserver/model"
+	"fastgo/internal/apiserver/pkg/audit"
 	"fastgo/internal/pkg/errorsx"
 	where "fastgo/pkg/store"
 	"gorm.io/gorm"
 	"log/slog"
+	"time"
 )
 
 // UserStore 定义了 user 模块在 store 层实现的方法.
@@ -27,6 +29,12 @@ type UserStore interface {
 
 // UserExpansion 定义了用户操作的附加方法.
 type UserExpansion interface {
+	// ListTrash 返回回收站中满足条件的用户列表和总数, 按照删除时间降序排列.
+	ListTrash(ctx context.Context, opts *where.Options) (int64, []*model.User, error)
+	// Restore 恢复回收站中满足条件的用户, 返回恢复的记录数.
+	Restore(ctx context.Context, opts *where.Options) (int64, error)
+	// Purge 永久删除所有租户中在 before 之前被删除的用户, 返回删除的记录数.
+	Purge(ctx context.Context, before time.Time) (int64, error)
 }
 
 // userStore 是 UserStore 接口的实现.
@@ -45,59 +53,186 @@ func newUserStore(store *datastore) *userStore {
 	return &userStore{store: store}
 }
 
-// Create 插入一条用户记录.
+// Create 插入一条用户记录, 并在同一个事务中记录审计日志.
 func (s *userStore) Create(ctx context.Context, obj *model.User) error {
-	// 调用`s.store.DB(ctx)`尝试从context中获取事务, 若没有事务则获取`*gorm.DB`类型的实例
-	// 调用`*gorm.DB`提供的`Create`方法进行数据库插入记录
-	if err := s.store.DB(ctx).Create(&obj).Error; err != nil {
-		slog.Error("Failed to insert user into database", "err", err, "user", obj)
-		// 项目`internal/pkg/errorsx`对DB错误进行封装, 防止直接输出未过滤的敏感信息
-		return errorsx.ErrDBWrite.WithMessage(err.Error())
-	}
-	return nil
+	return s.store.TX(ctx, func(ctx context.Context) error {
+		// 调用`s.store.DB(ctx)`尝试从context中获取事务, 若没有事务则获取`*gorm.DB`类型的实例
+		// 调用`*gorm.DB`提供的`Create`方法进行数据库插入记录
+		if err := s.store.DB(ctx).Create(&obj).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to insert user into database", "err", err, "user", obj)
+			// 项目`internal/pkg/errorsx`对DB错误进行封装, 防止直接输出未过滤的敏感信息
+			return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+		}
+		return s.store.AuditLog().Create(ctx, audit.New(ctx, audit.ResourceUser, obj.UserID, audit.ActionCreate, nil, obj))
+	})
 }
 
-// Delete 根据条件删除用户记录.
+// Delete 根据条件将用户移入回收站(软删除), 回收站中的记录由 Purge 永久删除.
+// 每条被删除的记录都在同一个事务中记录审计日志.
 func (s *userStore) Delete(ctx context.Context, opts *where.Options) error {
-	err := s.store.DB(ctx).Delete(new(model.User)).Error
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		slog.Error("Failed to delete user from database", "err", err, "conditions", opts)
-		return errorsx.ErrDBWrite.WithMessage(err.Error())
-	}
-	return nil
+	return s.store.TX(ctx, func(ctx context.Context) error {
+		// 删除前查询将被删除的记录, 用于记录审计日志
+		var deleted []*model.User
+		if err := s.store.DB(ctx, opts).Find(&deleted).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to retrieve users to delete from database", "err", err, "conditions", opts)
+			return errorsx.ErrDBRead.WithMessage("%s", err.Error())
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+
+		err := s.store.DB(ctx, opts).Delete(new(model.User)).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.ErrorContext(ctx, "Failed to delete user from database", "err", err, "conditions", opts)
+			return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+		}
+		for _, obj := range deleted {
+			if err := s.store.AuditLog().Create(ctx, audit.New(ctx, audit.ResourceUser, obj.UserID, audit.ActionDelete, obj, nil)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// List 返回用户列表和总数.
+// List 返回用户列表和总数, opts.SkipCount 为 true 时总数为 -1.
 // nolint: nonamedreturns
 func (s *userStore) List(ctx context.Context, opts *where.Options) (count int64, ret []*model.User, err error) {
 	// 通过`s.store.DB`的可变入参传入查询条件
 	// 后续表示 : 按数据库字段`id`降序排列、
-	err = s.store.DB(ctx, opts).Order("id desc").Find(&ret).Offset(-1).Limit(-1).Count(&count).Error
+	db := s.store.DB(ctx, opts).Order("id desc").Find(&ret)
+	switch {
+	case opts.SkipCount:
+		// 调用方不需要总数时, 省去额外的 COUNT 查询, 总数返回 -1
+		count = -1
+	case opts.AfterID > 0:
+		// 游标分页时, 总数不受游标的影响
+		if db.Error == nil {
+			db = s.store.DB(ctx, opts.Total()).Model(&model.User{}).Count(&count)
+		}
+	default:
+		db = db.Offset(-1).Limit(-1).Count(&count)
+	}
+	err = db.Error
 	if err != nil {
-		slog.Error("Failed to list users from database", "err", err, "conditions", opts)
-		err = errorsx.ErrDBRead.WithMessage(err.Error())
+		slog.ErrorContext(ctx, "Failed to list users from database", "err", err, "conditions", opts)
+		err = errorsx.ErrDBRead.WithMessage("%s", err.Error())
 	}
 	return
 }
 
-// Update 更新用户数据库记录.
+// Update 更新用户数据库记录, 只有数据库中的版本号与 obj.Version 一致时才会更新, 更新后 obj.Version 加 1.
+// 记录已被其他请求修改(版本号不一致)时返回 ErrVersionConflict. 更新前后的字段变更在同一个事务中记录到审计日志.
 func (s *userStore) Update(ctx context.Context, obj *model.User) error {
-	if err := s.store.DB(ctx).Save(obj).Error; err != nil {
-		slog.Error("Failed to update user in database", "err", err, "user", obj)
-		return errorsx.ErrDBWrite.WithMessage(err.Error())
-	}
-	return nil
+	return s.store.TX(ctx, func(ctx context.Context) error {
+		// 更新前查询当前记录, 用于记录审计日志
+		var before model.User
+		if err := s.store.DB(ctx).Where("id = ?", obj.ID).First(&before).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errorsx.ErrVersionConflict
+			}
+			slog.ErrorContext(ctx, "Failed to retrieve user from database", "err", err, "user", obj)
+			return errorsx.ErrDBRead.WithMessage("%s", err.Error())
+		}
+
+		version := obj.Version
+		obj.Version++
+		// 指定 Select 后, 没有更新任何记录时 Save 不会退化为插入
+		db := s.store.DB(ctx).Select("*").Where("version = ?", version).Save(obj)
+		if err := db.Error; err != nil {
+			obj.Version = version
+			slog.ErrorContext(ctx, "Failed to update user in database", "err", err, "user", obj)
+			return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+		}
+		if db.RowsAffected == 0 {
+			obj.Version = version
+			return errorsx.ErrVersionConflict
+		}
+		return s.store.AuditLog().Create(ctx, audit.New(ctx, audit.ResourceUser, obj.UserID, audit.ActionUpdate, &before, obj))
+	})
 }
 
 // Get 根据条件查询用户记录.
 func (s *userStore) Get(ctx context.Context, opts *where.Options) (*model.User, error) {
 	var obj model.User
 	if err := s.store.DB(ctx, opts).First(&obj).Error; err != nil {
-		slog.Error("Failed to retrieve user from database", "err", err, "conditions", opts)
+		slog.ErrorContext(ctx, "Failed to retrieve user from database", "err", err, "conditions", opts)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errorsx.ErrUserNotFound
 		}
-		return nil, errorsx.ErrDBRead.WithMessage(err.Error())
+		return nil, errorsx.ErrDBRead.WithMessage("%s", err.Error())
 	}
 	return &obj, nil
 }
+
+// ListTrash 返回回收站中满足条件的用户列表和总数, 按照删除时间降序排列.
+// nolint: nonamedreturns
+func (s *userStore) ListTrash(ctx context.Context, opts *where.Options) (count int64, ret []*model.User, err error) {
+	err = s.store.DB(ctx, opts).Unscoped().Where("deletedAt IS NOT NULL").Order("deletedAt desc").Order("id desc").
+		Find(&ret).Offset(-1).Limit(-1).Count(&count).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list deleted users from database", "err", err, "conditions", opts)
+		err = errorsx.ErrDBRead.WithMessage("%s", err.Error())
+	}
+	return
+}
+
+// Restore 恢复回收站中满足条件的用户, 返回恢复的记录数, 每条被恢复的记录都在同一个事务中记录审计日志.
+// nolint: nonamedreturns
+func (s *userStore) Restore(ctx context.Context, opts *where.Options) (restored int64, err error) {
+	err = s.store.TX(ctx, func(ctx context.Context) error {
+		var deleted []*model.User
+		if err := s.store.DB(ctx, opts).Unscoped().Where("deletedAt IS NOT NULL").Find(&deleted).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to retrieve deleted users from database", "err", err, "conditions", opts)
+			return errorsx.ErrDBRead.WithMessage("%s", err.Error())
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+
+		db := s.store.DB(ctx, opts).Unscoped().Model(new(model.User)).Where("deletedAt IS NOT NULL").UpdateColumn("deletedAt", nil)
+		if err := db.Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to restore users in database", "err", err, "conditions", opts)
+			return errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+		}
+		for _, obj := range deleted {
+			if err := s.store.AuditLog().Create(ctx, audit.New(ctx, audit.ResourceUser, obj.UserID, audit.ActionRestore, nil, nil)); err != nil {
+				return err
+			}
+		}
+		restored = db.RowsAffected
+		return nil
+	})
+	return restored, err
+}
+
+// Purge 永久删除所有租户中在 before 之前被删除的用户, 返回删除的记录数.
+// 在同一个事务中删除这些用户的博客、refresh token、两步验证、恢复码、外部身份和历史密码, 避免留下孤立的记录.
+// 审计日志需要保留, 不随用户删除.
+func (s *userStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := s.store.TX(where.SkipTenant(ctx), func(ctx context.Context) error {
+		var userIDs []string
+		if err := s.store.DB(ctx).Unscoped().Model(new(model.User)).Where("deletedAt < ?", before).Pluck("userID", &userIDs).Error; err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+
+		dependents := []any{new(model.Post), new(model.RefreshToken), new(model.TwoFactor), new(model.RecoveryCode), new(model.ExternalIdentity), new(model.PasswordHistory)}
+		for _, dependent := range dependents {
+			if err := s.store.DB(ctx).Unscoped().Where("userID IN ?", userIDs).Delete(dependent).Error; err != nil {
+				return err
+			}
+		}
+		db := s.store.DB(ctx).Unscoped().Where("userID IN ?", userIDs).Delete(new(model.User))
+		purged = db.RowsAffected
+		return db.Error
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to purge deleted users from database", "err", err, "before", before)
+		return 0, errorsx.ErrDBWrite.WithMessage("%s", err.Error())
+	}
+	return purged, nil
+}
@@ -0,0 +1,24 @@
+package apiserver
+
+import (
+	"context"
+
+	"fastgo/internal/pkg/contextx"
+	genericoptions "fastgo/pkg/options"
+	where "fastgo/pkg/store"
+	"gorm.io/gorm"
+)
+
+// EnableTenant 注册租户并在 db 上启用租户隔离.
+// 租户 ID 从上下文中获取, 上下文中没有租户时使用默认租户.
+// 启用后, 所有带有租户列的数据表的查询、更新、删除都只作用于当前租户, 创建记录时自动写入当前租户.
+func EnableTenant(db *gorm.DB, opts *genericoptions.TenantOptions) error {
+	where.RegisterTenant(opts.Key, func(ctx context.Context) string {
+		if tenantID := contextx.TenantID(ctx); tenantID != "" {
+			return tenantID
+		}
+		return opts.Default
+	})
+
+	return db.Use(where.NewTenantPlugin())
+}
@@ -0,0 +1,38 @@
+package apiserver
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	store2 "fastgo/internal/apiserver/store"
+)
+
+// trashPurgeInterval 为清理回收站的时间间隔.
+const trashPurgeInterval = time.Hour
+
+// purgeTrash 每隔 trashPurgeInterval 永久删除所有租户回收站中超过保留时间 retention 的用户和博客, 直到 ctx 被取消.
+func purgeTrash(ctx context.Context, store store2.IStore, retention time.Duration) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		before := time.Now().Add(-retention)
+		if purged, err := store.Post().Purge(ctx, before); err != nil {
+			slog.ErrorContext(ctx, "Failed to purge deleted posts", "err", err)
+		} else if purged > 0 {
+			slog.InfoContext(ctx, "Purged deleted posts", "count", purged, "before", before)
+		}
+		if purged, err := store.User().Purge(ctx, before); err != nil {
+			slog.ErrorContext(ctx, "Failed to purge deleted users", "err", err)
+		} else if purged > 0 {
+			slog.InfoContext(ctx, "Purged deleted users", "count", purged, "before", before)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package apiserver
+
+import (
+	userv1 "fastgo/internal/apiserver/biz/v1/user"
+	genericoptions "fastgo/pkg/options"
+)
+
+// newTwoFactorConfig 根据配置创建两步验证的配置.
+func newTwoFactorConfig(opts *genericoptions.TwoFactorOptions) userv1.TwoFactorConfig {
+	return userv1.TwoFactorConfig{
+		Issuer:              opts.Issuer,
+		ChallengeExpiration: opts.ChallengeExpiration,
+		RecoveryCodes:       opts.RecoveryCodes,
+		Skew:                opts.Skew,
+	}
+}
@@ -0,0 +1,81 @@
+// Package authz 实现了基于角色的访问控制(RBAC).
+//
+// 访问策略由一组声明式的规则组成, 每条规则表示哪些角色可以对某种资源执行某个操作,
+// 资源和操作均支持通配符 `*`. 未被任何规则允许的操作一律拒绝.
+//
+// 示例:
+//
+//	authorizer := authz.New(resolver,
+//		authz.Rule{Resource: "users", Verb: "list", Roles: []string{"admin"}},
+//		authz.Rule{Resource: "posts", Verb: authz.Any, Roles: []string{"admin", "user"}},
+//	)
+//	role, err := authorizer.Role(ctx, userID)
+//	allowed := err == nil && authorizer.Allowed(role, "users", "list")
+package authz
+
+import (
+	"context"
+)
+
+// Any 为通配符, 匹配任意资源或操作.
+const Any = "*"
+
+// Rule 是一条访问策略规则, 表示 Roles 中的角色可以对 Resource 执行 Verb 操作.
+type Rule struct {
+	// Resource 为资源名称, 例如 users、posts.
+	Resource string
+	// Verb 为操作名称, 例如 get、list、create、update、delete.
+	Verb string
+	// Roles 为允许执行该操作的角色.
+	Roles []string
+}
+
+// RoleResolver 用于查询用户的角色.
+type RoleResolver interface {
+	Role(ctx context.Context, userID string) (string, error)
+}
+
+// RoleResolverFunc 是函数形式的 RoleResolver.
+type RoleResolverFunc func(ctx context.Context, userID string) (string, error)
+
+// Role 调用 f 查询用户的角色.
+func (f RoleResolverFunc) Role(ctx context.Context, userID string) (string, error) {
+	return f(ctx, userID)
+}
+
+// Authorizer 根据访问策略判断用户是否可以执行某个操作.
+type Authorizer struct {
+	resolver RoleResolver
+	rules    []Rule
+}
+
+// New 创建一个 Authorizer, resolver 用于查询用户的角色, rules 为访问策略.
+func New(resolver RoleResolver, rules ...Rule) *Authorizer {
+	return &Authorizer{resolver: resolver, rules: rules}
+}
+
+// Role 查询用户的角色.
+// 每次请求都重新查询角色, 而不是将角色写入 token, 使角色变更立即生效.
+func (a *Authorizer) Role(ctx context.Context, userID string) (string, error) {
+	return a.resolver.Role(ctx, userID)
+}
+
+// Allowed 判断角色 role 是否可以对 resource 执行 verb 操作.
+func (a *Authorizer) Allowed(role string, resource string, verb string) bool {
+	for _, rule := range a.rules {
+		if !match(rule.Resource, resource) || !match(rule.Verb, verb) {
+			continue
+		}
+		for _, r := range rule.Roles {
+			if r == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// match 判断规则中的资源或操作 pattern 是否匹配 value.
+func match(pattern string, value string) bool {
+	return pattern == Any || pattern == value
+}
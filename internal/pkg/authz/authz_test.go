@@ -0,0 +1,58 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAllowed(t *testing.T) {
+	a := New(nil,
+		Rule{Resource: "users", Verb: "list", Roles: []string{"admin"}},
+		Rule{Resource: "users", Verb: "get", Roles: []string{"admin", "user"}},
+		Rule{Resource: "posts", Verb: Any, Roles: []string{"admin", "user"}},
+		Rule{Resource: Any, Verb: "delete", Roles: []string{"admin"}},
+	)
+
+	tests := []struct {
+		role     string
+		resource string
+		verb     string
+		want     bool
+	}{
+		{role: "admin", resource: "users", verb: "list", want: true},
+		{role: "user", resource: "users", verb: "list"},
+		{role: "user", resource: "users", verb: "get", want: true},
+		{role: "user", resource: "posts", verb: "create", want: true},
+		{role: "user", resource: "posts", verb: "delete", want: true},
+		{role: "admin", resource: "users", verb: "delete", want: true},
+		{role: "user", resource: "users", verb: "delete"},
+		{role: "admin", resource: "audit-logs", verb: "list"},
+		{role: "", resource: "posts", verb: "get"},
+		// 通配符只出现在规则中, 请求中的 `*` 只匹配字面值
+		{role: "user", resource: Any, verb: "list"},
+		{role: "Admin", resource: "users", verb: "list"},
+	}
+	for _, tt := range tests {
+		if got := a.Allowed(tt.role, tt.resource, tt.verb); got != tt.want {
+			t.Errorf("Allowed(%q, %q, %q) = %v, want %v", tt.role, tt.resource, tt.verb, got, tt.want)
+		}
+	}
+}
+
+func TestRole(t *testing.T) {
+	errNotFound := errors.New("not found")
+	a := New(RoleResolverFunc(func(ctx context.Context, userID string) (string, error) {
+		if userID == "root" {
+			return "admin", nil
+		}
+		return "", errNotFound
+	}))
+
+	if role, err := a.Role(context.Background(), "root"); role != "admin" || err != nil {
+		t.Errorf("Role(root) = %q, %v, want admin, nil", role, err)
+	}
+	if _, err := a.Role(context.Background(), "nobody"); !errors.Is(err, errNotFound) {
+		t.Errorf("Role(nobody) error = %v, want %v", err, errNotFound)
+	}
+}
@@ -1,6 +1,9 @@
 package contextx
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // 定义用于上下文的键
 type (
@@ -8,6 +11,18 @@ type (
 	requestIDKey struct{}
 	// userIDKey 定义用户 ID 的上下文键.
 	userIDKey struct{}
+	// tokenIDKey 定义 token 唯一标识(jti)的上下文键.
+	tokenIDKey struct{}
+	// tokenExpireAtKey 定义 token 过期时间的上下文键.
+	tokenExpireAtKey struct{}
+	// roleKey 定义用户角色的上下文键.
+	roleKey struct{}
+	// tenantIDKey 定义租户 ID 的上下文键.
+	tenantIDKey struct{}
+	// routeKey 定义请求匹配的路由的上下文键.
+	routeKey struct{}
+	// clientIPKey 定义客户端 IP 的上下文键.
+	clientIPKey struct{}
 )
 
 // 将请求ID存放到上下文中
@@ -31,3 +46,69 @@ func UserID(ctx context.Context) string {
 	userID, _ := ctx.Value(userIDKey{}).(string)
 	return userID
 }
+
+// 将 token 唯一标识(jti)存放到上下文中.
+func WithTokenID(ctx context.Context, tokenID string) context.Context {
+	return context.WithValue(ctx, tokenIDKey{}, tokenID)
+}
+
+// 从上下文中提取 token 唯一标识(jti).
+func TokenID(ctx context.Context) string {
+	tokenID, _ := ctx.Value(tokenIDKey{}).(string)
+	return tokenID
+}
+
+// 将 token 过期时间存放到上下文中.
+func WithTokenExpireAt(ctx context.Context, expireAt time.Time) context.Context {
+	return context.WithValue(ctx, tokenExpireAtKey{}, expireAt)
+}
+
+// 从上下文中提取 token 过期时间.
+func TokenExpireAt(ctx context.Context) time.Time {
+	expireAt, _ := ctx.Value(tokenExpireAtKey{}).(time.Time)
+	return expireAt
+}
+
+// 将用户角色存放到上下文中.
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// 从上下文中提取用户角色.
+func Role(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey{}).(string)
+	return role
+}
+
+// 将租户 ID 存放到上下文中.
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantIDKey{}, tenantID)
+}
+
+// 从上下文中提取租户 ID.
+func TenantID(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantIDKey{}).(string)
+	return tenantID
+}
+
+// 将请求匹配的路由(例如 `/v1/posts/:postID`)存放到上下文中.
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// 从上下文中提取请求匹配的路由.
+func Route(ctx context.Context) string {
+	route, _ := ctx.Value(routeKey{}).(string)
+	return route
+}
+
+// 将客户端 IP 存放到上下文中.
+func WithClientIP(ctx context.Context, clientIP string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, clientIP)
+}
+
+// 从上下文中提取客户端 IP.
+func ClientIP(ctx context.Context) string {
+	clientIP, _ := ctx.Value(clientIPKey{}).(string)
+	return clientIP
+}
@@ -13,6 +13,8 @@ type ErrorResponse struct {
 	Reason string `json:"reason,omitempty"`
 	// 错误详情的描述信息
 	Message string `json:"message,omitempty"`
+	// 错误相关的结构化信息
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // WriteResponse 是通用的响应函数.
@@ -22,8 +24,9 @@ func WriteResponse(c *gin.Context, err error, data any) {
 		// 如果发生错误,生成错误响应
 		errx := errorsx.FromError(err)
 		c.JSON(errx.Code, ErrorResponse{
-			Reason:  errx.Reason,
-			Message: errx.Message,
+			Reason:   errx.Reason,
+			Message:  errx.Message,
+			Metadata: errx.Metadata,
 		})
 		return
 	}
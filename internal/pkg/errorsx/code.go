@@ -28,6 +28,21 @@ var (
 	// ErrSignToken 表示签发 JWT Token 时出错.
 	ErrSignToken = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.SignToken", Message: "Error occurred while signing the JSON web token."}
 
+	// ErrPermissionDenied 表示请求没有操作目标资源的权限.
+	ErrPermissionDenied = &ErrorX{Code: http.StatusForbidden, Reason: "PermissionDenied", Message: "Permission denied. Access to the requested resource is forbidden."}
+
+	// ErrInsufficientRole 表示当前用户的角色不允许执行该操作.
+	ErrInsufficientRole = &ErrorX{Code: http.StatusForbidden, Reason: "PermissionDenied.InsufficientRole", Message: "The role of the current user is not allowed to perform this operation."}
+
+	// ErrVersionConflict 表示资源已被其他请求修改, 请求中的版本号(If-Match)已过期.
+	ErrVersionConflict = &ErrorX{Code: http.StatusPreconditionFailed, Reason: "PreconditionFailed.VersionConflict", Message: "The resource has been modified by another request, fetch the latest version and retry."}
+
+	// ErrTooManyRequests 表示请求过于频繁, 客户端需要在 Retry-After 之后重试.
+	ErrTooManyRequests = &ErrorX{Code: http.StatusTooManyRequests, Reason: "ResourceExhausted.TooManyRequests", Message: "Too many requests, please retry later."}
+
 	// ErrTokenInvalid 表示 JWT Token 格式无效.
 	ErrTokenInvalid = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.TokenInvalid", Message: "Token was invalid."}
+
+	// ErrRefreshTokenInvalid 表示 refresh token 不存在、已过期、已被轮换或已被吊销.
+	ErrRefreshTokenInvalid = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.RefreshTokenInvalid", Message: "Refresh token was invalid."}
 )
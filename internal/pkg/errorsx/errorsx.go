@@ -13,6 +13,8 @@ type ErrorX struct {
 	Reason string `json:"reason,omitempty"`
 	// Message 表示简短的错误信息，通常可直接暴露给用户查看.
 	Message string `json:"message,omitempty"`
+	// Metadata 用于存储与该错误相关的结构化信息, 例如校验失败的参数和表达式, 便于客户端定位问题.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // Error 实现 error 接口中的 `Error` 方法.
@@ -25,14 +27,35 @@ func New(code int, reason string, format string, args ...any) *ErrorX {
 	return &ErrorX{
 		Code:    code,
 		Reason:  reason,
-		Message: fmt.Sprintf(format, args),
+		Message: fmt.Sprintf(format, args...),
 	}
 }
 
-// WithMessage 设置错误的 Message 字段.
+// WithMessage 返回 Message 字段为指定内容的错误副本.
+// 不修改 err 本身, 预定义的错误被多个请求共享, 修改会导致数据竞争和错误信息串到其他请求中.
 func (err *ErrorX) WithMessage(format string, args ...any) *ErrorX {
-	err.Message = fmt.Sprintf(format, args)
-	return err
+	clone := err.clone()
+	clone.Message = fmt.Sprintf(format, args...)
+	return clone
+}
+
+// KV 返回以键值对的形式向 Metadata 中添加了结构化信息的错误副本, 同样不修改 err 本身.
+func (err *ErrorX) KV(kvs ...string) *ErrorX {
+	clone := err.clone()
+	for i := 0; i+1 < len(kvs); i += 2 {
+		clone.Metadata[kvs[i]] = kvs[i+1]
+	}
+	return clone
+}
+
+// clone 返回 err 的深拷贝.
+func (err *ErrorX) clone() *ErrorX {
+	clone := *err
+	clone.Metadata = make(map[string]string, len(err.Metadata))
+	for k, v := range err.Metadata {
+		clone.Metadata[k] = v
+	}
+	return &clone
 }
 
 // FromError 尝试将一个通用的 error 转换为自定义的 *ErrorX 类型.
@@ -49,5 +72,5 @@ func FromError(err error) *ErrorX {
 	}
 
 	// 默认返回未知错误错误. 该错误代表服务端出错
-	return New(ErrInternal.Code, ErrInternal.Reason, err.Error())
+	return New(ErrInternal.Code, ErrInternal.Reason, "%s", err.Error())
 }
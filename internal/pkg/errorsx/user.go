@@ -17,9 +17,57 @@ var (
 		Message: "Password is incorrect.",
 	}
 
+	// ErrPasswordPolicy 表示新密码不满足密码策略, Metadata 中的每一项对应一条不满足的规则.
+	ErrPasswordPolicy = &ErrorX{Code: http.StatusBadRequest, Reason: "InvalidArgument.PasswordPolicy", Message: "Password does not satisfy the password policy."}
+
 	// ErrUserAlreadyExists 表示用户已存在.
 	ErrUserAlreadyExists = &ErrorX{Code: http.StatusBadRequest, Reason: "AlreadyExist.UserAlreadyExists", Message: "User already exists."}
 
 	// ErrUserNotFound 表示未找到指定用户.
 	ErrUserNotFound = &ErrorX{Code: http.StatusNotFound, Reason: "NotFound.UserNotFound", Message: "User not found."}
+
+	// ErrLoginFailed 表示用户名或密码错误, 不区分用户不存在和密码错误, 避免用户名被枚举.
+	ErrLoginFailed = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.LoginFailed", Message: "Username or password is incorrect."}
+
+	// ErrAccountLocked 表示登录失败次数过多, 用户名或客户端 IP 被临时锁定.
+	ErrAccountLocked = &ErrorX{Code: http.StatusTooManyRequests, Reason: "ResourceExhausted.AccountLocked", Message: "Too many failed login attempts, please retry later."}
+
+	// ErrEmailNotVerified 表示要求验证邮箱时, 用户尚未验证邮箱.
+	ErrEmailNotVerified = &ErrorX{Code: http.StatusForbidden, Reason: "PermissionDenied.EmailNotVerified", Message: "Email address has not been verified."}
+
+	// ErrVerificationTokenInvalid 表示邮箱验证令牌无效、已过期或已被使用.
+	ErrVerificationTokenInvalid = &ErrorX{Code: http.StatusBadRequest, Reason: "InvalidArgument.VerificationTokenInvalid", Message: "Email verification link is invalid or has expired."}
+
+	// ErrPasswordResetTokenInvalid 表示重置密码令牌无效、已过期或已被使用.
+	ErrPasswordResetTokenInvalid = &ErrorX{Code: http.StatusBadRequest, Reason: "InvalidArgument.PasswordResetTokenInvalid", Message: "Password reset link is invalid or has expired."}
+
+	// ErrTwoFactorNotEnrolled 表示用户尚未绑定两步验证.
+	ErrTwoFactorNotEnrolled = &ErrorX{Code: http.StatusNotFound, Reason: "NotFound.TwoFactorNotEnrolled", Message: "Two-factor authentication has not been enrolled."}
+
+	// ErrTwoFactorAlreadyEnabled 表示用户已经启用了两步验证, 需要先关闭才能重新绑定.
+	ErrTwoFactorAlreadyEnabled = &ErrorX{Code: http.StatusBadRequest, Reason: "AlreadyExist.TwoFactorAlreadyEnabled", Message: "Two-factor authentication is already enabled."}
+
+	// ErrTwoFactorCodeInvalid 表示两步验证码或恢复码错误、已过期或已被使用.
+	ErrTwoFactorCodeInvalid = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.TwoFactorCodeInvalid", Message: "Two-factor authentication code is incorrect."}
+
+	// ErrChallengeTokenInvalid 表示两步验证的登录挑战令牌无效、已过期或已被使用.
+	ErrChallengeTokenInvalid = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.ChallengeTokenInvalid", Message: "Login challenge is invalid or has expired."}
+
+	// ErrOIDCProviderNotFound 表示未配置指定的 OIDC 身份提供方.
+	ErrOIDCProviderNotFound = &ErrorX{Code: http.StatusNotFound, Reason: "NotFound.OIDCProviderNotFound", Message: "OIDC provider not found."}
+
+	// ErrOIDCStateInvalid 表示 OIDC 回调中的 state 无效、已过期, 或者与发起登录的浏览器不匹配.
+	ErrOIDCStateInvalid = &ErrorX{Code: http.StatusBadRequest, Reason: "InvalidArgument.OIDCStateInvalid", Message: "OIDC login state is invalid or has expired."}
+
+	// ErrOIDCLoginFailed 表示身份提供方拒绝了授权, 或者授权码兑换、ID Token 校验失败.
+	ErrOIDCLoginFailed = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.OIDCLoginFailed", Message: "Failed to sign in with the identity provider."}
+
+	// ErrExternalIdentityNotLinked 表示外部身份没有绑定任何用户, 并且身份提供方没有开启自动创建用户.
+	ErrExternalIdentityNotLinked = &ErrorX{Code: http.StatusForbidden, Reason: "PermissionDenied.ExternalIdentityNotLinked", Message: "External identity is not linked to any user."}
+
+	// ErrExternalIdentityAlreadyLinked 表示外部身份已绑定其他用户, 或者用户已绑定该身份提供方中的其他身份.
+	ErrExternalIdentityAlreadyLinked = &ErrorX{Code: http.StatusBadRequest, Reason: "AlreadyExist.ExternalIdentityAlreadyLinked", Message: "External identity is already linked."}
+
+	// ErrExternalIdentityNotFound 表示用户没有绑定指定身份提供方中的身份.
+	ErrExternalIdentityNotFound = &ErrorX{Code: http.StatusNotFound, Reason: "NotFound.ExternalIdentityNotFound", Message: "External identity not found."}
 )
@@ -7,8 +7,36 @@ const (
 	// XUserID 用来定义上下文的键，代表请求用户 ID. UserID 整个用户生命周期唯一.
 	XUserID = "x-user-id"
 
+	// XTenantID 用来定义上下文的键，代表请求所属的租户 ID. 同时也是 token 中租户声明的键和默认的租户请求头.
+	XTenantID = "x-tenant-id"
+
 	// MaxErrGroupConcurrency 定义了 errgroup 的最大并发任务数量.
 	// 用于限制 errgroup 中同时执行的 Goroutine 数量，从而防止资源耗尽，提升程序的稳定性.
 	// 根据场景需求，可以调整该值大小.
 	MaxErrGroupConcurrency = 1000
+
+	// DefaultSearchLimit 定义了全文检索未指定每页数量时返回的结果数.
+	DefaultSearchLimit = 10
+)
+
+// 用户角色.
+const (
+	// RoleAdmin 表示管理员, 可以管理所有用户.
+	RoleAdmin = "admin"
+	// RoleUser 表示普通用户, 只能管理自己的资源. 新创建的用户默认为普通用户.
+	RoleUser = "user"
+)
+
+// 一次性操作令牌的用途, 签发和校验时使用, 不同用途的令牌不能混用.
+const (
+	// ActionVerifyEmail 表示验证邮箱.
+	ActionVerifyEmail = "verify-email"
+	// ActionResetPassword 表示重置密码.
+	ActionResetPassword = "reset-password"
+	// ActionTwoFactor 表示密码验证通过后, 使用两步验证码完成登录.
+	ActionTwoFactor = "two-factor"
+	// ActionOIDCLogin 表示使用外部身份提供方登录, 令牌作为 OIDC 授权请求的 state.
+	ActionOIDCLogin = "oidc-login"
+	// ActionOIDCLink 表示为当前用户绑定外部身份, 令牌作为 OIDC 授权请求的 state.
+	ActionOIDCLink = "oidc-link"
 )
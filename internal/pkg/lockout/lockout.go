@@ -0,0 +1,149 @@
+// Package lockout 实现了登录的暴力破解防护.
+//
+// 分别按照用户名和客户端 IP 记录连续登录失败的次数:
+//   - 每次失败后响应延迟按照失败次数指数增长, 降低在线猜测密码的速度;
+//   - 失败次数达到阈值后, 在一段时间内锁定该用户名或 IP, 锁定期间不再校验密码;
+//   - 登录成功后清除该用户名的失败记录, 管理员可以手动解锁用户名.
+//
+// 失败记录按照用户名而不是用户 ID 保存, 不存在的用户名同样会被记录和锁定, 避免通过锁定行为探测用户是否存在.
+// 超过 Window 没有再次失败的记录会被遗忘.
+//
+// 提供进程内的 memory 后端, 适用于单实例部署. 多实例部署需要共享失败记录时,
+// 可以基于 Redis 等共享存储实现 Store 接口, 并在 NewStore 中注册.
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BackendMemory 表示使用进程内存储失败记录.
+const BackendMemory = "memory"
+
+// Record 为一个用户名或 IP 的失败记录.
+type Record struct {
+	// Failures 为 Window 内连续失败的次数.
+	Failures int
+	// LastFailure 为最近一次失败的时间.
+	LastFailure time.Time
+	// LockedUntil 为锁定的截止时间, 未锁定时为零值.
+	LockedUntil time.Time
+}
+
+// Store 定义了失败记录的存储后端需要实现的方法.
+type Store interface {
+	// Get 返回 key 的失败记录, 没有记录时返回 nil.
+	Get(ctx context.Context, key string) (*Record, error)
+	// Fail 记录 key 的一次失败, 距离上次失败超过 window 时重新计数, 返回更新后的失败记录.
+	// 失败次数达到 threshold 时锁定 key 到 now + duration.
+	Fail(ctx context.Context, key string, window time.Duration, threshold int, duration time.Duration) (*Record, error)
+	// Reset 删除 key 的失败记录并解除锁定.
+	Reset(ctx context.Context, key string) error
+}
+
+// NewStore 根据 backend 创建失败记录的存储后端.
+func NewStore(backend string) (Store, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unsupported lockout backend: %s", backend)
+	}
+}
+
+// Policy 为暴力破解防护策略.
+type Policy struct {
+	// MaxFailures 为锁定用户名之前允许的连续失败次数.
+	MaxFailures int
+	// MaxIPFailures 为锁定客户端 IP 之前允许的连续失败次数, 应当大于 MaxFailures, 避免共享出口 IP 的用户被误锁.
+	MaxIPFailures int
+	// Window 为失败记录的有效期, 超过 Window 没有再次失败时重新计数.
+	Window time.Duration
+	// Duration 为锁定时长.
+	Duration time.Duration
+	// BaseDelay 为第一次失败后的响应延迟, 之后每次失败翻倍.
+	BaseDelay time.Duration
+	// MaxDelay 为响应延迟的上限.
+	MaxDelay time.Duration
+}
+
+// Guard 按照 Policy 记录登录失败并判断用户名和 IP 是否被锁定.
+type Guard struct {
+	store  Store
+	policy Policy
+}
+
+// New 创建一个 Guard.
+func New(store Store, policy Policy) *Guard {
+	return &Guard{store: store, policy: policy}
+}
+
+// Locked 返回用户名 username 和客户端 IP ip 中较长的剩余锁定时间, 都未被锁定时返回 0.
+func (g *Guard) Locked(ctx context.Context, username string, ip string) (time.Duration, error) {
+	var remaining time.Duration
+	now := time.Now()
+	for _, key := range g.keys(username, ip) {
+		r, err := g.store.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if r != nil && r.LockedUntil.After(now) {
+			remaining = max(remaining, r.LockedUntil.Sub(now))
+		}
+	}
+	return remaining, nil
+}
+
+// Fail 记录用户名 username 和客户端 IP ip 的一次登录失败, 返回本次失败的响应延迟.
+func (g *Guard) Fail(ctx context.Context, username string, ip string) (time.Duration, error) {
+	var failures int
+	for _, key := range g.keys(username, ip) {
+		threshold := g.policy.MaxFailures
+		if key != userKey(username) {
+			threshold = g.policy.MaxIPFailures
+		}
+		r, err := g.store.Fail(ctx, key, g.policy.Window, threshold, g.policy.Duration)
+		if err != nil {
+			return 0, err
+		}
+		failures = max(failures, r.Failures)
+	}
+	return g.delay(failures), nil
+}
+
+// Succeed 清除用户名 username 的失败记录.
+// 不清除客户端 IP 的失败记录, 否则攻击者可以用自己的账号登录来重置 IP 的失败次数.
+func (g *Guard) Succeed(ctx context.Context, username string) error {
+	return g.store.Reset(ctx, userKey(username))
+}
+
+// Unlock 解除用户名 username 的锁定并清除失败记录.
+func (g *Guard) Unlock(ctx context.Context, username string) error {
+	return g.store.Reset(ctx, userKey(username))
+}
+
+// delay 返回第 failures 次失败后的响应延迟.
+func (g *Guard) delay(failures int) time.Duration {
+	if failures < 1 || g.policy.BaseDelay <= 0 {
+		return 0
+	}
+	d := g.policy.BaseDelay
+	for i := 1; i < failures && d < g.policy.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, g.policy.MaxDelay)
+}
+
+// keys 返回用户名和客户端 IP 的失败记录键, ip 为空时只返回用户名的键.
+func (g *Guard) keys(username string, ip string) []string {
+	if ip == "" {
+		return []string{userKey(username)}
+	}
+	return []string{userKey(username), "ip:" + ip}
+}
+
+// userKey 返回用户名的失败记录键.
+func userKey(username string) string {
+	return "user:" + username
+}
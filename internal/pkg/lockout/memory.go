@@ -0,0 +1,98 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultSweepInterval 是内存后端清理过期失败记录的最小间隔.
+const defaultSweepInterval = time.Minute
+
+// entry 为内存后端保存的失败记录.
+type entry struct {
+	Record
+	// expiresAt 为记录的过期时间, 此后记录既不计数也不锁定, 可以被清理.
+	expiresAt time.Time
+}
+
+// memory 是 Store 的进程内实现, 过期的失败记录在清理时被删除.
+type memory struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+// 确保 memory 实现了 Store 接口.
+var _ Store = (*memory)(nil)
+
+// NewMemory 创建一个进程内的失败记录存储.
+func NewMemory() *memory {
+	return &memory{
+		entries:   make(map[string]*entry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Get 返回 key 的失败记录.
+func (m *memory) Get(ctx context.Context, key string) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok || !time.Now().Before(e.expiresAt) {
+		return nil, nil
+	}
+	r := e.Record
+	return &r, nil
+}
+
+// Fail 记录 key 的一次失败.
+func (m *memory) Fail(ctx context.Context, key string, window time.Duration, threshold int, duration time.Duration) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+
+	e, ok := m.entries[key]
+	if !ok || now.Sub(e.LastFailure) > window {
+		e = &entry{}
+		m.entries[key] = e
+	}
+	e.Failures++
+	e.LastFailure = now
+	if threshold > 0 && e.Failures >= threshold {
+		e.LockedUntil = now.Add(duration)
+	}
+	e.expiresAt = now.Add(window)
+	if e.LockedUntil.After(e.expiresAt) {
+		e.expiresAt = e.LockedUntil
+	}
+
+	r := e.Record
+	return &r, nil
+}
+
+// Reset 删除 key 的失败记录.
+func (m *memory) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// sweep 清理过期的失败记录, 调用方需要持有锁.
+func (m *memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < defaultSweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for key, e := range m.entries {
+		if !now.Before(e.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Package logx 提供了从 context 中提取请求信息的 slog.Handler.
+//
+// 使用 NewHandler 包装后, 每次调用 slog.InfoContext 等带有 context 的方法时,
+// 都会自动添加 context 中的请求 ID、用户 ID 和路由, 便于将同一个请求的日志关联起来:
+//
+//	slog.SetDefault(slog.New(logx.NewHandler(slog.NewJSONHandler(os.Stdout, nil))))
+//	slog.InfoContext(ctx, "Post created", "postID", postID)
+//	// {"time":"...","level":"INFO","msg":"Post created","postID":"post-xxx","requestID":"...","userID":"user-xxx","route":"/v1/posts"}
+//
+// context 中没有的字段不会输出, 因此不带 context 的 slog.Info 等方法与原来的输出一致.
+package logx
+
+import (
+	"context"
+	"log/slog"
+
+	"fastgo/internal/pkg/contextx"
+)
+
+// 从 context 中提取的日志字段名.
+const (
+	KeyRequestID = "requestID"
+	KeyUserID    = "userID"
+	KeyRoute     = "route"
+)
+
+// contextHandler 在每条日志中添加 context 中的请求信息.
+type contextHandler struct {
+	slog.Handler
+	// keys 为通过 WithAttrs 添加的字段名, 这些字段不再从 context 中重复添加
+	keys map[string]bool
+}
+
+// 确保 contextHandler 实现了 slog.Handler 接口.
+var _ slog.Handler = (*contextHandler)(nil)
+
+// NewHandler 包装 h, 返回的 Handler 在每条日志中添加 context 中的请求 ID、用户 ID 和路由.
+func NewHandler(h slog.Handler) slog.Handler {
+	return &contextHandler{Handler: h}
+}
+
+// Handle 添加 context 中的请求信息后交给被包装的 Handler 处理.
+// 日志中已经显式带有的同名字段(例如 "userID", userID)优先, 不会重复添加.
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	attrs := Attrs(ctx)
+	present := make(map[string]bool, len(h.keys)+r.NumAttrs())
+	for key := range h.keys {
+		present[key] = true
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		present[attr.Key] = true
+		return true
+	})
+	for _, attr := range attrs {
+		if !present[attr.Key] {
+			r.AddAttrs(attr)
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs 返回包装了 h.Handler.WithAttrs 的 Handler.
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	keys := make(map[string]bool, len(h.keys)+len(attrs))
+	for key := range h.keys {
+		keys[key] = true
+	}
+	for _, attr := range attrs {
+		keys[attr.Key] = true
+	}
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs), keys: keys}
+}
+
+// WithGroup 返回包装了 h.Handler.WithGroup 的 Handler.
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name), keys: h.keys}
+}
+
+// Attrs 返回 context 中不为空的请求 ID、用户 ID 和路由.
+func Attrs(ctx context.Context) []slog.Attr {
+	attrs := make([]slog.Attr, 0, 3)
+	if requestID := contextx.RequestID(ctx); requestID != "" {
+		attrs = append(attrs, slog.String(KeyRequestID, requestID))
+	}
+	if userID := contextx.UserID(ctx); userID != "" {
+		attrs = append(attrs, slog.String(KeyUserID, userID))
+	}
+	if route := contextx.Route(ctx); route != "" {
+		attrs = append(attrs, slog.String(KeyRoute, route))
+	}
+	return attrs
+}
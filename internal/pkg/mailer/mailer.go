@@ -0,0 +1,57 @@
+// Package mailer 实现了发送邮件的 Mailer 接口.
+//
+// 提供两种实现:
+//   - writer: 将邮件写入标准输出或文件, 适用于开发和测试环境, 无需部署邮件服务器;
+//   - smtp: 通过 SMTP 服务器发送邮件, 服务器支持时自动使用 STARTTLS 加密连接.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Message 为一封纯文本邮件.
+type Message struct {
+	// To 为收件人邮箱.
+	To string
+	// Subject 为邮件主题.
+	Subject string
+	// Body 为邮件正文.
+	Body string
+}
+
+// Mailer 定义了发送邮件需要实现的方法.
+type Mailer interface {
+	// Send 发送邮件 msg, 发件人由创建 Mailer 时指定.
+	Send(ctx context.Context, msg *Message) error
+}
+
+// format 将邮件编码为 RFC 5322 格式, 主题使用 RFC 2047 编码以支持非 ASCII 字符.
+func format(from string, msg *Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// validHeader 判断邮件头的值中没有换行符, 防止邮件头注入.
+func validHeader(values ...string) error {
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("mail header contains line break: %q", v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTPConfig 为 SMTP 服务器的配置.
+type SMTPConfig struct {
+	// Host 为 SMTP 服务器地址.
+	Host string
+	// Port 为 SMTP 服务器端口.
+	Port int
+	// Username 为认证用户名, 为空时不认证.
+	Username string
+	// Password 为认证密码.
+	Password string
+	// From 为发件人, 例如 `fastgo <noreply@example.com>`.
+	From string
+}
+
+// smtpMailer 通过 SMTP 服务器发送邮件.
+type smtpMailer struct {
+	addr string
+	// from 为邮件头中的发件人, sender 为 SMTP 信封中的发件人邮箱.
+	from   string
+	sender string
+	auth   smtp.Auth
+}
+
+var _ Mailer = (*smtpMailer)(nil)
+
+// NewSMTP 创建一个通过 SMTP 服务器发送邮件的 Mailer.
+// 服务器支持 STARTTLS 时自动加密连接; 使用 PLAIN 认证时, 除 localhost 外必须加密连接.
+func NewSMTP(cfg SMTPConfig) (Mailer, error) {
+	sender, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mail sender %q: %w", cfg.From, err)
+	}
+
+	m := &smtpMailer{addr: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)), from: cfg.From, sender: sender.Address}
+	if cfg.Username != "" {
+		m.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return m, nil
+}
+
+// Send 通过 SMTP 服务器发送邮件.
+// net/smtp 不支持 context, 发送超时由 SMTP 服务器的连接超时决定.
+func (m *smtpMailer) Send(ctx context.Context, msg *Message) error {
+	if err := validHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+	if err := smtp.SendMail(m.addr, m.auth, m.sender, []string{msg.To}, format(m.from, msg)); err != nil {
+		return fmt.Errorf("failed to send mail to %s: %w", msg.To, err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// writer 将邮件写入 io.Writer, 适用于开发和测试环境.
+type writer struct {
+	mu   sync.Mutex
+	from string
+	w    io.Writer
+}
+
+var _ Mailer = (*writer)(nil)
+
+// NewWriter 创建一个将邮件写入 w 的 Mailer, 每封邮件之间以空行分隔.
+func NewWriter(from string, w io.Writer) Mailer {
+	return &writer{from: from, w: w}
+}
+
+// NewFile 创建一个将邮件追加写入文件 path 的 Mailer.
+func NewFile(from string, path string) (Mailer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mail output: %w", err)
+	}
+	return NewWriter(from, f), nil
+}
+
+// Send 将邮件写入 io.Writer.
+func (m *writer) Send(ctx context.Context, msg *Message) error {
+	if err := validHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.w.Write(append(format(m.from, msg), '\r', '\n')); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Package metrics 定义了 Prometheus 监控指标, 并通过 Handler 以 Prometheus 文本格式暴露.
+//
+// 指标注册在包内独立的 Registry 中, 而不是 prometheus.DefaultRegisterer, 避免依赖库注册的指标混入.
+// 包含以下指标:
+//
+//   - fastgo_http_requests_total: HTTP 请求数, 按照请求方法、路由和状态码分类
+//   - fastgo_http_request_duration_seconds: HTTP 请求耗时分布, 按照请求方法、路由和状态码分类
+//   - fastgo_login_attempts_total: 登录次数, 按照结果(success、failure、challenge)分类
+//   - go_sql_*: 数据库连接池状态, 通过 RegisterDB 注册
+//   - go_*、process_*: Go 运行时和进程指标
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 为业务指标名称的前缀.
+const namespace = "fastgo"
+
+// 登录结果.
+const (
+	LoginSuccess   = "success"
+	LoginFailure   = "failure"
+	LoginChallenge = "challenge"
+)
+
+// registry 为所有指标所在的 Registry.
+var registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestsTotal 为 HTTP 请求数.
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration 为 HTTP 请求耗时分布.
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency in seconds by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// LoginAttempts 为登录次数.
+	LoginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_attempts_total",
+		Help:      "Total number of login attempts by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	registry.MustRegister(
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		LoginAttempts,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// RegisterDB 注册数据库连接池指标, 包括打开和空闲的连接数、等待连接的次数和总时长, name 用于区分不同的数据库.
+func RegisterDB(db *sql.DB, name string) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveLogin 记录一次登录, err 为 nil 时表示登录成功.
+func ObserveLogin(err error) {
+	result := LoginSuccess
+	if err != nil {
+		result = LoginFailure
+	}
+	LoginAttempts.WithLabelValues(result).Inc()
+}
+
+// ObserveLoginChallenge 记录一次需要两步验证的登录, 用户提交验证码后再通过 ObserveLogin 记录最终结果.
+func ObserveLoginChallenge() {
+	LoginAttempts.WithLabelValues(LoginChallenge).Inc()
+}
+
+// Handler 返回以 Prometheus 文本格式暴露所有指标的 http.Handler.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/logx"
+	genericoptions "fastgo/pkg/options"
+	"github.com/gin-gonic/gin"
+)
+
+// clfTimeFormat 为 Common Log Format 中的时间格式.
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLog 为访问日志中间件, 在请求处理完成后按照 format 将访问日志写入 w.
+// 访问日志包含请求方法、路径、状态码、耗时、响应字节数和客户端 IP, format 为 json 时还包含请求 ID、用户 ID 和路由.
+// 该中间件同时将请求匹配的路由注入上下文, 因此即使 format 为 none 也需要安装, 并且需要在 RequestID 之后使用.
+func AccessLog(format string, w io.Writer) gin.HandlerFunc {
+	logger := slog.New(logx.NewHandler(slog.NewJSONHandler(w, nil)))
+
+	return func(c *gin.Context) {
+		if route := c.FullPath(); route != "" {
+			c.Request = c.Request.WithContext(contextx.WithRoute(c.Request.Context(), route))
+		}
+
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+
+		// 认证中间件会替换 c.Request, 此时的上下文中包含用户 ID
+		ctx := c.Request.Context()
+		switch format {
+		case genericoptions.AccessLogFormatJSON:
+			logger.LogAttrs(ctx, slog.LevelInfo, "access",
+				slog.String("method", c.Request.Method),
+				slog.String("path", c.Request.URL.Path),
+				slog.Int("status", c.Writer.Status()),
+				slog.String("latency", latency.String()),
+				slog.Int("bytes", max(c.Writer.Size(), 0)),
+				slog.String("clientIP", c.ClientIP()),
+			)
+		case genericoptions.AccessLogFormatCLF:
+			_, _ = fmt.Fprintln(w, commonLogLine(ctx, c, start))
+		}
+	}
+}
+
+// commonLogLine 返回 Common Log Format 格式的访问日志, authuser 为用户 ID, 未认证的请求为 `-`.
+func commonLogLine(ctx context.Context, c *gin.Context, start time.Time) string {
+	user := contextx.UserID(ctx)
+	if user == "" {
+		user = "-"
+	}
+	bytes := "-"
+	if size := c.Writer.Size(); size > 0 {
+		bytes = strconv.Itoa(size)
+	}
+	return fmt.Sprintf(`%s - %s [%s] "%s %s %s" %d %s`,
+		c.ClientIP(), user, start.Format(clfTimeFormat),
+		c.Request.Method, c.Request.URL.RequestURI(), c.Request.Proto,
+		c.Writer.Status(), bytes)
+}
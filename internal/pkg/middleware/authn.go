@@ -4,24 +4,49 @@ import (
 	"fastgo/internal/pkg/contextx"
 	"fastgo/internal/pkg/core"
 	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/known"
+	"fastgo/internal/pkg/revocation"
 	"fastgo/pkg/token"
 	"github.com/gin-gonic/gin"
+	"log/slog"
 )
 
 // Authn 为认证中间件, 该函数将从 gin.Context 中提取 token 并验证是否合法.
-// 若 token 合法, 则从中解析出 userID 并将其注入上下文
-func Authn() gin.HandlerFunc {
+// 若 token 合法且未被吊销, 则从中解析出 userID 并将其注入上下文.
+// revoker 为 nil 时不检查吊销列表. token 中没有租户声明时使用默认租户 defaultTenant.
+func Authn(revoker revocation.Revoker, defaultTenant string) gin.HandlerFunc {
 	return func(context *gin.Context) {
 		// 解析 JWT Token
-		userID, err := token.ParseRequest(context)
+		claims, err := token.ParseRequestClaims(context)
 		if err != nil {
 			core.WriteResponse(context, errorsx.ErrTokenInvalid, nil)
 			context.Abort()
 			return
 		}
 
-		// 解析成功, 将用户ID和用户名注入上下文
-		ctx := contextx.WithUserID(context.Request.Context(), userID)
+		// 查询吊销列表, 查询失败时按照 token 无效处理
+		if revoker != nil {
+			revoked, err := revoker.IsRevoked(context.Request.Context(), claims.ID, claims.Identity, claims.IssuedAt)
+			if err != nil {
+				slog.ErrorContext(context.Request.Context(), "Failed to check token revocation", "err", err)
+			}
+			if err != nil || revoked {
+				core.WriteResponse(context, errorsx.ErrTokenInvalid, nil)
+				context.Abort()
+				return
+			}
+		}
+
+		// 解析成功, 将用户ID和 token 信息注入上下文
+		ctx := contextx.WithUserID(context.Request.Context(), claims.Identity)
+		ctx = contextx.WithTokenID(ctx, claims.ID)
+		ctx = contextx.WithTokenExpireAt(ctx, claims.ExpiresAt)
+		// 已认证的请求以 token 中的租户为准, 始终忽略请求头中的租户
+		tenantID, _ := claims.Extra[known.XTenantID].(string)
+		if tenantID == "" {
+			tenantID = defaultTenant
+		}
+		ctx = contextx.WithTenantID(ctx, tenantID)
 		context.Request = context.Request.WithContext(ctx)
 
 		// 继续执行主线程
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/known"
+	"fastgo/pkg/token"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthnTenant(t *testing.T) {
+	withTenant, _, err := token.SignWithClaims("user-a", map[string]any{known.XTenantID: "acme"})
+	if err != nil {
+		t.Fatalf("SignWithClaims() error = %v", err)
+	}
+	withoutTenant, _, err := token.Sign("user-a")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   string
+	}{
+		{name: "tenant claim", token: withTenant, want: "acme"},
+		{name: "header ignored", token: withTenant, header: "umbrella", want: "acme"},
+		{name: "default tenant", token: withoutTenant, want: "default"},
+		{name: "header ignored without tenant claim", token: withoutTenant, header: "umbrella", want: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			engine := gin.New()
+			engine.Use(Tenant("X-Tenant-ID"), Authn(nil, "default"))
+			engine.GET("/", func(c *gin.Context) { got = contextx.TenantID(c.Request.Context()) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got != tt.want {
+				t.Errorf("TenantID = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"fastgo/internal/pkg/authz"
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/core"
+	"fastgo/internal/pkg/errorsx"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+)
+
+// Authz 为授权中间件, 必须在 Authn 之后使用.
+// 该函数查询当前用户的角色并注入上下文, 然后根据访问策略判断该角色是否可以对 resource 执行 verb 操作.
+func Authz(authorizer *authz.Authorizer, resource string, verb string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		role, err := authorizer.Role(ctx, contextx.UserID(ctx))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to resolve user role", "err", err)
+			core.WriteResponse(c, errorsx.ErrInsufficientRole, nil)
+			c.Abort()
+			return
+		}
+
+		if !authorizer.Allowed(role, resource, verb) {
+			slog.WarnContext(ctx, "Permission denied", "userID", contextx.UserID(ctx), "role", role, "resource", resource, "verb", verb)
+			core.WriteResponse(c, errorsx.ErrInsufficientRole, nil)
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(contextx.WithRole(ctx, role))
+		c.Next()
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"fastgo/internal/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute 为没有匹配到路由的请求使用的路由标签, 避免使用原始路径导致标签基数过高.
+const unmatchedRoute = "unmatched"
+
+// Metrics 为监控指标中间件, 在请求处理完成后按照请求方法、路由和状态码记录请求数和耗时.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequestsTotal.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
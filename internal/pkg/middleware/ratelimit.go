@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/core"
+	"fastgo/internal/pkg/errorsx"
+	"fastgo/internal/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// 限流相关的响应头.
+const (
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+	headerRetryAfter         = "Retry-After"
+)
+
+// RateLimitKeyFunc 返回请求的限流键, 限流键相同的请求共享一个令牌桶.
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// KeyByIP 按照客户端 IP 限流.
+// 只有来自受信任代理(gin.Engine.SetTrustedProxies)的请求才使用 X-Forwarded-For 等请求头中的客户端 IP.
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser 按照用户 ID 限流, 需要在 Authn 之后使用, 未认证的请求按照客户端 IP 限流.
+func KeyByUser(c *gin.Context) string {
+	if userID := contextx.UserID(c.Request.Context()); userID != "" {
+		return "user:" + userID
+	}
+	return KeyByIP(c)
+}
+
+// KeyByAPIKey 按照请求头 header 中的 API Key 限流, apiKeys 为已签发的 API Key 的 SHA-256 哈希(十六进制).
+// 只有 apiKeys 中的 API Key 使用单独的令牌桶, 没有 API Key 或者 API Key 未知的请求按照客户端 IP 限流,
+// 避免客户端每次请求携带不同的 API Key 绕过限流.
+// 限流键中保存的是 API Key 的哈希, 避免共享存储中出现明文的 API Key.
+func KeyByAPIKey(header string, apiKeys []string) RateLimitKeyFunc {
+	known := make(map[string]bool, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		known[strings.ToLower(apiKey)] = true
+	}
+
+	return func(c *gin.Context) string {
+		if apiKey := c.Request.Header.Get(header); apiKey != "" {
+			sum := sha256.Sum256([]byte(apiKey))
+			if hash := hex.EncodeToString(sum[:]); known[hash] {
+				return "api-key:" + hash
+			}
+		}
+		return KeyByIP(c)
+	}
+}
+
+// RateLimit 为限流中间件, 按照 rule 对路由分组 group 中限流键相同的请求限流.
+// 响应中带有 X-RateLimit-* 响应头, 请求被拒绝时返回 429 和 Retry-After 响应头.
+// 限流后端出错时不拒绝请求, 避免限流后端故障导致服务不可用.
+func RateLimit(limiter ratelimit.Limiter, group string, rule ratelimit.Rule, key RateLimitKeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		result, err := limiter.Allow(ctx, group+":"+key(c), rule)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to check rate limit", "group", group, "err", err)
+			c.Next()
+			return
+		}
+
+		c.Header(headerRateLimitLimit, strconv.Itoa(result.Limit))
+		c.Header(headerRateLimitRemaining, strconv.Itoa(result.Remaining))
+		c.Header(headerRateLimitReset, ceilSeconds(result.ResetAfter))
+		if !result.Allowed {
+			slog.WarnContext(ctx, "Rate limit exceeded", "group", group, "clientIP", c.ClientIP())
+			c.Header(headerRetryAfter, ceilSeconds(result.RetryAfter))
+			core.WriteResponse(c, errorsx.ErrTooManyRequests, nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ceilSeconds 返回向上取整的秒数.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
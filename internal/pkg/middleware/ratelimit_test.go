@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newKeyContext 创建一个来自 remoteAddr 的请求上下文, trustedProxies 为受信任的反向代理.
+func newKeyContext(t *testing.T, remoteAddr string, trustedProxies []string, header http.Header) *gin.Context {
+	t.Helper()
+
+	c, engine := gin.CreateTestContext(httptest.NewRecorder())
+	if err := engine.SetTrustedProxies(trustedProxies); err != nil {
+		t.Fatalf("SetTrustedProxies() error = %v", err)
+	}
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.RemoteAddr = remoteAddr
+	c.Request.Header = header
+	return c
+}
+
+func TestKeyByIP(t *testing.T) {
+	spoofed := http.Header{"X-Forwarded-For": {"1.2.3.4"}}
+
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		trustedProxies []string
+		header         http.Header
+		want           string
+	}{
+		{name: "no forwarded header", remoteAddr: "10.0.0.1:1234", want: "ip:10.0.0.1"},
+		{name: "untrusted forwarded header", remoteAddr: "10.0.0.1:1234", header: spoofed, want: "ip:10.0.0.1"},
+		{name: "forwarded by trusted proxy", remoteAddr: "10.0.0.1:1234", trustedProxies: []string{"10.0.0.0/8"}, header: spoofed, want: "ip:1.2.3.4"},
+		{name: "forwarded by other proxy", remoteAddr: "192.168.0.1:1234", trustedProxies: []string{"10.0.0.0/8"}, header: spoofed, want: "ip:192.168.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+			if got := KeyByIP(newKeyContext(t, tt.remoteAddr, tt.trustedProxies, header)); got != tt.want {
+				t.Errorf("KeyByIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyByAPIKey(t *testing.T) {
+	sum := sha256.Sum256([]byte("issued-key"))
+	hash := hex.EncodeToString(sum[:])
+	key := KeyByAPIKey("X-API-Key", []string{hash})
+
+	tests := []struct {
+		name   string
+		apiKey string
+		want   string
+	}{
+		{name: "issued api key", apiKey: "issued-key", want: "api-key:" + hash},
+		{name: "unknown api key", apiKey: "random-key", want: "ip:10.0.0.1"},
+		{name: "no api key", want: "ip:10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.apiKey != "" {
+				header.Set("X-API-Key", tt.apiKey)
+			}
+			if got := key(newKeyContext(t, "10.0.0.1:1234", nil, header)); got != tt.want {
+				t.Errorf("KeyByAPIKey() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
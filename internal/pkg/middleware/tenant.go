@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"fastgo/internal/pkg/contextx"
+	"github.com/gin-gonic/gin"
+)
+
+// Tenant 从请求头 header 中获取租户 ID 并注入上下文.
+// 未认证的请求(例如注册、登录)通过请求头指定租户; 已认证的请求由 Authn 使用 token 中的租户(没有时为默认租户)覆盖.
+func Tenant(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tenantID := c.Request.Header.Get(header); tenantID != "" {
+			ctx := contextx.WithTenantID(c.Request.Context(), tenantID)
+			c.Request = c.Request.WithContext(ctx)
+		}
+
+		c.Next()
+	}
+}
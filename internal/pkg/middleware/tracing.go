@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"fastgo/internal/pkg/contextx"
+	"fastgo/internal/pkg/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing 为链路追踪中间件, 为每个请求创建一个 server span, 后续的 biz 和 store 的 span 都是它的子 span.
+// 请求头中带有 W3C traceparent 时, 该 span 加入上游的链路.
+// span 中记录请求 ID, 因此需要在 RequestID 之后使用.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		name := c.Request.Method + " " + route
+		if route == "" {
+			name = c.Request.Method + " " + unmatchedRoute
+		}
+		ctx, span := tracing.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+		span.SetAttributes(
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(c.Request.URL.Path),
+			semconv.ClientAddress(c.ClientIP()),
+			tracing.KeyRequestID.String(contextx.RequestID(ctx)),
+		)
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,428 @@
+// Package oidc 实现了 OpenID Connect 授权码流程(带 PKCE)的客户端(Relying Party).
+//
+// Provider 在第一次使用时通过 `/.well-known/openid-configuration` 获取身份提供方的元数据并缓存,
+// 因此身份提供方暂时不可用时不影响服务启动. ID Token 使用身份提供方 JWKS 中的公钥校验签名,
+// 遇到未知的 kid 时重新获取 JWKS, 以支持身份提供方轮换密钥.
+//
+// 一次登录的流程:
+//
+//	verifier, _ := oidc.NewVerifier()
+//	nonce, _ := oidc.NewNonce()
+//	authURL, _ := provider.AuthCodeURL(ctx, state, nonce, oidc.Challenge(verifier))
+//	// 用户在身份提供方登录后, 浏览器携带 code 和 state 重定向回 RedirectURL
+//	identity, _ := provider.Exchange(ctx, code, verifier, nonce)
+//
+// oidctest 子包提供了进程内的模拟身份提供方, 用于测试.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+// DefaultScopes 为未配置 Scopes 时请求的权限范围.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// jwksRefreshInterval 为遇到未知 kid 时重新获取 JWKS 的最短间隔, 避免伪造的 kid 导致频繁请求身份提供方.
+const jwksRefreshInterval = time.Minute
+
+// ErrInvalidIDToken 表示 ID Token 签名错误、已过期, 或者签发者、受众、nonce 不符.
+var ErrInvalidIDToken = errors.New("oidc: invalid id token")
+
+// Config 为 OIDC 客户端的配置.
+type Config struct {
+	// Issuer 为身份提供方的标识, 元数据地址为 Issuer + `/.well-known/openid-configuration`.
+	Issuer string
+	// ClientID 为在身份提供方注册的客户端 ID.
+	ClientID string
+	// ClientSecret 为客户端密钥, 为空时作为公共客户端, 只依靠 PKCE 保护授权码.
+	ClientSecret string
+	// RedirectURL 为在身份提供方注册的回调地址.
+	RedirectURL string
+	// Scopes 为请求的权限范围, 必须包含 openid, 为空时使用 DefaultScopes.
+	Scopes []string
+	// HTTPClient 为请求身份提供方使用的 HTTP 客户端, 为空时使用超时为 10 秒的客户端.
+	HTTPClient *http.Client
+}
+
+// Identity 为从 ID Token 中解析出的用户身份.
+type Identity struct {
+	// Subject 为用户在身份提供方中的唯一标识, 同一身份提供方中不会变化.
+	Subject string
+	// Email 为用户的邮箱地址, 身份提供方没有返回时为空.
+	Email string
+	// EmailVerified 表示身份提供方是否已验证该邮箱.
+	EmailVerified bool
+	// Name 为用户的显示名称.
+	Name string
+	// PreferredUsername 为用户希望使用的用户名, 不保证唯一.
+	PreferredUsername string
+}
+
+// metadata 为身份提供方的元数据, 只包含授权码流程需要的字段.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider 为一个 OIDC 身份提供方的客户端, 并发安全.
+type Provider struct {
+	cfg Config
+
+	mu            sync.Mutex
+	meta          *metadata
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// NewProvider 创建一个 OIDC 身份提供方的客户端, 不会请求身份提供方.
+func NewProvider(cfg Config) (*Provider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("oidc: issuer, client id and redirect url are required")
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = DefaultScopes
+	}
+	hasOpenID := false
+	for _, scope := range cfg.Scopes {
+		hasOpenID = hasOpenID || scope == "openid"
+	}
+	if !hasOpenID {
+		return nil, errors.New("oidc: scopes must include openid")
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &Provider{cfg: cfg}, nil
+}
+
+// NewVerifier 生成一个随机的 PKCE code_verifier.
+func NewVerifier() (string, error) {
+	return randomString(32)
+}
+
+// NewNonce 生成一个随机的 nonce, 用于将 ID Token 与本次登录绑定, 防止重放.
+func NewNonce() (string, error) {
+	return randomString(16)
+}
+
+// Challenge 返回 code_verifier 对应的 S256 code_challenge.
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL 返回引导用户登录的身份提供方授权地址.
+// state 由调用方生成, 回调时原样返回; challenge 为 Challenge(verifier) 的结果.
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, challenge string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("oidc: invalid authorization endpoint: %w", err)
+	}
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", challenge)
+	query.Set("code_challenge_method", "S256")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Exchange 使用授权码 code 和 PKCE code_verifier 换取 ID Token, 校验后返回其中的用户身份.
+// nonce 必须与 AuthCodeURL 中使用的 nonce 一致.
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string, nonce string) (*Identity, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.cfg.ClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic, RFC 6749 要求先对客户端 ID 和密钥进行 URL 编码
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var resp struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.do(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || resp.Error != "" {
+		return nil, fmt.Errorf("oidc: token request failed with status %d: %s %s", status, resp.Error, resp.ErrorDescription)
+	}
+	if resp.IDToken == "" {
+		return nil, errors.New("oidc: token response does not contain an id token")
+	}
+	return p.verify(ctx, meta, resp.IDToken, nonce)
+}
+
+// verify 校验 ID Token 的签名、签发者、受众、有效期和 nonce, 返回其中的用户身份.
+func (p *Provider) verify(ctx context.Context, meta *metadata, rawIDToken string, nonce string) (*Identity, error) {
+	var claims struct {
+		jwt.RegisteredClaims
+		Nonce             string `json:"nonce"`
+		AuthorizedParty   string `json:"azp"`
+		Email             string `json:"email"`
+		EmailVerified     any    `json:"email_verified"`
+		Name              string `json:"name"`
+		PreferredUsername string `json:"preferred_username"`
+	}
+	_, err := jwt.ParseWithClaims(rawIDToken, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := p.key(ctx, meta, kid)
+		if err != nil {
+			return nil, err
+		}
+		// 确保签名算法与公钥类型一致, 防止算法混淆攻击
+		switch key.(type) {
+		case *rsa.PublicKey:
+			if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+				return nil, ErrInvalidIDToken
+			}
+		case *ecdsa.PublicKey:
+			if _, ok := t.Method.(*jwt.SigningMethodECDSA); !ok {
+				return nil, ErrInvalidIDToken
+			}
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	// jwt 已校验 exp、iat 和 nbf, 其余声明需要手动校验
+	if claims.Issuer != meta.Issuer || claims.Subject == "" || claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: unexpected issuer or subject", ErrInvalidIDToken)
+	}
+	if !claims.VerifyAudience(p.cfg.ClientID, true) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidIDToken)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: unexpected authorized party", ErrInvalidIDToken)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	// 部分身份提供方将 email_verified 返回为字符串
+	verified := claims.EmailVerified == true || claims.EmailVerified == "true"
+	return &Identity{
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     verified,
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+// metadata 返回身份提供方的元数据, 第一次调用时从身份提供方获取, 获取失败时下次调用重新获取.
+func (p *Provider) metadata(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var meta metadata
+	status, err := p.do(req, &meta)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("oidc: discovery failed with status %d", status)
+	}
+	// OpenID Connect Discovery 要求元数据中的 issuer 与请求的 issuer 完全一致
+	if strings.TrimSuffix(meta.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: issuer mismatch, expected %q, got %q", p.cfg.Issuer, meta.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document is missing required endpoints")
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// key 返回 kid 对应的公钥, 公钥不在缓存中时重新获取 JWKS.
+func (p *Provider) key(ctx context.Context, meta *metadata, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("oidc: unknown key id %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	status, err := p.do(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("oidc: jwks request failed with status %d", status)
+	}
+
+	p.keys = make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		// 忽略不支持的密钥和加密用的密钥
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if pub, err := k.publicKey(); err == nil {
+			p.keys[k.Kid] = pub
+		}
+	}
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("oidc: unknown key id %q", kid)
+}
+
+// lookup 在缓存的公钥中查找 kid 对应的公钥, kid 为空且只有一个公钥时返回该公钥.
+func (p *Provider) lookup(kid string) (crypto.PublicKey, bool) {
+	if key, ok := p.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// do 发送请求并将 JSON 响应解码到 v 中, 返回响应状态码.
+func (p *Provider) do(req *http.Request, v any) (int, error) {
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("oidc: request to %s failed: %w", req.URL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("oidc: invalid response from %s: %w", req.URL.Redacted(), err)
+	}
+	return resp.StatusCode, nil
+}
+
+// jwk 为 JWKS 中的一个公钥, 支持 RSA 和 EC(P-256、P-384、P-521) 公钥.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey 将 JWK 转换为公钥.
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("oidc: unsupported key type %q", k.Kty)
+	}
+}
+
+// randomString 返回 n 个随机字节的 base64url 编码.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
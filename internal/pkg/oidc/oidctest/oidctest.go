@@ -0,0 +1,257 @@
+// Package oidctest 提供了一个进程内的 OpenID Connect 模拟身份提供方, 用于在不依赖外部身份提供方的情况下测试 OIDC 登录.
+//
+// 模拟身份提供方实现了元数据、授权、令牌和 JWKS 接口. 授权接口不展示登录页面,
+// 而是直接以 SetUser 设置的用户身份签发授权码并重定向回客户端; 令牌接口校验客户端密钥、回调地址和 PKCE,
+// 并签发 RS256 签名的 ID Token.
+//
+// 示例:
+//
+//	idp := oidctest.NewServer("fastgo", "secret")
+//	defer idp.Close()
+//	idp.SetUser(oidctest.User{Subject: "alice", Email: "alice@example.com", EmailVerified: true})
+//	provider, _ := oidc.NewProvider(oidc.Config{Issuer: idp.Issuer(), ClientID: "fastgo", ClientSecret: "secret", RedirectURL: callbackURL})
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+// keyID 为签发 ID Token 使用的密钥标识.
+const keyID = "oidctest"
+
+// User 为模拟身份提供方中登录的用户身份.
+type User struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// grant 为一个尚未兑换的授权码.
+type grant struct {
+	user        User
+	redirectURI string
+	nonce       string
+	challenge   string
+}
+
+// Server 为进程内的模拟身份提供方.
+type Server struct {
+	*httptest.Server
+
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+
+	mu     sync.Mutex
+	user   User
+	claims map[string]any
+	grants map[string]*grant
+}
+
+// NewServer 创建并启动一个模拟身份提供方, 只接受 clientID 和 clientSecret 对应的客户端.
+// clientSecret 为空时作为公共客户端, 不校验客户端密钥.
+func NewServer(clientID string, clientSecret string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic("oidctest: failed to generate key: " + err.Error())
+	}
+
+	s := &Server{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		key:          key,
+		user:         User{Subject: "oidctest-user", Email: "user@example.com", EmailVerified: true, Name: "Test User", PreferredUsername: "testuser"},
+		grants:       map[string]*grant{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("GET /authorize", s.authorize)
+	mux.HandleFunc("POST /token", s.token)
+	mux.HandleFunc("GET /jwks", s.jwks)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Issuer 返回模拟身份提供方的 issuer.
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// SetUser 设置之后的授权请求中登录的用户身份.
+func (s *Server) SetUser(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = user
+}
+
+// SetClaims 设置之后签发的 ID Token 中覆盖的声明, 例如 aud、azp 或 nonce, 用于测试客户端对异常 ID Token 的校验.
+// 值为 nil 的声明从 ID Token 中删除, claims 为 nil 时恢复默认的声明.
+func (s *Server) SetClaims(claims map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claims = claims
+}
+
+// discovery 返回身份提供方的元数据.
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorize 以当前用户身份签发授权码, 并重定向回客户端的回调地址.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirectURI.Scheme == "" || query.Get("client_id") != s.clientID {
+		http.Error(w, "invalid client or redirect uri", http.StatusBadRequest)
+		return
+	}
+	if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		redirect(w, r, redirectURI, url.Values{"error": {"invalid_request"}, "state": {query.Get("state")}})
+		return
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.grants[code] = &grant{user: s.user, redirectURI: redirectURI.String(), nonce: query.Get("nonce"), challenge: query.Get("code_challenge")}
+	s.mu.Unlock()
+	redirect(w, r, redirectURI, url.Values{"code": {code}, "state": {query.Get("state")}})
+}
+
+// token 校验客户端、授权码和 PKCE, 签发 ID Token. 每个授权码只能使用一次.
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if !s.authenticate(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	g, ok := s.grants[r.PostForm.Get("code")]
+	delete(s.grants, r.PostForm.Get("code"))
+	overrides := s.claims
+	s.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	if r.PostForm.Get("grant_type") != "authorization_code" || !ok || g.redirectURI != r.PostForm.Get("redirect_uri") || g.challenge != challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                s.URL,
+		"sub":                g.user.Subject,
+		"aud":                s.clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              g.nonce,
+		"email":              g.user.Email,
+		"email_verified":     g.user.EmailVerified,
+		"name":               g.user.Name,
+		"preferred_username": g.user.PreferredUsername,
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+			continue
+		}
+		claims[k] = v
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = keyID
+	signed, err := idToken.SignedString(s.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+// authenticate 校验客户端身份, 支持 client_secret_basic 和 client_secret_post.
+func (s *Server) authenticate(r *http.Request) bool {
+	clientID, secret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != s.clientID {
+		return false
+	}
+	return s.clientSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(s.clientSecret)) == 1
+}
+
+// jwks 返回校验 ID Token 使用的公钥.
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// redirect 将 params 追加到 target 的查询参数中并重定向.
+func redirect(w http.ResponseWriter, r *http.Request, target *url.URL, params url.Values) {
+	query := target.Query()
+	for k, v := range params {
+		query[k] = v
+	}
+	u := *target
+	u.RawQuery = query.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// writeJSON 以 JSON 格式写入响应.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// randomString 返回一个随机字符串, 用作授权码和访问令牌.
+func randomString() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
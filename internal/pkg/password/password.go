@@ -0,0 +1,173 @@
+// Package password 实现了可配置的密码策略.
+//
+// 密码策略由一组 Rule 组成, Check 返回所有不满足的规则, 便于一次性告知用户密码需要满足的全部要求.
+// 内置的规则包括长度、字符类型、不能包含用户名以及常见或已泄露的密码列表, 也可以实现 Rule 接口添加自定义规则.
+//
+// 历史密码需要与数据库中保存的密码哈希比较, 不属于 Rule, 由 BIZ 层根据 Policy.History 校验.
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Violation 为一条不满足的密码规则.
+type Violation struct {
+	// Rule 为规则名称.
+	Rule string
+	// Message 为规则的描述, 可以直接展示给用户.
+	Message string
+}
+
+// Rule 定义了密码规则需要实现的方法.
+type Rule interface {
+	// Name 返回规则名称, 同一个 Policy 中的规则名称不能重复.
+	Name() string
+	// Check 校验用户 username 的密码 password, 满足规则时返回空字符串, 否则返回规则的描述.
+	Check(password string, username string) string
+}
+
+// Policy 为密码策略.
+type Policy struct {
+	// Rules 为密码需要满足的规则.
+	Rules []Rule
+	// History 为不能重复使用的最近密码个数(包括当前密码), 0 表示不限制.
+	History int
+}
+
+// Check 校验用户 username 的密码 password, 返回所有不满足的规则.
+func (p *Policy) Check(password string, username string) []Violation {
+	var violations []Violation
+	for _, rule := range p.Rules {
+		if msg := rule.Check(password, username); msg != "" {
+			violations = append(violations, Violation{Rule: rule.Name(), Message: msg})
+		}
+	}
+	return violations
+}
+
+// length 限制密码的字符数.
+type length struct {
+	min int
+	max int
+}
+
+// Length 返回限制密码字符数在 [minimum, maximum] 之间的规则.
+func Length(minimum int, maximum int) Rule {
+	return &length{min: minimum, max: maximum}
+}
+
+func (r *length) Name() string { return "length" }
+
+func (r *length) Check(password string, username string) string {
+	if n := utf8.RuneCountInString(password); n < r.min || n > r.max {
+		return fmt.Sprintf("Password must be between %d and %d characters", r.min, r.max)
+	}
+	return ""
+}
+
+// characterClass 要求密码至少包含一个某种类型的字符.
+type characterClass struct {
+	name    string
+	message string
+	match   func(r rune) bool
+}
+
+// RequireUpper 返回要求密码包含大写字母的规则.
+func RequireUpper() Rule {
+	return &characterClass{name: "uppercase", message: "Password must contain at least one uppercase letter", match: unicode.IsUpper}
+}
+
+// RequireLower 返回要求密码包含小写字母的规则.
+func RequireLower() Rule {
+	return &characterClass{name: "lowercase", message: "Password must contain at least one lowercase letter", match: unicode.IsLower}
+}
+
+// RequireDigit 返回要求密码包含数字的规则.
+func RequireDigit() Rule {
+	return &characterClass{name: "digit", message: "Password must contain at least one digit", match: unicode.IsDigit}
+}
+
+// RequireSymbol 返回要求密码包含特殊字符(标点符号或符号)的规则.
+func RequireSymbol() Rule {
+	return &characterClass{name: "symbol", message: "Password must contain at least one symbol", match: func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	}}
+}
+
+func (r *characterClass) Name() string { return r.name }
+
+func (r *characterClass) Check(password string, username string) string {
+	if strings.IndexFunc(password, r.match) < 0 {
+		return r.message
+	}
+	return ""
+}
+
+// notContainUsername 禁止密码中包含用户名.
+type notContainUsername struct{}
+
+// NotContainUsername 返回禁止密码中包含用户名(不区分大小写)的规则.
+func NotContainUsername() Rule {
+	return notContainUsername{}
+}
+
+func (notContainUsername) Name() string { return "username" }
+
+func (notContainUsername) Check(password string, username string) string {
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return "Password must not contain the username"
+	}
+	return ""
+}
+
+// blocklist 禁止使用常见或已泄露的密码.
+type blocklist struct {
+	passwords map[string]struct{}
+}
+
+// Blocklist 返回禁止使用 passwords 中任意一个密码(不区分大小写)的规则.
+func Blocklist(passwords []string) Rule {
+	r := &blocklist{passwords: make(map[string]struct{}, len(passwords))}
+	for _, p := range passwords {
+		r.passwords[strings.ToLower(p)] = struct{}{}
+	}
+	return r
+}
+
+// LoadBlocklist 从文件 path 中读取常见或已泄露的密码列表, 返回禁止使用这些密码的规则.
+// 文件中每行一个密码, 忽略空行和以 `#` 开头的注释行.
+func LoadBlocklist(path string) (Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open password blocklist: %w", err)
+	}
+	defer f.Close()
+
+	var passwords []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords = append(passwords, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read password blocklist: %w", err)
+	}
+	return Blocklist(passwords), nil
+}
+
+func (r *blocklist) Name() string { return "breached" }
+
+func (r *blocklist) Check(password string, username string) string {
+	if _, ok := r.passwords[strings.ToLower(password)]; ok {
+		return "Password is too common or has appeared in a data breach"
+	}
+	return ""
+}
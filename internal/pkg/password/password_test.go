@@ -0,0 +1,101 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// rules 返回违反的规则名称.
+func rules(violations []Violation) []string {
+	var names []string
+	for _, v := range violations {
+		names = append(names, v.Rule)
+	}
+	return names
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     Rule
+		password string
+		username string
+		want     bool
+	}{
+		{name: "length within range", rule: Length(8, 12), password: "12345678", want: true},
+		{name: "too short", rule: Length(8, 12), password: "1234567"},
+		{name: "too long", rule: Length(8, 12), password: "1234567890123"},
+		{name: "length counts characters", rule: Length(4, 4), password: "密码密码", want: true},
+		{name: "uppercase", rule: RequireUpper(), password: "abcD", want: true},
+		{name: "without uppercase", rule: RequireUpper(), password: "abcd1!"},
+		{name: "lowercase", rule: RequireLower(), password: "ABCd", want: true},
+		{name: "without lowercase", rule: RequireLower(), password: "ABCD1!"},
+		{name: "digit", rule: RequireDigit(), password: "abc1", want: true},
+		{name: "without digit", rule: RequireDigit(), password: "abcD!"},
+		{name: "punctuation", rule: RequireSymbol(), password: "abc!", want: true},
+		{name: "symbol", rule: RequireSymbol(), password: "abc+", want: true},
+		{name: "without symbol", rule: RequireSymbol(), password: "abcD1 "},
+		{name: "without username", rule: NotContainUsername(), password: "Passw0rd!", username: "alice", want: true},
+		{name: "contains username", rule: NotContainUsername(), password: "xxAliCExx", username: "alice"},
+		{name: "empty username", rule: NotContainUsername(), password: "Passw0rd!", want: true},
+		{name: "not in blocklist", rule: Blocklist([]string{"password1"}), password: "Passw0rd!", want: true},
+		{name: "in blocklist", rule: Blocklist([]string{"password1"}), password: "PASSWORD1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := tt.rule.Check(tt.password, tt.username)
+			if got := msg == ""; got != tt.want {
+				t.Errorf("Check(%q, %q) = %q, want passed %v", tt.password, tt.username, msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	data := "# 常见密码\n123456\n\n  qwerty  \n#password\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	rule, err := LoadBlocklist(path)
+	if err != nil {
+		t.Fatalf("LoadBlocklist() error = %v", err)
+	}
+	if rule.Name() != "breached" {
+		t.Errorf("Name() = %s, want breached", rule.Name())
+	}
+
+	for password, want := range map[string]bool{"123456": false, "QWERTY": false, "#password": true, "# 常见密码": true, "": true} {
+		if got := rule.Check(password, "") == ""; got != want {
+			t.Errorf("Check(%q) passed = %v, want %v", password, got, want)
+		}
+	}
+
+	if _, err := LoadBlocklist(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadBlocklist() of a missing file error = nil")
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := &Policy{Rules: []Rule{Length(8, 64), RequireUpper(), RequireLower(), RequireDigit(), RequireSymbol(), NotContainUsername(), Blocklist([]string{"Passw0rd!"})}}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{name: "valid", password: "C0rrect-horse"},
+		{name: "all character classes missing", password: "        ", want: []string{"uppercase", "lowercase", "digit", "symbol"}},
+		{name: "short and contains username", password: "alice", want: []string{"length", "uppercase", "digit", "symbol", "username"}},
+		{name: "breached", password: "passw0rd!", want: []string{"uppercase", "breached"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules(policy.Check(tt.password, "alice")); !slices.Equal(got, tt.want) {
+				t.Errorf("Check(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
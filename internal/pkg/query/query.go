@@ -0,0 +1,331 @@
+// Package query 将列表接口的 filter 和 sort 参数解析为 where.Options.
+//
+// filter 由逗号分隔的过滤条件组成, 每个条件的格式为 `字段 操作符 值`, 多个条件之间为 AND 关系,
+// 值中的逗号需要转义为 `\,`. 支持的操作符:
+//
+//	=   等于
+//	!=  不等于
+//	>   大于
+//	>=  大于等于
+//	<   小于
+//	<=  小于等于
+//	~   包含, 只适用于字符串
+//
+// sort 由逗号分隔的字段组成, 字段前加 `-` 表示降序, 例如 `-updatedAt,title`.
+// 主键总是作为最后一个排序字段, 保证排序结果稳定.
+//
+// 只有在 Schema 中声明的字段和操作符可以使用, 其余的表达式一律以 ErrInvalidArgument 拒绝.
+//
+// 示例:
+//
+//	var postSchema = query.NewSchema(&model.Post{},
+//		query.Field{Name: "title", Type: query.String, Ops: []query.Operator{query.Eq, query.Contains}},
+//		query.Field{Name: "createdAt", Type: query.Time, Ops: query.Comparable, Sortable: true},
+//	)
+//	err := postSchema.Apply(whr, "createdAt>2025-01-01,title~go", "-createdAt")
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// maxFilters 为 filter 中过滤条件的最大数量.
+	maxFilters = 10
+	// maxSorts 为 sort 中排序字段的最大数量.
+	maxSorts = 3
+)
+
+// Operator 为过滤操作符.
+type Operator string
+
+// 支持的过滤操作符.
+const (
+	Eq       Operator = "="
+	Ne       Operator = "!="
+	Gt       Operator = ">"
+	Ge       Operator = ">="
+	Lt       Operator = "<"
+	Le       Operator = "<="
+	Contains Operator = "~"
+)
+
+// operators 为所有操作符, 解析时按照顺序匹配, 因此较长的操作符在前.
+var operators = []Operator{Ne, Ge, Le, Eq, Gt, Lt, Contains}
+
+// likeEscape 为 Contains 生成的 LIKE 条件使用的转义字符.
+// 不使用反斜杠, 反斜杠在 MySQL 的字符串字面值中本身需要转义, 在各数据库中的写法不一致.
+const likeEscape = "!"
+
+// likeEscaper 转义值中的转义字符和 LIKE 通配符.
+var likeEscaper = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+// Comparable 为可比较类型(数值、时间)常用的操作符.
+var Comparable = []Operator{Eq, Ne, Gt, Ge, Lt, Le}
+
+// Type 为字段值的类型, 用于将请求中的字符串转换为数据库中对应的类型.
+type Type int
+
+// 支持的字段类型.
+const (
+	String Type = iota
+	Int
+	Time
+	Bool
+)
+
+// Field 声明一个可以用于过滤或排序的字段.
+type Field struct {
+	// Name 为 filter 和 sort 中使用的字段名.
+	Name string
+	// Column 为数据库列名, 为空时与 Name 相同.
+	Column string
+	// Type 为字段值的类型.
+	Type Type
+	// Ops 为允许的过滤操作符, 为空时不允许按照该字段过滤.
+	Ops []Operator
+	// Sortable 表示是否允许按照该字段排序.
+	Sortable bool
+}
+
+// Schema 是某种资源的字段白名单.
+type Schema struct {
+	model  *schema.Schema
+	fields map[string]Field
+}
+
+// NewSchema 根据模型 model 和字段白名单 fields 创建 Schema.
+// 字段对应的列在模型中不存在时 panic, 这属于编程错误.
+func NewSchema(model any, fields ...Field) *Schema {
+	s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		panic(fmt.Sprintf("query: failed to parse schema of %T: %v", model, err))
+	}
+
+	m := make(map[string]Field, len(fields))
+	for _, f := range fields {
+		if f.Column == "" {
+			f.Column = f.Name
+		}
+		if s.LookUpField(f.Column) == nil {
+			panic(fmt.Sprintf("query: column %s does not exist in %T", f.Column, model))
+		}
+		m[f.Name] = f
+	}
+	return &Schema{model: s, fields: m}
+}
+
+// Apply 解析 filter 和 sort 并添加到 whr 中, 表达式不合法时返回 ErrInvalidArgument.
+func (s *Schema) Apply(whr *where.Options, filter string, sort string) error {
+	if err := s.applyFilter(whr, filter); err != nil {
+		return err
+	}
+	return s.applySort(whr, sort)
+}
+
+// PageToken 从 NextPageToken 返回的 pageToken 中恢复游标, 需要在 Apply 之后调用, 空的 pageToken 被忽略.
+// pageToken 与当前的排序字段不一致时返回 ErrInvalidArgument.
+func (s *Schema) PageToken(whr *where.Options, token string) error {
+	if token == "" {
+		return nil
+	}
+
+	c, err := where.DecodeCursor(token)
+	if err != nil || len(c.Values) != len(whr.Sorts) {
+		return invalid("pageToken", token, "Invalid page token")
+	}
+	values := make([]any, len(c.Values))
+	for i, sort := range whr.Sorts {
+		if values[i], err = s.cursorValue(sort.Column, c.Values[i]); err != nil {
+			return invalid("pageToken", token, "Invalid page token")
+		}
+	}
+	whr.O(0).After(c.ID, values...)
+	return nil
+}
+
+// NextPageToken 返回指向记录 row 之后的 pageToken, row 为当前页的最后一条记录.
+func (s *Schema) NextPageToken(ctx context.Context, whr *where.Options, row any) string {
+	rv := reflect.Indirect(reflect.ValueOf(row))
+	id, _ := s.model.PrioritizedPrimaryField.ValueOf(ctx, rv)
+
+	c := where.Cursor{ID: reflect.ValueOf(id).Int()}
+	for _, sort := range whr.Sorts {
+		value, _ := s.model.LookUpField(sort.Column).ValueOf(ctx, rv)
+		c.Values = append(c.Values, value)
+	}
+	return c.Encode()
+}
+
+// applyFilter 将 filter 中的每个过滤条件转换为 `column op ?` 形式的查询条件.
+func (s *Schema) applyFilter(whr *where.Options, filter string) error {
+	if filter == "" {
+		return nil
+	}
+
+	terms := split(filter)
+	if len(terms) > maxFilters {
+		return invalid("filter", filter, "Too many filter expressions, at most %d are allowed", maxFilters)
+	}
+	for _, term := range terms {
+		name, op, raw, ok := parseTerm(term)
+		if !ok {
+			return invalid("filter", term, "Malformed filter expression, expected <field><operator><value>")
+		}
+		field, ok := s.fields[name]
+		if !ok || len(field.Ops) == 0 {
+			return invalid("filter", term, "Field '%s' cannot be filtered, allowed fields: %s", name, s.names(func(f Field) bool { return len(f.Ops) > 0 }))
+		}
+		if !slices.Contains(field.Ops, op) {
+			return invalid("filter", term, "Operator '%s' is not allowed on field '%s'", op, name)
+		}
+		value, err := convert(field.Type, raw)
+		if err != nil {
+			return invalid("filter", term, "Invalid value for field '%s': %s", name, err.Error())
+		}
+
+		switch op {
+		case Contains:
+			// 转义值中的通配符, 使其按照字面值匹配
+			whr.Q(field.Column+" like ? escape '"+likeEscape+"'", "%"+likeEscaper.Replace(raw)+"%")
+		default:
+			whr.Q(fmt.Sprintf("%s %s ?", field.Column, op), value)
+		}
+	}
+	return nil
+}
+
+// applySort 将 sort 中的字段转换为排序条件.
+func (s *Schema) applySort(whr *where.Options, sort string) error {
+	if sort == "" {
+		return nil
+	}
+
+	names := strings.Split(sort, ",")
+	if len(names) > maxSorts {
+		return invalid("sort", sort, "Too many sort fields, at most %d are allowed", maxSorts)
+	}
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name, desc := strings.CutPrefix(name, "-")
+		if !desc {
+			name = strings.TrimPrefix(name, "+")
+		}
+		field, ok := s.fields[name]
+		if !ok || !field.Sortable {
+			return invalid("sort", sort, "Field '%s' cannot be sorted, allowed fields: %s", name, s.names(func(f Field) bool { return f.Sortable }))
+		}
+		if seen[name] {
+			return invalid("sort", sort, "Duplicate sort field '%s'", name)
+		}
+		seen[name] = true
+		whr.S(field.Column, desc)
+	}
+	return nil
+}
+
+// cursorValue 将游标中的排序字段值转换为列对应的类型.
+func (s *Schema) cursorValue(column string, value any) (any, error) {
+	for _, f := range s.fields {
+		if f.Column != column {
+			continue
+		}
+		switch v := value.(type) {
+		case json.Number:
+			return convert(f.Type, v.String())
+		case string:
+			return convert(f.Type, v)
+		case bool:
+			return convert(f.Type, strconv.FormatBool(v))
+		}
+		return nil, fmt.Errorf("unexpected value %v", value)
+	}
+	return nil, fmt.Errorf("unknown column %s", column)
+}
+
+// names 返回满足条件的字段名, 用于错误提示.
+func (s *Schema) names(ok func(Field) bool) string {
+	var names []string
+	for name, f := range s.fields {
+		if ok(f) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return strings.Join(names, ",")
+}
+
+// split 按照未转义的逗号分割 filter, 并去除转义符.
+func split(filter string) []string {
+	var (
+		terms []string
+		b     strings.Builder
+	)
+	for i := 0; i < len(filter); i++ {
+		switch {
+		case filter[i] == '\\' && i+1 < len(filter) && filter[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case filter[i] == ',':
+			terms = append(terms, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(filter[i])
+		}
+	}
+	return append(terms, b.String())
+}
+
+// parseTerm 将形如 `createdAt>=2025-01-01` 的过滤条件解析为字段名、操作符和值.
+func parseTerm(term string) (string, Operator, string, bool) {
+	end := strings.IndexFunc(term, func(r rune) bool {
+		return !(r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if end <= 0 {
+		return "", "", "", false
+	}
+	for _, op := range operators {
+		if rest, ok := strings.CutPrefix(term[end:], string(op)); ok {
+			return term[:end], op, rest, true
+		}
+	}
+	return "", "", "", false
+}
+
+// convert 将字符串 raw 转换为类型 t 的值.
+func convert(t Type, raw string) (any, error) {
+	switch t {
+	case Int:
+		return strconv.ParseInt(raw, 10, 64)
+	case Bool:
+		return strconv.ParseBool(raw)
+	case Time:
+		for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+			if v, err := time.Parse(layout, raw); err == nil {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("'%s' is not a RFC 3339 time or a YYYY-MM-DD date", raw)
+	default:
+		return raw, nil
+	}
+}
+
+// invalid 返回描述非法参数的 ErrInvalidArgument, Metadata 中包含参数名和出错的表达式.
+// 每次创建新的错误, 避免修改全局的 errorsx.ErrInvalidArgument.
+func invalid(parameter string, expression string, format string, args ...any) error {
+	return errorsx.New(errorsx.ErrInvalidArgument.Code, errorsx.ErrInvalidArgument.Reason, format, args...).
+		KV("parameter", parameter, "expression", expression)
+}
@@ -0,0 +1,178 @@
+package query
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"fastgo/internal/pkg/errorsx"
+	where "fastgo/pkg/store"
+)
+
+// testPost 为测试使用的模型, 包含各种类型的字段.
+type testPost struct {
+	ID        int64     `gorm:"column:id;primaryKey"`
+	Title     string    `gorm:"column:title"`
+	Version   int64     `gorm:"column:version"`
+	Content   string    `gorm:"column:content"`
+	CreatedAt time.Time `gorm:"column:createdAt"`
+}
+
+var testSchema = NewSchema(&testPost{},
+	Field{Name: "title", Type: String, Ops: []Operator{Eq, Contains}, Sortable: true},
+	Field{Name: "version", Type: Int, Ops: Comparable, Sortable: true},
+	Field{Name: "createdAt", Type: Time, Ops: Comparable, Sortable: true},
+	Field{Name: "content", Type: String},
+)
+
+// wantInvalid 校验 err 为 ErrInvalidArgument, 并且 Metadata 中记录了出错的参数, parameter 为空时校验 err 为 nil.
+func wantInvalid(t *testing.T, err error, parameter string) {
+	t.Helper()
+
+	if parameter == "" {
+		if err != nil {
+			t.Fatalf("error = %v, want nil", err)
+		}
+		return
+	}
+	got := errorsx.FromError(err)
+	if got == nil || got.Reason != errorsx.ErrInvalidArgument.Reason || got.Metadata["parameter"] != parameter {
+		t.Fatalf("error = %v, want %s on %s", err, errorsx.ErrInvalidArgument.Reason, parameter)
+	}
+}
+
+func TestApplyFilter(t *testing.T) {
+	date := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		filter    string
+		want      []where.Query
+		wantError bool
+	}{
+		{name: "empty"},
+		{name: "equal", filter: "title=go", want: []where.Query{{Query: "title = ?", Args: []any{"go"}}}},
+		{name: "longest operator first", filter: "version>=2", want: []where.Query{{Query: "version >= ?", Args: []any{int64(2)}}}},
+		{name: "not equal", filter: "version!=2", want: []where.Query{{Query: "version != ?", Args: []any{int64(2)}}}},
+		{name: "date", filter: "createdAt<2025-01-01", want: []where.Query{{Query: "createdAt < ?", Args: []any{date}}}},
+		{
+			name:   "multiple terms",
+			filter: "title~go,version>1",
+			want: []where.Query{
+				{Query: "title like ? escape '!'", Args: []any{"%go%"}},
+				{Query: "version > ?", Args: []any{int64(1)}},
+			},
+		},
+		{name: "escaped comma", filter: `title=a\,b`, want: []where.Query{{Query: "title = ?", Args: []any{"a,b"}}}},
+		{name: "like wildcards", filter: "title~100%_done", want: []where.Query{{Query: "title like ? escape '!'", Args: []any{"%100!%!_done%"}}}},
+		{name: "like escape character", filter: "title~wow!", want: []where.Query{{Query: "title like ? escape '!'", Args: []any{"%wow!!%"}}}},
+		{name: "unknown field", filter: "password=x", wantError: true},
+		{name: "field without operators", filter: "content=x", wantError: true},
+		{name: "operator not allowed", filter: "title>go", wantError: true},
+		{name: "malformed", filter: "=go", wantError: true},
+		{name: "missing operator", filter: "title", wantError: true},
+		{name: "invalid int", filter: "version=one", wantError: true},
+		{name: "invalid time", filter: "createdAt>yesterday", wantError: true},
+		{name: "injection", filter: "title=x or 1=1;--", want: []where.Query{{Query: "title = ?", Args: []any{"x or 1=1;--"}}}},
+		{name: "too many terms", filter: "version>0,version>0,version>0,version>0,version>0,version>0,version>0,version>0,version>0,version>0,version>0", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whr := where.NewWhere()
+			err := testSchema.Apply(whr, tt.filter, "")
+			if tt.wantError {
+				wantInvalid(t, err, "filter")
+				return
+			}
+			wantInvalid(t, err, "")
+			if !reflect.DeepEqual(whr.Queries, tt.want) {
+				t.Errorf("Queries = %+v, want %+v", whr.Queries, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySort(t *testing.T) {
+	tests := []struct {
+		name      string
+		sort      string
+		want      []where.Sort
+		wantError bool
+	}{
+		{name: "empty"},
+		{name: "ascending", sort: "title", want: []where.Sort{{Column: "title"}}},
+		{name: "explicit ascending", sort: "+title", want: []where.Sort{{Column: "title"}}},
+		{name: "descending", sort: "-createdAt,title", want: []where.Sort{{Column: "createdAt", Desc: true}, {Column: "title"}}},
+		{name: "unsortable field", sort: "content", wantError: true},
+		{name: "unknown field", sort: "-password", wantError: true},
+		{name: "duplicate field", sort: "title,-title", wantError: true},
+		{name: "too many fields", sort: "title,version,createdAt,title", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whr := where.NewWhere()
+			err := testSchema.Apply(whr, "", tt.sort)
+			if tt.wantError {
+				wantInvalid(t, err, "sort")
+				return
+			}
+			wantInvalid(t, err, "")
+			if !reflect.DeepEqual(whr.Sorts, tt.want) {
+				t.Errorf("Sorts = %+v, want %+v", whr.Sorts, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageToken(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	row := &testPost{ID: 42, Title: "go", Version: 3, CreatedAt: createdAt}
+
+	// 使用与生成 pageToken 相同的排序字段恢复游标
+	next := where.NewWhere()
+	if err := testSchema.Apply(next, "", "-createdAt,version"); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	token := testSchema.NextPageToken(context.Background(), next, row)
+
+	tests := []struct {
+		name       string
+		sort       string
+		token      string
+		wantID     int64
+		wantValues []any
+		wantError  bool
+	}{
+		{name: "empty"},
+		{name: "same sort", sort: "-createdAt,version", token: token, wantID: 42, wantValues: []any{createdAt, int64(3)}},
+		{name: "different sort fields", sort: "title", token: token, wantError: true},
+		{name: "sort field types changed", sort: "version,-createdAt", token: token, wantError: true},
+		{name: "without sort", token: token, wantError: true},
+		{name: "malformed", sort: "title", token: "not a token", wantError: true},
+		{name: "without id", sort: "title", token: where.Cursor{Values: []any{"go"}}.Encode(), wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whr := where.NewWhere().O(20)
+			if err := testSchema.Apply(whr, "", tt.sort); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			err := testSchema.PageToken(whr, tt.token)
+			if tt.wantError {
+				wantInvalid(t, err, "pageToken")
+				return
+			}
+			wantInvalid(t, err, "")
+			if tt.token == "" {
+				if whr.AfterID != 0 || whr.Offset != 20 {
+					t.Errorf("PageToken(\"\") changed the options to %+v", whr)
+				}
+				return
+			}
+			if whr.AfterID != tt.wantID || whr.Offset != 0 || !reflect.DeepEqual(whr.AfterValues, tt.wantValues) {
+				t.Errorf("AfterID = %d, AfterValues = %v, Offset = %d, want %d, %v, 0", whr.AfterID, whr.AfterValues, whr.Offset, tt.wantID, tt.wantValues)
+			}
+		})
+	}
+}
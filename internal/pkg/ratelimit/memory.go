@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// defaultSweepInterval 是内存后端清理空闲令牌桶的最小间隔.
+const defaultSweepInterval = time.Minute
+
+// bucket 为一个令牌桶.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full 为令牌桶补满的时间, 此后该令牌桶与新建的令牌桶没有区别, 可以被清理.
+	full time.Time
+}
+
+// memory 是 Limiter 的进程内实现, 已经补满的令牌桶在清理时被删除.
+type memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// 确保 memory 实现了 Limiter 接口.
+var _ Limiter = (*memory)(nil)
+
+// NewMemory 创建一个进程内的限流后端.
+func NewMemory() *memory {
+	return &memory{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow 按照 rule 判断 key 的一个请求是否被允许.
+func (m *memory) Allow(ctx context.Context, key string, rule Rule) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+
+	burst := float64(rule.Burst)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		m.buckets[key] = b
+	}
+
+	// 补充上次请求之后产生的令牌
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rule.Rate)
+	b.last = now
+
+	result := &Result{Limit: rule.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / rule.Rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.ResetAfter = seconds((burst - b.tokens) / rule.Rate)
+	b.full = now.Add(result.ResetAfter)
+	return result, nil
+}
+
+// sweep 清理已经补满的令牌桶, 调用方需要持有锁.
+func (m *memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < defaultSweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// seconds 将秒数转换为 time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
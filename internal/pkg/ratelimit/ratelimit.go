@@ -0,0 +1,55 @@
+// Package ratelimit 实现了基于令牌桶的限流.
+//
+// 每个限流键(例如客户端 IP、用户 ID)对应一个令牌桶, 桶的容量为 Burst, 以每秒 Rate 个的速度补充令牌,
+// 每个请求消耗一个令牌, 没有令牌时拒绝请求. 因此客户端最多可以连续发送 Burst 个请求, 之后平均每秒 Rate 个.
+//
+// 提供进程内的 memory 后端, 适用于单实例部署. 多实例部署需要共享限流状态时,
+// 可以基于 Redis 等共享存储实现 Limiter 接口, 并在 New 中注册.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BackendMemory 表示使用进程内存储令牌桶.
+const BackendMemory = "memory"
+
+// Rule 为令牌桶的限流规则.
+type Rule struct {
+	// Rate 为每秒补充的令牌数.
+	Rate float64
+	// Burst 为令牌桶的容量, 即允许连续发送的最大请求数.
+	Burst int
+}
+
+// Result 为一次限流判断的结果.
+type Result struct {
+	// Allowed 表示请求是否被允许.
+	Allowed bool
+	// Limit 为令牌桶的容量.
+	Limit int
+	// Remaining 为本次请求之后剩余的令牌数.
+	Remaining int
+	// RetryAfter 为请求被拒绝时, 距离下一个令牌可用的时间.
+	RetryAfter time.Duration
+	// ResetAfter 为距离令牌桶补满的时间.
+	ResetAfter time.Duration
+}
+
+// Limiter 定义了限流后端需要实现的方法.
+type Limiter interface {
+	// Allow 按照 rule 判断 key 的一个请求是否被允许, 允许时消耗一个令牌.
+	Allow(ctx context.Context, key string, rule Rule) (*Result, error)
+}
+
+// New 根据 backend 创建限流后端.
+func New(backend string) (Limiter, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unsupported rate limit backend: %s", backend)
+	}
+}
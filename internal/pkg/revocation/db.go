@@ -0,0 +1,115 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// userKeyPrefix 是用户级别吊销记录的 jti 前缀, 与 token 级别的吊销记录共用一张表.
+const userKeyPrefix = "user:"
+
+// revokedToken 是 revoked_token 表对应的模型.
+type revokedToken struct {
+	ID     int64  `gorm:"column:id;primaryKey;autoIncrement:true"`
+	JTI    string `gorm:"column:jti;not null"`
+	UserID string `gorm:"column:userID;not null"`
+	// RevokedBefore 仅用于用户级别的吊销记录, 表示在此之前签发的 token 均被吊销.
+	RevokedBefore *time.Time `gorm:"column:revokedBefore"`
+	ExpiresAt     time.Time  `gorm:"column:expiresAt;not null"`
+	CreatedAt     time.Time  `gorm:"column:createdAt;not null"`
+}
+
+// TableName 返回 revoked_token 表名.
+func (*revokedToken) TableName() string {
+	return "revoked_token"
+}
+
+// db 是 Revoker 的数据库实现, 表结构由数据库迁移创建.
+type db struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// 确保 db 实现了 Revoker 接口.
+var _ Revoker = (*db)(nil)
+
+// NewDB 创建一个使用数据库存储的吊销列表.
+func NewDB(gdb *gorm.DB) *db {
+	return &db{db: gdb, lastSweep: time.Now()}
+}
+
+// Revoke 吊销唯一标识为 jti 的 token.
+func (d *db) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	d.sweep(ctx)
+	return d.upsert(ctx, &revokedToken{JTI: jti, ExpiresAt: expiresAt, CreatedAt: time.Now()})
+}
+
+// RevokeUser 吊销用户在 before 之前签发的所有 token.
+func (d *db) RevokeUser(ctx context.Context, userID string, before time.Time, expiresAt time.Time) error {
+	d.sweep(ctx)
+	// revokedBefore 列精确到毫秒, 写入时向上取整, 避免数据库舍入后漏掉吊销之前签发的 token
+	if rounded := before.Truncate(time.Millisecond); rounded.Before(before) {
+		before = rounded.Add(time.Millisecond)
+	}
+	return d.upsert(ctx, &revokedToken{
+		JTI:           userKeyPrefix + userID,
+		UserID:        userID,
+		RevokedBefore: &before,
+		ExpiresAt:     expiresAt,
+		CreatedAt:     time.Now(),
+	})
+}
+
+// IsRevoked 判断 token 是否已被吊销.
+func (d *db) IsRevoked(ctx context.Context, jti string, userID string, issuedAt time.Time) (bool, error) {
+	keys := []string{userKeyPrefix + userID}
+	if jti != "" {
+		keys = append(keys, jti)
+	}
+
+	var rows []*revokedToken
+	err := d.db.WithContext(ctx).Where("jti IN ? AND expiresAt > ?", keys, time.Now()).Find(&rows).Error
+	if err != nil {
+		return false, err
+	}
+
+	for _, row := range rows {
+		if row.RevokedBefore == nil || revokedBefore(issuedAt, *row.RevokedBefore) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// upsert 插入吊销记录, jti 已存在时更新吊销时间点和过期时间.
+func (d *db) upsert(ctx context.Context, row *revokedToken) error {
+	err := d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "jti"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revokedBefore", "expiresAt"}),
+	}).Create(row).Error
+	if err != nil {
+		return errors.Join(errors.New("failed to save revoked token"), err)
+	}
+	return nil
+}
+
+// sweep 定期删除过期的吊销记录.
+func (d *db) sweep(ctx context.Context) {
+	d.mu.Lock()
+	now := time.Now()
+	if now.Sub(d.lastSweep) < defaultSweepInterval {
+		d.mu.Unlock()
+		return
+	}
+	d.lastSweep = now
+	d.mu.Unlock()
+
+	_ = d.db.WithContext(ctx).Where("expiresAt <= ?", now).Delete(new(revokedToken)).Error
+}
@@ -0,0 +1,91 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultSweepInterval 是内存后端清理过期吊销记录的最小间隔.
+const defaultSweepInterval = time.Minute
+
+// userRevocation 表示用户级别的吊销记录.
+type userRevocation struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
+// memory 是 Revoker 的进程内实现, 吊销记录在过期后被清理.
+type memory struct {
+	mu        sync.RWMutex
+	tokens    map[string]time.Time
+	users     map[string]userRevocation
+	lastSweep time.Time
+}
+
+// 确保 memory 实现了 Revoker 接口.
+var _ Revoker = (*memory)(nil)
+
+// NewMemory 创建一个进程内的吊销列表.
+func NewMemory() *memory {
+	return &memory{
+		tokens:    make(map[string]time.Time),
+		users:     make(map[string]userRevocation),
+		lastSweep: time.Now(),
+	}
+}
+
+// Revoke 吊销唯一标识为 jti 的 token.
+func (m *memory) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep()
+	m.tokens[jti] = expiresAt
+	return nil
+}
+
+// RevokeUser 吊销用户在 before 之前签发的所有 token.
+func (m *memory) RevokeUser(ctx context.Context, userID string, before time.Time, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep()
+	m.users[userID] = userRevocation{before: before, expiresAt: expiresAt}
+	return nil
+}
+
+// IsRevoked 判断 token 是否已被吊销.
+func (m *memory) IsRevoked(ctx context.Context, jti string, userID string, issuedAt time.Time) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	if expiresAt, ok := m.tokens[jti]; ok && jti != "" && now.Before(expiresAt) {
+		return true, nil
+	}
+	if r, ok := m.users[userID]; ok && now.Before(r.expiresAt) && revokedBefore(issuedAt, r.before) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// sweep 清理过期的吊销记录, 调用方需要持有写锁.
+func (m *memory) sweep() {
+	now := time.Now()
+	if now.Sub(m.lastSweep) < defaultSweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for jti, expiresAt := range m.tokens {
+		if !now.Before(expiresAt) {
+			delete(m.tokens, jti)
+		}
+	}
+	for userID, r := range m.users {
+		if !now.Before(r.expiresAt) {
+			delete(m.users, userID)
+		}
+	}
+}
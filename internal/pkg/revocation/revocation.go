@@ -0,0 +1,54 @@
+// Package revocation 实现了 JWT 的服务端吊销列表.
+//
+// JWT 本身是无状态的, 在过期之前一直有效. 吊销列表记录被吊销的 token(按 jti)
+// 以及用户级别的吊销时间点("退出所有设备"), 认证中间件在校验签名之后查询吊销列表.
+// 吊销记录只需要保存到 token 过期为止, 过期后自动清理.
+//
+// 提供两种后端:
+//   - memory: 进程内存储, 适用于单实例部署, 重启后吊销记录丢失;
+//   - db: 数据库存储, 适用于多实例部署.
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// BackendMemory 表示使用进程内存储吊销列表.
+	BackendMemory = "memory"
+	// BackendDB 表示使用数据库存储吊销列表.
+	BackendDB = "db"
+)
+
+// Revoker 定义了吊销列表需要实现的方法.
+type Revoker interface {
+	// Revoke 吊销唯一标识为 jti 的 token, 吊销记录保存到 expiresAt 为止.
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	// RevokeUser 吊销用户在 before 之前签发的所有 token, 吊销记录保存到 expiresAt 为止.
+	RevokeUser(ctx context.Context, userID string, before time.Time, expiresAt time.Time) error
+	// IsRevoked 判断用户 userID 在 issuedAt 签发的、唯一标识为 jti 的 token 是否已被吊销.
+	IsRevoked(ctx context.Context, jti string, userID string, issuedAt time.Time) (bool, error)
+}
+
+// New 根据 backend 创建吊销列表, backend 为 db 时使用 db 存储吊销记录.
+func New(backend string, db *gorm.DB) (Revoker, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewMemory(), nil
+	case BackendDB:
+		return NewDB(db), nil
+	default:
+		return nil, fmt.Errorf("unsupported revocation backend: %s", backend)
+	}
+}
+
+// revokedBefore 判断签发时间为 issuedAt 的 token 是否早于用户级别的吊销时间点 before.
+// token 中的签发时间向下截断到毫秒(旧 token 截断到秒), 因此吊销之前签发的 token 一定被吊销;
+// 吊销之后签发的 token 只有在与吊销时间点处于同一毫秒(旧 token 为同一秒)时才会被保守地吊销.
+func revokedBefore(issuedAt time.Time, before time.Time) bool {
+	return issuedAt.Before(before)
+}
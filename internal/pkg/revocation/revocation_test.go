@@ -0,0 +1,94 @@
+package revocation
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fastgo/internal/apiserver/migrations"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB 创建一个使用临时 SQLite 数据库的吊销列表, 表结构由迁移创建.
+func newTestDB(t *testing.T) *db {
+	t.Helper()
+
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "fastgo.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	migrator, err := migrations.NewMigrator(gdb, "sqlite")
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	return NewDB(gdb)
+}
+
+func TestRevokeUser(t *testing.T) {
+	backends := map[string]func(t *testing.T) Revoker{
+		"memory": func(t *testing.T) Revoker { return NewMemory() },
+		"db":     func(t *testing.T) Revoker { return newTestDB(t) },
+	}
+	for name, newRevoker := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			r := newRevoker(t)
+			// 吊销时间点位于某一秒的中间, token 中的签发时间精确到毫秒
+			before := time.Now().Truncate(time.Second).Add(500*time.Millisecond + 300*time.Microsecond)
+			if err := r.RevokeUser(ctx, "user-a", before, before.Add(time.Hour)); err != nil {
+				t.Fatalf("RevokeUser() error = %v", err)
+			}
+
+			tests := []struct {
+				name     string
+				userID   string
+				issuedAt time.Time
+				want     bool
+			}{
+				{name: "issued earlier in the same second", userID: "user-a", issuedAt: before.Add(-time.Millisecond).Truncate(time.Millisecond), want: true},
+				{name: "issued in the same millisecond", userID: "user-a", issuedAt: before.Truncate(time.Millisecond), want: true},
+				{name: "issued later in the same second", userID: "user-a", issuedAt: before.Add(time.Millisecond).Truncate(time.Millisecond)},
+				{name: "issued in the next second", userID: "user-a", issuedAt: before.Add(time.Second)},
+				{name: "other user", userID: "user-b", issuedAt: before.Add(-time.Second)},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got, err := r.IsRevoked(ctx, "jti", tt.userID, tt.issuedAt)
+					if err != nil {
+						t.Fatalf("IsRevoked() error = %v", err)
+					}
+					if got != tt.want {
+						t.Errorf("IsRevoked() = %v, want %v", got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	r := newTestDB(t)
+	if err := r.Revoke(ctx, "jti-a", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if err := r.Revoke(ctx, "jti-expired", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	for jti, want := range map[string]bool{"jti-a": true, "jti-b": false, "jti-expired": false} {
+		got, err := r.IsRevoked(ctx, jti, "user-a", time.Now())
+		if err != nil {
+			t.Fatalf("IsRevoked(%s) error = %v", jti, err)
+		}
+		if got != want {
+			t.Errorf("IsRevoked(%s) = %v, want %v", jti, got, want)
+		}
+	}
+}
@@ -16,7 +16,7 @@ const (
 
 // 将资源标识符转换为字符串
 func (rid ResourceID) String() string {
-	return rid.String()
+	return string(rid)
 }
 
 // 创建带前缀的唯一标识符
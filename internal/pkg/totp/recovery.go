@@ -0,0 +1,50 @@
+package totp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// recoveryAlphabet 为恢复码使用的 32 个字符, 去掉了容易混淆的字符(0/o、1/l), 长度为 2 的幂使每个字符的概率相同.
+const recoveryAlphabet = "23456789abcdefghijkmnpqrstuvwxyz"
+
+// GenerateRecoveryCodes 生成 n 个形如 `xxxxx-xxxxx` 的随机恢复码.
+// 恢复码在无法使用验证器应用时代替验证码, 每个恢复码只能使用一次, 服务端只保存 HashRecoveryCode 的结果.
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	b := make([]byte, 10)
+	for range n {
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		var sb strings.Builder
+		for i, c := range b {
+			if i == len(b)/2 {
+				sb.WriteByte('-')
+			}
+			sb.WriteByte(recoveryAlphabet[int(c)%len(recoveryAlphabet)])
+		}
+		codes = append(codes, sb.String())
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode 计算恢复码的哈希值, 忽略大小写、空白和连字符.
+// 恢复码是高熵的随机字符串, 使用 SHA-256 即可, 无需 bcrypt 等慢哈希.
+func HashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsRecoveryCode 判断 code 的格式是否可能是恢复码而不是验证码.
+func IsRecoveryCode(code string) bool {
+	return len(strings.TrimSpace(code)) > Digits
+}
@@ -0,0 +1,128 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfcSecret 为 RFC 4226 和 RFC 6238 测试向量使用的 SHA1 密钥.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeHOTP(t *testing.T) {
+	// RFC 4226 附录 D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		got, err := Code(rfcSecret, int64(counter))
+		if err != nil {
+			t.Fatalf("Code(%d) error = %v", counter, err)
+		}
+		if got != code {
+			t.Errorf("Code(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestCodeTOTP(t *testing.T) {
+	// RFC 6238 附录 B 的 SHA1 测试向量, 验证码长度为 6, 取 8 位验证码的后 6 位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(%d) error = %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	// 密钥不区分大小写
+	if got, _ := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 1); got != "287082" {
+		t.Errorf("Code() with lowercase secret = %s, want 287082", got)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code() with invalid secret error = nil")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	code := func(delta int64) string {
+		c, err := Code(rfcSecret, current+delta)
+		if err != nil {
+			t.Fatalf("Code() error = %v", err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		after    int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", code: code(0), skew: 1, wantStep: current, wantOK: true},
+		{name: "surrounding whitespace", code: " " + code(0) + "\n", skew: 1, wantStep: current, wantOK: true},
+		{name: "previous step within skew", code: code(-1), skew: 1, wantStep: current - 1, wantOK: true},
+		{name: "next step within skew", code: code(1), skew: 1, wantStep: current + 1, wantOK: true},
+		{name: "outside skew", code: code(-2), skew: 1},
+		{name: "without skew", code: code(-1), skew: 0},
+		{name: "wrong code", code: "000000", skew: 1},
+		{name: "wrong length", code: code(0)[:5], skew: 1},
+		{name: "step already used", code: code(0), skew: 1, after: current},
+		{name: "step before the last used one", code: code(-1), skew: 1, after: current - 1},
+		{name: "step after the last used one", code: code(0), skew: 1, after: current - 1, wantStep: current, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, tt.code, now, tt.skew, tt.after)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate() = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRecoveryCode(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || !IsRecoveryCode(code) {
+			t.Errorf("recovery code %q has an unexpected format", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate recovery code %q", code)
+		}
+		seen[code] = true
+	}
+
+	// 哈希忽略大小写、空白和连字符
+	want := HashRecoveryCode("abcde-fghij")
+	for _, code := range []string{"ABCDE-FGHIJ", "abcdefghij", " abcde fghij\t", "abcde\t-fghij"} {
+		if got := HashRecoveryCode(code); got != want {
+			t.Errorf("HashRecoveryCode(%q) = %s, want %s", code, got, want)
+		}
+	}
+	if HashRecoveryCode("abcde-fghik") == want {
+		t.Error("HashRecoveryCode() of a different code matches")
+	}
+	if IsRecoveryCode(" 123456 ") {
+		t.Error("IsRecoveryCode() of a TOTP code = true")
+	}
+}
@@ -0,0 +1,28 @@
+// Package tracing 提供了创建 OpenTelemetry span 的辅助方法.
+//
+// span 通过全局的 TracerProvider 创建, 未启用链路追踪时全局 TracerProvider 为 noop, 创建 span 几乎没有开销.
+// 一个请求的链路由以下 span 组成:
+//
+//	GET /v1/posts/:postID          // middleware.Tracing, 接受上游的 traceparent 请求头
+//	└── PostBiz.Get                // biz 层方法
+//	    └── gorm.Query             // where.TracingPlugin, 记录 SQL 语句
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation 为创建 span 的 Tracer 名称.
+const instrumentation = "fastgo"
+
+// KeyRequestID 为 span 中请求 ID 属性的名称.
+const KeyRequestID = attribute.Key("request.id")
+
+// Start 创建一个名为 name 的 span, 返回包含该 span 的 context, 调用方需要调用 span.End 结束 span.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, opts...)
+}
@@ -0,0 +1,52 @@
+package v1
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 审计日志
+type AuditLog struct {
+	// 审计日志 ID
+	ID int64 `json:"id"`
+	// 操作者的用户 ID, 匿名请求(例如注册)和系统任务为空
+	ActorID string `json:"actorID"`
+	// 请求 ID, 与响应头 X-Request-ID 一致
+	RequestID string `json:"requestID"`
+	// 资源类型, user 或 post
+	Resource string `json:"resource"`
+	// 资源 ID
+	ResourceID string `json:"resourceID"`
+	// 操作, create、update、delete 或 restore
+	Action string `json:"action"`
+	// 变更前后的字段值, 例如 `{"title":{"before":"a","after":"b"}}`, 敏感字段已脱敏
+	Diff json.RawMessage `json:"diff"`
+	// 操作时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// 审计日志列表请求
+type ListAuditLogRequest struct {
+	// 偏移量
+	Offset int64 `json:"offset" form:"offset"`
+	// 每页数量
+	Limit int64 `json:"limit" form:"limit"`
+	// 只返回该用户执行的操作
+	ActorID string `json:"actorID" form:"actorID"`
+	// 只返回该类型资源的操作, user 或 post
+	Resource string `json:"resource" form:"resource"`
+	// 只返回该资源的操作, 需要与 resource 一起使用
+	ResourceID string `json:"resourceID" form:"resourceID"`
+	// 只返回该时间及之后的操作, RFC 3339 格式
+	Since time.Time `json:"since" form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	// 只返回该时间之前的操作, RFC 3339 格式
+	Until time.Time `json:"until" form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// 审计日志列表响应
+type ListAuditLogResponse struct {
+	// 满足条件的审计日志总数
+	TotalCount int64 `json:"totalCount"`
+	// 审计日志列表, 按照操作时间降序排列
+	AuditLogs []*AuditLog `json:"auditLogs"`
+}
@@ -16,10 +16,14 @@ type Post struct {
 	Title string `json:"title"`
 	// 博客内容
 	Content string `json:"content"`
+	// 博客版本号, 每次更新后加 1, 与 ETag 响应头一致
+	Version int64 `json:"version"`
 	// 博客创建时间
 	CreatedAt time.Time `json:"createdAt"`
 	// 博客最后更新时间
 	UpdatedAt time.Time `json:"updatedAt"`
+	// 博客删除时间, 只有回收站中的博客有该字段
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
 }
 
 // 创建文章请求
@@ -44,10 +48,14 @@ type UpdatePostRequest struct {
 	Title *string `json:"title"`
 	// 更新后的博客内容
 	Content *string `json:"content"`
+	// 期望的博客版本号, 来自 If-Match 请求头, 为空时不校验
+	Version *int64 `json:"-"`
 }
 
 // 更新文章响应
 type UpdatePostResponse struct {
+	// 更新后的版本号
+	Version int64 `json:"version"`
 }
 
 // 删除文章请求
@@ -75,17 +83,83 @@ type GetPostResponse struct {
 // 获取文章列表请求
 type ListPostRequest struct {
 	// 偏移量
-	Offset int64 `json:"offset"`
+	Offset int64 `json:"offset" form:"offset"`
 	// 每页数量
-	Limit int64 `json:"limit"`
+	Limit int64 `json:"limit" form:"limit"`
+	// 上一页响应中的 nextPageToken, 指定后按游标分页, 忽略 offset
+	PageToken string `json:"pageToken" form:"pageToken"`
+	// 为 true 时不统计总数, totalCount 返回 -1
+	SkipTotalCount bool `json:"skipTotalCount" form:"skipTotalCount"`
+	// 逗号分隔的过滤条件, 例如 `createdAt>2025-01-01,title~go`
+	Filter string `json:"filter" form:"filter"`
+	// 逗号分隔的排序字段, 字段前加 `-` 表示降序, 例如 `-updatedAt`, 默认按照创建顺序降序排列
+	Sort string `json:"sort" form:"sort"`
 	// 可选的标题过滤
-	Title *string `json:"title"`
+	Title *string `json:"title" form:"title"`
 }
 
 // 获取文章列表响应
 type ListPostResponse struct {
-	// 总文章数
+	// 总文章数, 不统计总数时为 -1
 	TotalCount int64 `json:"totalCount"`
 	// 文章列表
 	Posts []*Post `json:"posts"`
+	// 下一页的游标, 为空表示没有下一页
+	NextPageToken string `json:"nextPageToken,omitempty"`
+}
+
+// 查询回收站中的文章列表请求
+type ListTrashPostRequest struct {
+	// 偏移量
+	Offset int64 `json:"offset" form:"offset"`
+	// 每页数量
+	Limit int64 `json:"limit" form:"limit"`
+}
+
+// 查询回收站中的文章列表响应
+type ListTrashPostResponse struct {
+	// 回收站中的文章总数
+	TotalCount int64 `json:"totalCount"`
+	// 回收站中的文章列表, 按照删除时间降序排列
+	Posts []*Post `json:"posts"`
+}
+
+// 恢复文章请求
+type RestorePostRequest struct {
+	// 要恢复的文章 ID
+	PostID string `json:"postID" uri:"postID"`
+}
+
+// 恢复文章响应
+type RestorePostResponse struct {
+}
+
+// 全文检索文章请求
+type SearchPostRequest struct {
+	// 检索词, 同时检索标题和内容
+	Q string `json:"q" form:"q"`
+	// 偏移量
+	Offset int64 `json:"offset" form:"offset"`
+	// 每页数量, 默认为 10
+	Limit int64 `json:"limit" form:"limit"`
+}
+
+// 全文检索文章响应
+type SearchPostResponse struct {
+	// 命中的文章总数
+	TotalCount int64 `json:"totalCount"`
+	// 按照相关度降序排列的检索结果
+	Results []*PostSearchResult `json:"results"`
+}
+
+// 一条文章检索结果
+type PostSearchResult struct {
+	// 命中的文章
+	Post *Post `json:"post"`
+	// 相关度
+	Score float64 `json:"score"`
+	// 高亮后的标题, 命中的检索词使用 <em></em> 标记, 其余部分经过 HTML 转义
+	Title string `json:"title"`
+	// 内容中命中检索词的高亮片段, 格式与 title 相同
+	Snippet string `json:"snippet"`
 }
@@ -12,14 +12,22 @@ type User struct {
 	Nickname string `json:"nickname"`
 	// 用户电子邮箱
 	Email string `json:"email"`
+	// 用户邮箱的验证时间, 未验证邮箱时没有该字段
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty"`
 	// 用户手机号
 	Phone string `json:"phone"`
+	// 用户角色, admin 或 user
+	Role string `json:"role"`
 	// 用户拥有的博客数量
 	PostCount int64 `json:"postCount"`
+	// 用户版本号, 每次更新后加 1, 与 ETag 响应头一致
+	Version int64 `json:"version"`
 	// 用户注册时间
 	CreatedAt time.Time `json:"createdAt"`
 	// 用户最后更新时间
 	UpdatedAt time.Time `json:"updatedAt"`
+	// 用户删除时间, 只有回收站中的用户有该字段
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
 }
 
 // 创建用户请求
@@ -44,6 +52,8 @@ type CreateUserResponse struct {
 
 // 更新用户请求
 type UpdateUserRequest struct {
+	// 要更新的用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
 	// 可选的用户名称
 	Username *string `json:"username"`
 	// 可选的用户昵称
@@ -52,22 +62,66 @@ type UpdateUserRequest struct {
 	Email *string `json:"email"`
 	// 可选的用户手机号
 	Phone *string `json:"phone"`
+	// 期望的用户版本号, 来自 If-Match 请求头, 为空时不校验
+	Version *int64 `json:"-"`
 }
 
 // 更新用户响应
 type UpdateUserResponse struct {
+	// 更新后的版本号
+	Version int64 `json:"version"`
 }
 
 // 删除用户请求
 type DeleteUserRequest struct {
+	// 要删除的用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
 }
 
 // 删除用户响应
 type DeleteUserResponse struct {
 }
 
+// 恢复用户请求
+type RestoreUserRequest struct {
+	// 要恢复的用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
+}
+
+// 恢复用户响应
+type RestoreUserResponse struct {
+}
+
+// 解除用户登录锁定请求
+type UnlockUserRequest struct {
+	// 要解锁的用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
+}
+
+// 解除用户登录锁定响应
+type UnlockUserResponse struct {
+}
+
+// 查询回收站中的用户列表请求
+type ListTrashUserRequest struct {
+	// 偏移量
+	Offset int64 `json:"offset" form:"offset"`
+	// 每页数量
+	Limit int64 `json:"limit" form:"limit"`
+}
+
+// 查询回收站中的用户列表响应
+type ListTrashUserResponse struct {
+	// 回收站中的用户总数
+	TotalCount int64 `json:"totalCount"`
+	// 回收站中的用户列表, 按照删除时间降序排列
+	Users []*User `json:"users"`
+}
+
 // 获取用户请求
 type GetUserRequest struct {
+	// 要获取的用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
 }
 
 // 获取用户响应
@@ -79,17 +133,27 @@ type GetUserResponse struct {
 // 用户列表请求
 type ListUserRequest struct {
 	// 偏移量
-	Offset int64 `json:"offset"`
+	Offset int64 `json:"offset" form:"offset"`
 	// 每页数量
-	Limit int64 `json:"limit"`
+	Limit int64 `json:"limit" form:"limit"`
+	// 上一页响应中的 nextPageToken, 指定后按游标分页, 忽略 offset
+	PageToken string `json:"pageToken" form:"pageToken"`
+	// 为 true 时不统计总数, totalCount 返回 -1
+	SkipTotalCount bool `json:"skipTotalCount" form:"skipTotalCount"`
+	// 逗号分隔的过滤条件, 例如 `createdAt>2025-01-01,username~li`
+	Filter string `json:"filter" form:"filter"`
+	// 逗号分隔的排序字段, 字段前加 `-` 表示降序, 例如 `-updatedAt`, 默认按照创建顺序降序排列
+	Sort string `json:"sort" form:"sort"`
 }
 
 // 用户列表响应
 type ListUserResponse struct {
-	// 总用户数
+	// 总用户数, 不统计总数时为 -1
 	TotalCount int64 `json:"totalCount"`
 	// 用户列表
 	Users []*User `json:"users"`
+	// 下一页的游标, 为空表示没有下一页
+	NextPageToken string `json:"nextPageToken,omitempty"`
 }
 
 // LoginRequest 表示登录请求
@@ -101,15 +165,36 @@ type LoginRequest struct {
 }
 
 // LoginResponse 表示登录响应
+// 用户启用了两步验证时, 只返回 twoFactorRequired 和登录挑战令牌, 需要调用 POST /login/2fa 完成登录
 type LoginResponse struct {
 	// token 表示返回的身份验证令牌
-	Token string `json:"token"`
+	Token string `json:"token,omitempty"`
 	// expireAt 表示该 token 的过期时间
-	ExpireAt time.Time `json:"expireAt"`
+	ExpireAt time.Time `json:"expireAt,omitzero"`
+	// refreshToken 表示用于换取新身份验证令牌的刷新令牌
+	RefreshToken string `json:"refreshToken,omitempty"`
+	// refreshExpireAt 表示该 refreshToken 的过期时间
+	RefreshExpireAt time.Time `json:"refreshExpireAt,omitzero"`
+	// twoFactorRequired 表示密码校验通过, 还需要提交两步验证码
+	TwoFactorRequired bool `json:"twoFactorRequired,omitempty"`
+	// challengeToken 表示完成两步验证时需要提交的登录挑战令牌
+	ChallengeToken string `json:"challengeToken,omitempty"`
+	// challengeExpireAt 表示该登录挑战令牌的过期时间
+	ChallengeExpireAt time.Time `json:"challengeExpireAt,omitzero"`
+}
+
+// CompleteLoginRequest 表示使用两步验证码完成登录的请求
+type CompleteLoginRequest struct {
+	// challengeToken 表示登录时返回的登录挑战令牌
+	ChallengeToken string `json:"challengeToken"`
+	// code 表示验证器应用生成的 6 位验证码, 或者一个未使用过的恢复码
+	Code string `json:"code"`
 }
 
 // RefreshTokenRequest 表示刷新令牌的请求
 type RefreshTokenRequest struct {
+	// refreshToken 表示登录或上次刷新时返回的刷新令牌
+	RefreshToken string `json:"refreshToken"`
 }
 
 // RefreshTokenResponse 表示刷新令牌的响应
@@ -118,10 +203,42 @@ type RefreshTokenResponse struct {
 	Token string `json:"token"`
 	// expireAt 表示该 token 的过期时间
 	ExpireAt time.Time `json:"expireAt"`
+	// refreshToken 表示轮换后的新刷新令牌, 旧的刷新令牌随即失效
+	RefreshToken string `json:"refreshToken"`
+	// refreshExpireAt 表示该 refreshToken 的过期时间
+	RefreshExpireAt time.Time `json:"refreshExpireAt"`
 }
 
+// UpdateUserRoleRequest 表示修改用户角色的请求，仅管理员可以调用
+type UpdateUserRoleRequest struct {
+	// userID 表示要修改角色的用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
+	// role 表示新的角色，admin 或 user
+	Role string `json:"role"`
+}
+
+// UpdateUserRoleResponse 表示修改用户角色的响应
+type UpdateUserRoleResponse struct{}
+
+// LogoutRequest 表示退出登录的请求
+type LogoutRequest struct {
+	// refreshToken 表示登录时返回的刷新令牌，可选；传入时同时吊销该刷新令牌所在的家族
+	RefreshToken string `json:"refreshToken"`
+}
+
+// LogoutResponse 表示退出登录的响应
+type LogoutResponse struct{}
+
+// LogoutAllRequest 表示退出所有设备的请求
+type LogoutAllRequest struct{}
+
+// LogoutAllResponse 表示退出所有设备的响应
+type LogoutAllResponse struct{}
+
 // ChangePasswordRequest 表示修改密码请求
 type ChangePasswordRequest struct {
+	// userID 表示要修改密码的用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
 	// oldPassword 表示当前密码
 	OldPassword string `json:"oldPassword"`
 	// newPassword 表示准备修改的新密码
@@ -131,3 +248,179 @@ type ChangePasswordRequest struct {
 // ChangePasswordResponse 表示修改密码响应
 type ChangePasswordResponse struct {
 }
+
+// SendVerificationEmailRequest 表示重新发送验证邮件的请求
+type SendVerificationEmailRequest struct {
+	// email 表示需要验证的邮箱，向使用该邮箱且未验证邮箱的用户发送验证邮件
+	Email string `json:"email"`
+}
+
+// SendVerificationEmailResponse 表示重新发送验证邮件的响应，无论邮箱是否存在都返回成功，避免邮箱被枚举
+type SendVerificationEmailResponse struct{}
+
+// VerifyEmailRequest 表示验证邮箱的请求
+type VerifyEmailRequest struct {
+	// token 表示验证邮件中链接携带的验证令牌
+	Token string `json:"token" form:"token"`
+}
+
+// VerifyEmailResponse 表示验证邮箱的响应
+type VerifyEmailResponse struct{}
+
+// RequestPasswordResetRequest 表示找回密码的请求
+type RequestPasswordResetRequest struct {
+	// email 表示用户的邮箱，向使用该邮箱的用户发送重置密码邮件
+	Email string `json:"email"`
+}
+
+// RequestPasswordResetResponse 表示找回密码的响应，无论邮箱是否存在都返回成功，避免邮箱被枚举
+type RequestPasswordResetResponse struct{}
+
+// ConfirmPasswordResetRequest 表示重置密码的请求
+type ConfirmPasswordResetRequest struct {
+	// token 表示重置密码邮件中链接携带的重置令牌
+	Token string `json:"token"`
+	// newPassword 表示新密码
+	NewPassword string `json:"newPassword"`
+}
+
+// ConfirmPasswordResetResponse 表示重置密码的响应
+type ConfirmPasswordResetResponse struct{}
+
+// EnrollTOTPRequest 表示绑定验证器应用的请求，只能为自己绑定
+type EnrollTOTPRequest struct {
+	// userID 表示用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
+	// password 表示用户当前的密码，用于确认是用户本人操作
+	Password string `json:"password"`
+}
+
+// EnrollTOTPResponse 表示绑定验证器应用的响应，需要调用确认接口后两步验证才会启用
+type EnrollTOTPResponse struct {
+	// secret 表示 base32 编码的 TOTP 密钥，可以手动输入到验证器应用中
+	Secret string `json:"secret"`
+	// uri 表示 otpauth URI，一般生成二维码供验证器应用扫描
+	URI string `json:"uri"`
+}
+
+// ConfirmTOTPRequest 表示确认绑定并启用两步验证的请求
+type ConfirmTOTPRequest struct {
+	// userID 表示用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
+	// code 表示验证器应用生成的 6 位验证码
+	Code string `json:"code"`
+}
+
+// ConfirmTOTPResponse 表示确认绑定并启用两步验证的响应
+type ConfirmTOTPResponse struct {
+	// recoveryCodes 表示一次性恢复码，只返回这一次，需要提示用户妥善保存
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// DisableTOTPRequest 表示关闭两步验证的请求
+type DisableTOTPRequest struct {
+	// userID 表示用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
+	// code 表示验证码或恢复码，用户关闭自己的两步验证时必填，管理员关闭其他用户的两步验证时不需要
+	Code string `json:"code"`
+}
+
+// DisableTOTPResponse 表示关闭两步验证的响应
+type DisableTOTPResponse struct{}
+
+// RegenerateRecoveryCodesRequest 表示重新生成恢复码的请求，原有的恢复码全部失效
+type RegenerateRecoveryCodesRequest struct {
+	// userID 表示用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
+	// code 表示验证器应用生成的 6 位验证码或者一个未使用过的恢复码
+	Code string `json:"code"`
+}
+
+// RegenerateRecoveryCodesResponse 表示重新生成恢复码的响应
+type RegenerateRecoveryCodesResponse struct {
+	// recoveryCodes 表示新的一次性恢复码，只返回这一次，需要提示用户妥善保存
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// ExternalIdentity 表示用户绑定的外部身份
+type ExternalIdentity struct {
+	// provider 表示身份提供方名称
+	Provider string `json:"provider"`
+	// subject 表示用户在身份提供方中的唯一标识
+	Subject string `json:"subject"`
+	// email 表示身份提供方返回的邮箱
+	Email string `json:"email"`
+	// lastLoginAt 表示最近一次使用该身份登录的时间，从未使用该身份登录时为空
+	LastLoginAt *time.Time `json:"lastLoginAt"`
+	// createdAt 表示绑定时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AuthorizeOIDCRequest 表示使用外部身份提供方登录的请求
+type AuthorizeOIDCRequest struct {
+	// provider 表示身份提供方名称，对应 {provider}
+	Provider string `json:"provider" uri:"provider"`
+}
+
+// AuthorizeOIDCResponse 表示跳转到外部身份提供方的响应
+type AuthorizeOIDCResponse struct {
+	// authorizationURL 表示身份提供方的授权地址，需要在浏览器中打开
+	AuthorizationURL string `json:"authorizationURL"`
+	// Session 表示需要保存在浏览器 cookie 中、回调时原样提交的会话信息，不在响应中返回
+	Session string `json:"-"`
+}
+
+// LinkIdentityRequest 表示为用户绑定外部身份的请求，只能为自己绑定
+type LinkIdentityRequest struct {
+	// userID 表示用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
+	// provider 表示身份提供方名称，对应 {provider}
+	Provider string `json:"provider" uri:"provider"`
+}
+
+// OIDCCallbackRequest 表示外部身份提供方授权完成后的回调请求
+type OIDCCallbackRequest struct {
+	// provider 表示身份提供方名称，对应 {provider}
+	Provider string `json:"provider" uri:"provider"`
+	// code 表示身份提供方返回的授权码
+	Code string `json:"code" form:"code"`
+	// state 表示发起授权时传递给身份提供方的 state
+	State string `json:"state" form:"state"`
+	// error 表示身份提供方返回的错误，例如用户拒绝授权
+	Error string `json:"error" form:"error"`
+	// Session 表示发起授权时保存在浏览器 cookie 中的会话信息
+	Session string `json:"-" form:"-"`
+}
+
+// OIDCCallbackResponse 表示外部身份提供方回调的响应
+// 登录时返回身份验证令牌或者登录挑战令牌，绑定外部身份时返回绑定的外部身份
+type OIDCCallbackResponse struct {
+	LoginResponse
+	// identity 表示新绑定的外部身份，只在绑定外部身份时返回
+	Identity *ExternalIdentity `json:"identity,omitempty"`
+}
+
+// ListIdentitiesRequest 表示查询用户绑定的外部身份的请求
+type ListIdentitiesRequest struct {
+	// userID 表示用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
+}
+
+// ListIdentitiesResponse 表示查询用户绑定的外部身份的响应
+type ListIdentitiesResponse struct {
+	// 外部身份总数
+	TotalCount int64 `json:"totalCount"`
+	// 外部身份列表
+	Identities []*ExternalIdentity `json:"identities"`
+}
+
+// UnlinkIdentityRequest 表示解除绑定外部身份的请求
+type UnlinkIdentityRequest struct {
+	// userID 表示用户 ID，对应 {userID}
+	UserID string `json:"userID" uri:"userID"`
+	// provider 表示身份提供方名称，对应 {provider}
+	Provider string `json:"provider" uri:"provider"`
+}
+
+// UnlinkIdentityResponse 表示解除绑定外部身份的响应
+type UnlinkIdentityResponse struct{}
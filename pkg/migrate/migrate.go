@@ -0,0 +1,356 @@
+// Package migrate 实现了基于 SQL 文件的版本化数据库迁移.
+//
+// 迁移文件命名格式为 `<version>_<name>.up.sql` 和 `<version>_<name>.down.sql`,
+// 其中 version 为递增的数字, 迁移按照 version 从小到大的顺序执行.
+// 已执行的迁移记录在 schema_migrations 表中.
+//
+// 一个迁移文件中可以包含多条 SQL 语句, 语句之间以行尾的分号 `;` 分隔.
+//
+// 每个迁移在一个事务中执行, 但 MySQL 的 DDL 语句会隐式提交事务, 迁移执行失败时已经执行的 DDL 无法回滚.
+// 因此执行迁移之前会先将其记录为 dirty, 执行成功后再清除; 存在 dirty 的迁移时拒绝继续执行,
+// 需要人工修复数据库后使用 Force 标记迁移的实际状态. 为了便于修复, 每个迁移文件应只包含一条 DDL 语句.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TableName 是记录迁移版本的表名.
+const TableName = "schema_migrations"
+
+// ErrDirty 表示存在执行失败的迁移, 数据库可能处于部分迁移的状态.
+var ErrDirty = errors.New("database is dirty")
+
+// fileRegexp 匹配迁移文件名, 例如: 000001_init_schema.up.sql
+var fileRegexp = regexp.MustCompile(`^(\d+)_([a-zA-Z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration 表示一个版本的迁移.
+type Migration struct {
+	// Version 为迁移版本号.
+	Version int64
+	// Name 为迁移名称.
+	Name string
+	// Up 为升级时执行的 SQL.
+	Up string
+	// Down 为回滚时执行的 SQL.
+	Down string
+}
+
+// Status 表示一个迁移的执行状态.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	// Dirty 表示迁移执行失败, 需要人工修复.
+	Dirty bool
+}
+
+// schemaMigration 是 schema_migrations 表对应的模型.
+type schemaMigration struct {
+	Version   int64     `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name;not null"`
+	AppliedAt time.Time `gorm:"column:appliedAt;not null"`
+	// Dirty 表示迁移已开始执行但没有执行成功.
+	Dirty bool `gorm:"column:dirty;not null;default:false"`
+}
+
+// TableName 返回 schema_migrations 表名.
+func (*schemaMigration) TableName() string {
+	return TableName
+}
+
+// Migrator 负责执行数据库迁移.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []*Migration
+}
+
+// New 从 fsys 中读取迁移文件, 创建一个 Migrator 实例.
+// fsys 根目录下应直接存放迁移文件.
+func New(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load 从 fsys 中读取所有迁移文件, 并按照版本号升序返回.
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := fileRegexp.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, _ := strconv.ParseInt(matches[1], 10, 64)
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		}
+		if m.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names: %s, %s", version, m.Name, matches[2])
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if matches[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// ensureTable 确保 schema_migrations 表存在.
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	return m.db.WithContext(ctx).AutoMigrate(&schemaMigration{})
+}
+
+// applied 返回所有已执行的迁移, key 为版本号.
+func (m *Migrator) applied(ctx context.Context) (map[int64]*schemaMigration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var rows []*schemaMigration
+	if err := m.db.WithContext(ctx).Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	ret := make(map[int64]*schemaMigration, len(rows))
+	for _, row := range rows {
+		ret[row.Version] = row
+	}
+	return ret, nil
+}
+
+// checkDirty 存在 dirty 的迁移时返回 ErrDirty.
+func checkDirty(applied map[int64]*schemaMigration) error {
+	for _, row := range applied {
+		if row.Dirty {
+			return fmt.Errorf("%w: migration %d_%s failed, fix the database manually and then force its version", ErrDirty, row.Version, row.Name)
+		}
+	}
+	return nil
+}
+
+// Up 按版本号升序执行所有未执行的迁移, 返回本次执行的迁移.
+// 存在 dirty 的迁移时返回 ErrDirty.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDirty(applied); err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		// 先在事务之外记录为 dirty, 执行失败时 dirty 记录会保留下来
+		row := &schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now(), Dirty: true}
+		if err := m.db.WithContext(ctx).Create(row).Error; err != nil {
+			return done, fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, migration.Up); err != nil {
+				return err
+			}
+			return tx.Model(row).Updates(map[string]any{"dirty": false, "appliedAt": time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down 按版本号降序回滚最近执行的 steps 个迁移, 返回本次回滚的迁移.
+// 存在 dirty 的迁移时返回 ErrDirty.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDirty(applied); err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		row := &schemaMigration{Version: migration.Version}
+		if err := m.db.WithContext(ctx).Model(row).Update("dirty", true).Error; err != nil {
+			return done, fmt.Errorf("failed to rollback migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, migration.Down); err != nil {
+				return err
+			}
+			return tx.Delete(row).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("failed to rollback migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Status 返回所有迁移的执行状态.
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := &Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			status.Applied = !row.Dirty
+			status.AppliedAt = &row.AppliedAt
+			status.Dirty = row.Dirty
+		}
+		ret = append(ret, status)
+	}
+	return ret, nil
+}
+
+// Force 在人工修复数据库之后标记版本为 version 的迁移的实际状态并清除 dirty 标记.
+// applied 为 true 时将迁移标记为已执行, 否则标记为未执行.
+func (m *Migrator) Force(ctx context.Context, version int64, applied bool) error {
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	var migration *Migration
+	for _, mi := range m.migrations {
+		if mi.Version == version {
+			migration = mi
+		}
+	}
+	if migration == nil {
+		return fmt.Errorf("migration version %d not found", version)
+	}
+
+	db := m.db.WithContext(ctx)
+	if !applied {
+		return db.Delete(&schemaMigration{Version: version}).Error
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "version"}},
+		DoUpdates: clause.AssignmentColumns([]string{"dirty", "appliedAt"}),
+	}).Create(&schemaMigration{Version: version, Name: migration.Name, AppliedAt: time.Now()}).Error
+}
+
+// Create 在 dir 目录下创建一对新的空迁移文件, 版本号为目录中已有最大版本号加 1.
+// 返回创建的文件路径.
+func Create(dir string, name string) ([]string, error) {
+	if !regexp.MustCompile(`^[a-zA-Z0-9_]+$`).MatchString(name) {
+		return nil, fmt.Errorf("invalid migration name '%s': only letters, digits and underscores are allowed", name)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	var version int64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	var files []string
+	for _, direction := range []string{"up", "down"} {
+		file := filepath.Join(dir, fmt.Sprintf("%06d_%s.%s.sql", version, name, direction))
+		content := fmt.Sprintf("-- %s migration for %06d_%s\n", direction, version, name)
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// execScript 依次执行 script 中以分号分隔的 SQL 语句.
+func execScript(tx *gorm.DB, script string) error {
+	for _, stmt := range splitStatements(script) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements 将 SQL 脚本按照行尾的分号拆分为多条语句, 并忽略 `--` 开头的注释行.
+func splitStatements(script string) []string {
+	var (
+		stmts []string
+		buf   strings.Builder
+	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(buf.String()))
+			buf.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(buf.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
@@ -0,0 +1,125 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestMigrator 创建一个使用临时 SQLite 数据库的 Migrator, 迁移文件为 files.
+func newTestMigrator(t *testing.T, files fstest.MapFS) (*Migrator, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	m, err := New(db, files)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return m, db
+}
+
+// wantStatus 校验所有迁移的执行状态, want 中依次为各个迁移的 pending、applied 或 dirty.
+func wantStatus(t *testing.T, m *Migrator, want ...string) {
+	t.Helper()
+
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	for i, s := range statuses {
+		got := "pending"
+		if s.Applied {
+			got = "applied"
+		}
+		if s.Dirty {
+			got = "dirty"
+		}
+		if got != want[i] {
+			t.Errorf("migration %d is %s, want %s", s.Version, got, want[i])
+		}
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t, fstest.MapFS{
+		"000001_create_a.up.sql":   {Data: []byte("-- 创建 a 表\nCREATE TABLE a (id INTEGER);")},
+		"000001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		"000002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER);\nCREATE INDEX idx_b_id ON b (id);")},
+		"000002_create_b.down.sql": {Data: []byte("DROP TABLE b;")},
+	})
+
+	done, err := m.Up(ctx)
+	if err != nil || len(done) != 2 {
+		t.Fatalf("Up() = %d migrations, %v, want 2", len(done), err)
+	}
+	wantStatus(t, m, "applied", "applied")
+	if !db.Migrator().HasTable("b") {
+		t.Error("table b is not created")
+	}
+
+	done, err = m.Down(ctx, 1)
+	if err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("Down() = %v, %v, want migration 2", done, err)
+	}
+	wantStatus(t, m, "applied", "pending")
+	if db.Migrator().HasTable("b") {
+		t.Error("table b is not dropped")
+	}
+}
+
+func TestDirty(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t, fstest.MapFS{
+		"000001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"000001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		"000002_broken.up.sql":     {Data: []byte("CREATE TABLE b (id INTEGER);\nCREATE INDEX idx_c ON c (id);")},
+		"000002_broken.down.sql":   {Data: []byte("DROP TABLE b;")},
+		"000003_create_d.up.sql":   {Data: []byte("CREATE TABLE d (id INTEGER);")},
+		"000003_create_d.down.sql": {Data: []byte("DROP TABLE d;")},
+	})
+
+	// 执行失败的迁移保留 dirty 标记, 后续的迁移不再执行
+	done, err := m.Up(ctx)
+	if err == nil || len(done) != 1 {
+		t.Fatalf("Up() = %d migrations, %v, want 1 and an error", len(done), err)
+	}
+	wantStatus(t, m, "applied", "dirty", "pending")
+
+	// 存在 dirty 的迁移时拒绝继续执行和回滚
+	if _, err := m.Up(ctx); !errors.Is(err, ErrDirty) {
+		t.Errorf("Up() error = %v, want %v", err, ErrDirty)
+	}
+	if _, err := m.Down(ctx, 1); !errors.Is(err, ErrDirty) {
+		t.Errorf("Down() error = %v, want %v", err, ErrDirty)
+	}
+
+	// 人工修复数据库之后标记迁移的实际状态
+	if err := db.Exec("CREATE TABLE b (id INTEGER)").Error; err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if err := m.Force(ctx, 2, true); err != nil {
+		t.Fatalf("Force() error = %v", err)
+	}
+	wantStatus(t, m, "applied", "applied", "pending")
+	if done, err := m.Up(ctx); err != nil || len(done) != 1 {
+		t.Fatalf("Up() after Force() = %d migrations, %v, want 1", len(done), err)
+	}
+
+	if err := m.Force(ctx, 3, false); err != nil {
+		t.Fatalf("Force() error = %v", err)
+	}
+	wantStatus(t, m, "applied", "applied", "pending")
+	if err := m.Force(ctx, 4, true); err == nil {
+		t.Error("Force() of an unknown version error = nil")
+	}
+}
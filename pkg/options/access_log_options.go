@@ -0,0 +1,55 @@
+package options
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// 支持的访问日志格式.
+const (
+	// AccessLogFormatJSON 每个请求输出一行 JSON.
+	AccessLogFormatJSON = "json"
+	// AccessLogFormatCLF 使用 Common Log Format, 例如 `127.0.0.1 - user-xxx [10/Oct/2000:13:55:36 -0700] "GET /v1/posts HTTP/1.1" 200 2326`.
+	AccessLogFormatCLF = "clf"
+	// AccessLogFormatNone 不记录访问日志.
+	AccessLogFormatNone = "none"
+)
+
+// AccessLogOptions defines options for the HTTP access log.
+type AccessLogOptions struct {
+	// Format 为访问日志格式, 支持 json、clf 和 none.
+	Format string `json:"format" mapstructure:"format"`
+	// Output 为访问日志的输出位置, 支持标准输出 stdout 和文件路径.
+	Output string `json:"output" mapstructure:"output"`
+}
+
+// NewAccessLogOptions 创建并返回一个默认的 AccessLogOptions 对象
+func NewAccessLogOptions() *AccessLogOptions {
+	return &AccessLogOptions{
+		Format: AccessLogFormatJSON,
+		Output: "stdout",
+	}
+}
+
+// Validate 校验 AccessLogOptions 中的选项是否合法.
+func (o *AccessLogOptions) Validate() error {
+	switch o.Format {
+	case AccessLogFormatJSON, AccessLogFormatCLF, AccessLogFormatNone:
+	default:
+		return fmt.Errorf("invalid access log format: %s", o.Format)
+	}
+	return nil
+}
+
+// Writer 返回访问日志的输出位置, Output 为空或 stdout 时返回标准输出, 否则以追加模式打开文件.
+func (o *AccessLogOptions) Writer() (io.Writer, error) {
+	if o.Output == "" || o.Output == "stdout" {
+		return os.Stdout, nil
+	}
+	f, err := os.OpenFile(o.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open access log file: %w", err)
+	}
+	return f, nil
+}
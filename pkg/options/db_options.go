@@ -0,0 +1,52 @@
+package options
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DriverMySQL 表示使用 MySQL 数据库.
+	DriverMySQL = "mysql"
+	// DriverSQLite 表示使用 SQLite 数据库.
+	DriverSQLite = "sqlite"
+)
+
+// DBOptions defines options for selecting database driver.
+// 根据 Driver 选择使用 MySQLOptions 或 SQLiteOptions 创建数据库连接.
+type DBOptions struct {
+	// Driver 为数据库驱动, 支持: mysql、sqlite
+	Driver string `json:"driver" mapstructure:"driver"`
+	// AutoMigrate 为 true 时, 服务启动时自动执行未执行的数据库迁移.
+	AutoMigrate bool `json:"auto-migrate" mapstructure:"auto-migrate"`
+}
+
+// NewDBOptions 创建并返回一个默认的 DBOptions 对象
+func NewDBOptions() *DBOptions {
+	return &DBOptions{
+		Driver: DriverMySQL,
+	}
+}
+
+// Validate 校验 DBOptions 中的选项是否合法.
+func (o *DBOptions) Validate() error {
+	switch o.Driver {
+	case DriverMySQL, DriverSQLite:
+		return nil
+	default:
+		return fmt.Errorf("unsupported database driver '%s', must be one of: %s, %s", o.Driver, DriverMySQL, DriverSQLite)
+	}
+}
+
+// NewDB 根据 Driver 选择对应的选项创建一个 *gorm.DB 类型的实例.
+func (o *DBOptions) NewDB(mysqlOptions *MySQLOptions, sqliteOptions *SQLiteOptions) (*gorm.DB, error) {
+	switch o.Driver {
+	case DriverSQLite:
+		return sqliteOptions.NewDB()
+	case DriverMySQL:
+		return mysqlOptions.NewDB()
+	default:
+		return nil, fmt.Errorf("unsupported database driver '%s'", o.Driver)
+	}
+}
@@ -0,0 +1,47 @@
+package options
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// EmailOptions defines options for email verification and password reset.
+// 验证邮件和重置密码邮件中的链接为 URL 加上 `token` 查询参数.
+type EmailOptions struct {
+	// VerifyURL 为验证邮箱的链接地址, 默认直接指向验证邮箱接口.
+	VerifyURL string `json:"verify-url" mapstructure:"verify-url"`
+	// ResetURL 为重置密码的链接地址, 一般指向前端页面, 由前端页面调用重置密码接口.
+	ResetURL string `json:"reset-url" mapstructure:"reset-url"`
+	// VerifyExpiration 为验证邮箱链接的有效期.
+	VerifyExpiration time.Duration `json:"verify-expiration" mapstructure:"verify-expiration"`
+	// ResetExpiration 为重置密码链接的有效期.
+	ResetExpiration time.Duration `json:"reset-expiration" mapstructure:"reset-expiration"`
+	// RequireVerified 为 true 时, 未验证邮箱的用户不能登录.
+	RequireVerified bool `json:"require-verified" mapstructure:"require-verified"`
+}
+
+// NewEmailOptions 创建并返回一个默认的 EmailOptions 对象
+func NewEmailOptions() *EmailOptions {
+	return &EmailOptions{
+		VerifyURL:        "http://127.0.0.1:6666/v1/email-verification/confirm",
+		ResetURL:         "http://127.0.0.1:6666/password-reset",
+		VerifyExpiration: 24 * time.Hour,
+		ResetExpiration:  30 * time.Minute,
+		RequireVerified:  false,
+	}
+}
+
+// Validate 校验 EmailOptions 中的选项是否合法.
+func (o *EmailOptions) Validate() error {
+	for _, link := range []string{o.VerifyURL, o.ResetURL} {
+		u, err := url.Parse(link)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("email link must be an absolute url: %q", link)
+		}
+	}
+	if o.VerifyExpiration <= 0 || o.ResetExpiration <= 0 {
+		return fmt.Errorf("email link expiration must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package options
+
+import (
+	"fmt"
+
+	"fastgo/pkg/token"
+)
+
+// JWTOptions defines options for asymmetric JWT signing keys.
+// 未配置 PrivateKeyFile 时, 使用 jwt-key 和 HS256 签发 token.
+type JWTOptions struct {
+	// KeyID 为签名密钥的标识, 签发 token 时写入 JWT 头部的 kid 字段.
+	KeyID string `json:"key-id" mapstructure:"key-id"`
+	// PrivateKeyFile 为 PEM 格式的 RSA 或 Ed25519 私钥文件, RSA 密钥使用 RS256 签名, Ed25519 密钥使用 EdDSA 签名.
+	PrivateKeyFile string `json:"private-key-file" mapstructure:"private-key-file"`
+	// VerificationKeys 为密钥轮换期间仍然接受的旧密钥, 旧密钥签发的 token 在过期之前仍然有效.
+	VerificationKeys []JWTVerificationKey `json:"verification-keys" mapstructure:"verification-keys"`
+}
+
+// JWTVerificationKey 定义一个只用于校验 token 的密钥.
+type JWTVerificationKey struct {
+	// KeyID 为密钥标识, 与 token 头部的 kid 字段对应.
+	KeyID string `json:"key-id" mapstructure:"key-id"`
+	// File 为 PEM 格式的公钥(或私钥)文件.
+	File string `json:"file" mapstructure:"file"`
+}
+
+// NewJWTOptions 创建并返回一个默认的 JWTOptions 对象
+func NewJWTOptions() *JWTOptions {
+	return &JWTOptions{}
+}
+
+// Validate 校验 JWTOptions 中的选项是否合法.
+func (o *JWTOptions) Validate() error {
+	if o.PrivateKeyFile == "" {
+		if len(o.VerificationKeys) > 0 {
+			return fmt.Errorf("jwt verification keys require a private key file")
+		}
+		return nil
+	}
+	if o.KeyID == "" {
+		return fmt.Errorf("jwt key id cannot be empty")
+	}
+
+	kids := map[string]bool{o.KeyID: true}
+	for _, key := range o.VerificationKeys {
+		if key.KeyID == "" || key.File == "" {
+			return fmt.Errorf("jwt verification key id and file cannot be empty")
+		}
+		if kids[key.KeyID] {
+			return fmt.Errorf("duplicate jwt key id '%s'", key.KeyID)
+		}
+		kids[key.KeyID] = true
+	}
+	return nil
+}
+
+// Enabled 返回是否配置了非对称签名密钥.
+func (o *JWTOptions) Enabled() bool {
+	return o.PrivateKeyFile != ""
+}
+
+// Keys 加载签名密钥和校验密钥.
+func (o *JWTOptions) Keys() (*token.Key, []*token.Key, error) {
+	signing, err := token.LoadPrivateKeyFile(o.KeyID, o.PrivateKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	verification := make([]*token.Key, 0, len(o.VerificationKeys))
+	for _, k := range o.VerificationKeys {
+		key, err := token.LoadPublicKeyFile(k.KeyID, k.File)
+		if err != nil {
+			return nil, nil, err
+		}
+		verification = append(verification, key)
+	}
+	return signing, verification, nil
+}
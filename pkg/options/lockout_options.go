@@ -0,0 +1,52 @@
+package options
+
+import (
+	"fmt"
+	"time"
+)
+
+// LockoutOptions defines options for login brute-force protection.
+// 按照用户名和客户端 IP 记录连续登录失败的次数, 失败后逐渐延长响应时间, 达到阈值后临时锁定.
+type LockoutOptions struct {
+	// Backend 为失败记录的存储后端.
+	Backend string `json:"backend" mapstructure:"backend"`
+	// MaxFailures 为锁定用户名之前允许的连续失败次数.
+	MaxFailures int `json:"max-failures" mapstructure:"max-failures"`
+	// MaxIPFailures 为锁定客户端 IP 之前允许的连续失败次数.
+	MaxIPFailures int `json:"max-ip-failures" mapstructure:"max-ip-failures"`
+	// Window 为失败记录的有效期, 超过该时间没有再次失败时重新计数.
+	Window time.Duration `json:"window" mapstructure:"window"`
+	// Duration 为锁定时长.
+	Duration time.Duration `json:"duration" mapstructure:"duration"`
+	// BaseDelay 为第一次失败后的响应延迟, 之后每次失败翻倍, 0 表示不延迟.
+	BaseDelay time.Duration `json:"base-delay" mapstructure:"base-delay"`
+	// MaxDelay 为响应延迟的上限.
+	MaxDelay time.Duration `json:"max-delay" mapstructure:"max-delay"`
+}
+
+// NewLockoutOptions 创建并返回一个默认的 LockoutOptions 对象
+func NewLockoutOptions() *LockoutOptions {
+	return &LockoutOptions{
+		Backend:       "memory",
+		MaxFailures:   5,
+		MaxIPFailures: 20,
+		Window:        15 * time.Minute,
+		Duration:      15 * time.Minute,
+		BaseDelay:     500 * time.Millisecond,
+		MaxDelay:      5 * time.Second,
+	}
+}
+
+// Validate 校验 LockoutOptions 中的选项是否合法.
+func (o *LockoutOptions) Validate() error {
+	if o.MaxFailures < 1 || o.MaxIPFailures < 1 {
+		return fmt.Errorf("lockout max failures must be at least 1")
+	}
+	if o.Window <= 0 || o.Duration <= 0 {
+		return fmt.Errorf("lockout window and duration must be positive")
+	}
+	if o.BaseDelay < 0 || o.MaxDelay < o.BaseDelay {
+		return fmt.Errorf("lockout max delay must not be less than base delay")
+	}
+	return nil
+}
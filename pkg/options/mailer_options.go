@@ -0,0 +1,63 @@
+package options
+
+import (
+	"fmt"
+	"net/mail"
+)
+
+// 支持的邮件发送方式.
+const (
+	// MailerBackendStdout 将邮件写入标准输出或文件, 适用于开发和测试环境.
+	MailerBackendStdout = "stdout"
+	// MailerBackendSMTP 通过 SMTP 服务器发送邮件.
+	MailerBackendSMTP = "smtp"
+)
+
+// MailerOptions defines options for sending emails.
+type MailerOptions struct {
+	// Backend 为邮件发送方式, 支持 stdout 和 smtp.
+	Backend string `json:"backend" mapstructure:"backend"`
+	// Output 为 stdout 方式的输出位置, 支持标准输出 stdout 和文件路径.
+	Output string `json:"output" mapstructure:"output"`
+	// From 为发件人邮箱.
+	From string `json:"from" mapstructure:"from"`
+	// Host 为 SMTP 服务器地址.
+	Host string `json:"host" mapstructure:"host"`
+	// Port 为 SMTP 服务器端口.
+	Port int `json:"port" mapstructure:"port"`
+	// Username 为 SMTP 认证用户名, 为空时不认证.
+	Username string `json:"username" mapstructure:"username"`
+	// Password 为 SMTP 认证密码.
+	Password string `json:"password" mapstructure:"password"`
+}
+
+// NewMailerOptions 创建并返回一个默认的 MailerOptions 对象
+func NewMailerOptions() *MailerOptions {
+	return &MailerOptions{
+		Backend: MailerBackendStdout,
+		Output:  "stdout",
+		From:    "fastgo <noreply@fastgo.local>",
+		Host:    "127.0.0.1",
+		Port:    587,
+	}
+}
+
+// Validate 校验 MailerOptions 中的选项是否合法.
+func (o *MailerOptions) Validate() error {
+	if _, err := mail.ParseAddress(o.From); err != nil {
+		return fmt.Errorf("invalid mailer from address %q: %w", o.From, err)
+	}
+	switch o.Backend {
+	case MailerBackendStdout:
+		if o.Output == "" {
+			return fmt.Errorf("mailer output cannot be empty when using stdout backend")
+		}
+	case MailerBackendSMTP:
+		if o.Host == "" || o.Port < 1 || o.Port > 65535 {
+			return fmt.Errorf("invalid smtp server address: %s:%d", o.Host, o.Port)
+		}
+	default:
+		return fmt.Errorf("invalid mailer backend: %s", o.Backend)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package options
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// MetricsOptions defines options for the Prometheus metrics endpoint.
+type MetricsOptions struct {
+	// Addr 为监控指标的监听地址, 为空时通过 API 服务的监听地址暴露.
+	// 单独监听可以避免将监控指标暴露到公网.
+	Addr string `json:"addr" mapstructure:"addr"`
+	// Path 为监控指标的请求路径.
+	Path string `json:"path" mapstructure:"path"`
+}
+
+// NewMetricsOptions 创建并返回一个默认的 MetricsOptions 对象
+func NewMetricsOptions() *MetricsOptions {
+	return &MetricsOptions{
+		Addr: "",
+		Path: "/metrics",
+	}
+}
+
+// Validate 校验 MetricsOptions 中的选项是否合法.
+func (o *MetricsOptions) Validate() error {
+	if o.Addr != "" {
+		if _, _, err := net.SplitHostPort(o.Addr); err != nil {
+			return fmt.Errorf("invalid metrics address format '%s': %w", o.Addr, err)
+		}
+	}
+	if !strings.HasPrefix(o.Path, "/") {
+		return fmt.Errorf("metrics path must start with '/': %s", o.Path)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package options
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// OIDCOptions defines options for signing in through external OpenID Connect providers.
+// 每个身份提供方的回调地址为 CallbackURL + "/" + Name + "/callback", 需要在身份提供方中注册.
+type OIDCOptions struct {
+	// CallbackURL 为 OIDC 回调地址的前缀, 需要是浏览器可以访问的 apiserver 地址.
+	CallbackURL string `json:"callback-url" mapstructure:"callback-url"`
+	// StateExpiration 为一次 OIDC 登录从发起到回调的最长时间.
+	StateExpiration time.Duration `json:"state-expiration" mapstructure:"state-expiration"`
+	// Providers 为可以用于登录的身份提供方, 为空时不启用 OIDC 登录.
+	Providers []OIDCProviderOptions `json:"providers" mapstructure:"providers"`
+}
+
+// OIDCProviderOptions 定义一个 OIDC 身份提供方.
+type OIDCProviderOptions struct {
+	// Name 为身份提供方的名称, 用于登录地址和外部身份记录, 配置后不应修改.
+	Name string `json:"name" mapstructure:"name"`
+	// Issuer 为身份提供方的标识, 元数据地址为 Issuer + "/.well-known/openid-configuration".
+	Issuer string `json:"issuer" mapstructure:"issuer"`
+	// ClientID 为在身份提供方注册的客户端 ID.
+	ClientID string `json:"client-id" mapstructure:"client-id"`
+	// ClientSecret 为客户端密钥, 为空时作为公共客户端.
+	ClientSecret string `json:"client-secret" mapstructure:"client-secret"`
+	// Scopes 为请求的权限范围, 为空时使用 openid、email 和 profile.
+	Scopes []string `json:"scopes" mapstructure:"scopes"`
+	// AutoProvision 表示外部身份没有绑定用户时, 是否在第一次登录时自动创建用户.
+	AutoProvision bool `json:"auto-provision" mapstructure:"auto-provision"`
+}
+
+// NewOIDCOptions 创建并返回一个默认的 OIDCOptions 对象
+func NewOIDCOptions() *OIDCOptions {
+	return &OIDCOptions{
+		CallbackURL:     "http://127.0.0.1:6666/v1/oidc",
+		StateExpiration: 10 * time.Minute,
+	}
+}
+
+// Validate 校验 OIDCOptions 中的选项是否合法.
+func (o *OIDCOptions) Validate() error {
+	if len(o.Providers) == 0 {
+		return nil
+	}
+	if u, err := url.Parse(o.CallbackURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("oidc callback url must be an absolute http(s) url, got '%s'", o.CallbackURL)
+	}
+	if o.StateExpiration <= 0 {
+		return fmt.Errorf("oidc state expiration must be positive")
+	}
+
+	names := map[string]bool{}
+	for _, p := range o.Providers {
+		if p.Name == "" || p.Issuer == "" || p.ClientID == "" {
+			return fmt.Errorf("oidc provider name, issuer and client id cannot be empty")
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate oidc provider '%s'", p.Name)
+		}
+		names[p.Name] = true
+		if len(p.Scopes) > 0 && !slices.Contains(p.Scopes, "openid") {
+			return fmt.Errorf("oidc provider '%s' scopes must include openid", p.Name)
+		}
+	}
+	return nil
+}
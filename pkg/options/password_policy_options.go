@@ -0,0 +1,55 @@
+package options
+
+import (
+	"fmt"
+)
+
+// PasswordPolicyOptions defines options for the password policy.
+// 创建用户和修改密码时, 新密码需要满足所有启用的规则.
+type PasswordPolicyOptions struct {
+	// MinLength 为密码的最小字符数.
+	MinLength int `json:"min-length" mapstructure:"min-length"`
+	// MaxLength 为密码的最大字符数.
+	MaxLength int `json:"max-length" mapstructure:"max-length"`
+	// RequireUpper 要求密码包含大写字母.
+	RequireUpper bool `json:"require-upper" mapstructure:"require-upper"`
+	// RequireLower 要求密码包含小写字母.
+	RequireLower bool `json:"require-lower" mapstructure:"require-lower"`
+	// RequireDigit 要求密码包含数字.
+	RequireDigit bool `json:"require-digit" mapstructure:"require-digit"`
+	// RequireSymbol 要求密码包含特殊字符.
+	RequireSymbol bool `json:"require-symbol" mapstructure:"require-symbol"`
+	// RejectUsername 禁止密码中包含用户名.
+	RejectUsername bool `json:"reject-username" mapstructure:"reject-username"`
+	// BlocklistFile 为常见或已泄露的密码列表文件, 每行一个密码, 为空时不校验.
+	BlocklistFile string `json:"blocklist-file" mapstructure:"blocklist-file"`
+	// History 为不能重复使用的最近密码个数(包括当前密码), 0 表示不限制.
+	History int `json:"history" mapstructure:"history"`
+}
+
+// NewPasswordPolicyOptions 创建并返回一个默认的 PasswordPolicyOptions 对象
+func NewPasswordPolicyOptions() *PasswordPolicyOptions {
+	return &PasswordPolicyOptions{
+		MinLength:      8,
+		MaxLength:      64,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSymbol:  false,
+		RejectUsername: true,
+		BlocklistFile:  "",
+		History:        5,
+	}
+}
+
+// Validate 校验 PasswordPolicyOptions 中的选项是否合法.
+// 登录时只接受 8 到 64 个字符的密码, 因此长度限制不能超出该范围.
+func (o *PasswordPolicyOptions) Validate() error {
+	if o.MinLength < 8 || o.MaxLength > 64 || o.MinLength > o.MaxLength {
+		return fmt.Errorf("password length must be within 8 and 64 characters, got min %d and max %d", o.MinLength, o.MaxLength)
+	}
+	if o.History < 0 {
+		return fmt.Errorf("password history cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package options
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// 支持的限流键.
+const (
+	// RateLimitKeyIP 按照客户端 IP 限流.
+	RateLimitKeyIP = "ip"
+	// RateLimitKeyUser 按照用户 ID 限流, 未认证的请求按照客户端 IP 限流.
+	RateLimitKeyUser = "user"
+	// RateLimitKeyAPIKey 按照请求头中的 API Key 限流, 没有 API Key 或者 API Key 不在 APIKeys 中的请求按照客户端 IP 限流.
+	RateLimitKeyAPIKey = "api-key"
+)
+
+// RateLimitRule defines the token bucket rule of a route group.
+type RateLimitRule struct {
+	// Rate 为每秒允许的平均请求数, 0 表示不限流.
+	Rate float64 `json:"rate" mapstructure:"rate"`
+	// Burst 为允许连续发送的最大请求数.
+	Burst int `json:"burst" mapstructure:"burst"`
+	// Key 为限流键, 支持 ip、user 和 api-key.
+	Key string `json:"key" mapstructure:"key"`
+}
+
+// RateLimitOptions defines options for rate limiting.
+type RateLimitOptions struct {
+	// Backend 为限流状态的存储后端.
+	Backend string `json:"backend" mapstructure:"backend"`
+	// APIKeyHeader 为按照 API Key 限流时读取 API Key 的请求头.
+	APIKeyHeader string `json:"api-key-header" mapstructure:"api-key-header"`
+	// APIKeys 为已签发的 API Key 的 SHA-256 哈希(十六进制), 只有这些 API Key 使用单独的令牌桶.
+	APIKeys []string `json:"api-keys" mapstructure:"api-keys"`
+	// Groups 为各个路由分组的限流规则, 未配置的路由分组不限流.
+	// 支持的路由分组: login(登录和刷新令牌)、register(注册用户)、email(邮箱验证和找回密码)、users、posts、audit-logs.
+	Groups map[string]*RateLimitRule `json:"groups" mapstructure:"groups"`
+}
+
+// NewRateLimitOptions 创建并返回一个默认的 RateLimitOptions 对象
+func NewRateLimitOptions() *RateLimitOptions {
+	return &RateLimitOptions{
+		Backend:      "memory",
+		APIKeyHeader: "X-API-Key",
+		Groups: map[string]*RateLimitRule{
+			"login":      {Rate: 0.2, Burst: 5, Key: RateLimitKeyIP},
+			"register":   {Rate: 0.05, Burst: 3, Key: RateLimitKeyIP},
+			"email":      {Rate: 0.05, Burst: 5, Key: RateLimitKeyIP},
+			"users":      {Rate: 10, Burst: 20, Key: RateLimitKeyUser},
+			"posts":      {Rate: 10, Burst: 20, Key: RateLimitKeyUser},
+			"audit-logs": {Rate: 5, Burst: 10, Key: RateLimitKeyUser},
+		},
+	}
+}
+
+// Validate 校验 RateLimitOptions 中的选项是否合法.
+func (o *RateLimitOptions) Validate() error {
+	for _, apiKey := range o.APIKeys {
+		if sum, err := hex.DecodeString(apiKey); err != nil || len(sum) != sha256.Size {
+			return fmt.Errorf("invalid api key hash: %s", apiKey)
+		}
+	}
+
+	for group, rule := range o.Groups {
+		if rule == nil || rule.Rate == 0 {
+			continue
+		}
+		if rule.Rate < 0 {
+			return fmt.Errorf("rate limit of group %s cannot be negative", group)
+		}
+		if rule.Burst < 1 {
+			return fmt.Errorf("rate limit burst of group %s must be at least 1", group)
+		}
+		switch rule.Key {
+		case RateLimitKeyIP, RateLimitKeyUser:
+		case RateLimitKeyAPIKey:
+			if o.APIKeyHeader == "" {
+				return fmt.Errorf("api key header cannot be empty when group %s is limited by api key", group)
+			}
+		default:
+			return fmt.Errorf("invalid rate limit key of group %s: %s", group, rule.Key)
+		}
+	}
+	return nil
+}
+
+// Rule 返回路由分组 group 的限流规则, 未配置或者 Rate 为 0 时返回 nil.
+func (o *RateLimitOptions) Rule(group string) *RateLimitRule {
+	rule := o.Groups[group]
+	if rule == nil || rule.Rate == 0 {
+		return nil
+	}
+	return rule
+}
@@ -0,0 +1,74 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// memoryDatabase 是 SQLite 内存数据库的特殊文件名.
+const memoryDatabase = ":memory:"
+
+// SQLiteOptions defines options for sqlite database.
+// 使用纯 Go 实现的 SQLite 驱动, 不依赖 CGO, 便于以单个二进制文件运行.
+type SQLiteOptions struct {
+	// Database 为数据库文件路径, 设置为 `:memory:` 时使用内存数据库.
+	Database string `json:"database" mapstructure:"database"`
+}
+
+// NewSQLiteOptions 创建并返回一个默认的 SQLiteOptions 对象
+func NewSQLiteOptions() *SQLiteOptions {
+	return &SQLiteOptions{
+		Database: "fastgo.db",
+	}
+}
+
+// Validate 校验 SQLiteOptions 中的选项是否合法.
+func (o *SQLiteOptions) Validate() error {
+	if o.Database == "" {
+		return fmt.Errorf("SQLite database cannot be empty")
+	}
+	return nil
+}
+
+// DSN return DSN from SQLiteOptions.
+func (o *SQLiteOptions) DSN() string {
+	// 内存数据库需要使用共享缓存, 否则连接池中的每个连接都会看到各自独立的数据库
+	if o.Database == memoryDatabase {
+		return "file::memory:?cache=shared&_pragma=foreign_keys(1)"
+	}
+
+	// _pragma 参数在打开每个连接时执行:
+	// busy_timeout 避免并发写入时立即返回 `database is locked` 错误;
+	// journal_mode(WAL) 允许读写并发.
+	sep := "?"
+	if strings.Contains(o.Database, "?") {
+		sep = "&"
+	}
+	return o.Database + sep + "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+}
+
+// NewDB 根据配置信息创建一个 *gorm.DB 类型的实例
+func (o *SQLiteOptions) NewDB() (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(o.DSN()), &gorm.Config{
+		PrepareStmt: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite 同一时刻只允许一个写连接, 限制为单连接可以避免写锁冲突,
+	// 同时保证内存数据库在连接池回收连接后不会丢失.
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+
+	return db, nil
+}
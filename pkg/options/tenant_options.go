@@ -0,0 +1,39 @@
+package options
+
+import (
+	"fmt"
+)
+
+// TenantOptions defines options for multi-tenancy.
+// 所有带有租户列的数据表都会按照租户自动隔离.
+type TenantOptions struct {
+	// Key 为数据表中租户列的列名.
+	Key string `json:"key" mapstructure:"key"`
+	// Header 为未认证请求(注册、登录、刷新令牌)指定租户的请求头.
+	Header string `json:"header" mapstructure:"header"`
+	// Default 为请求未指定租户时使用的租户, 单租户部署使用默认租户即可.
+	Default string `json:"default" mapstructure:"default"`
+}
+
+// NewTenantOptions 创建并返回一个默认的 TenantOptions 对象
+func NewTenantOptions() *TenantOptions {
+	return &TenantOptions{
+		Key:     "tenantID",
+		Header:  "X-Tenant-ID",
+		Default: "default",
+	}
+}
+
+// Validate 校验 TenantOptions 中的选项是否合法.
+func (o *TenantOptions) Validate() error {
+	if o.Key == "" {
+		return fmt.Errorf("tenant key cannot be empty")
+	}
+	if o.Header == "" {
+		return fmt.Errorf("tenant header cannot be empty")
+	}
+	if o.Default == "" {
+		return fmt.Errorf("default tenant cannot be empty")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package options
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// 支持的链路追踪导出器.
+const (
+	// TracingExporterNone 不导出链路, 不记录 span.
+	TracingExporterNone = "none"
+	// TracingExporterStdout 将链路以 JSON 格式写入标准输出或文件, 无需部署 collector.
+	TracingExporterStdout = "stdout"
+	// TracingExporterOTLP 通过 OTLP/HTTP 将链路导出到 collector.
+	TracingExporterOTLP = "otlp"
+)
+
+// TracingOptions defines options for OpenTelemetry tracing.
+type TracingOptions struct {
+	// Exporter 为链路导出器, 支持 none、stdout 和 otlp.
+	Exporter string `json:"exporter" mapstructure:"exporter"`
+	// Output 为 stdout 导出器的输出位置, 支持标准输出 stdout 和文件路径.
+	Output string `json:"output" mapstructure:"output"`
+	// Endpoint 为 otlp 导出器的 collector 地址, 例如 127.0.0.1:4318.
+	Endpoint string `json:"endpoint" mapstructure:"endpoint"`
+	// Insecure 为 true 时使用 HTTP 而不是 HTTPS 连接 collector.
+	Insecure bool `json:"insecure" mapstructure:"insecure"`
+	// ServiceName 为链路中的服务名.
+	ServiceName string `json:"service-name" mapstructure:"service-name"`
+	// SampleRatio 为没有上游采样决策时的采样比例, 取值范围 [0, 1].
+	SampleRatio float64 `json:"sample-ratio" mapstructure:"sample-ratio"`
+}
+
+// NewTracingOptions 创建并返回一个默认的 TracingOptions 对象
+func NewTracingOptions() *TracingOptions {
+	return &TracingOptions{
+		Exporter:    TracingExporterNone,
+		Output:      "stdout",
+		Endpoint:    "127.0.0.1:4318",
+		Insecure:    true,
+		ServiceName: "fg-apiserver",
+		SampleRatio: 1,
+	}
+}
+
+// Validate 校验 TracingOptions 中的选项是否合法.
+func (o *TracingOptions) Validate() error {
+	switch o.Exporter {
+	case TracingExporterNone, TracingExporterStdout:
+	case TracingExporterOTLP:
+		if o.Endpoint == "" {
+			return fmt.Errorf("tracing endpoint cannot be empty when using otlp exporter")
+		}
+	default:
+		return fmt.Errorf("invalid tracing exporter: %s", o.Exporter)
+	}
+	if o.SampleRatio < 0 || o.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample ratio must be between 0 and 1: %v", o.SampleRatio)
+	}
+	return nil
+}
+
+// Enabled 判断是否启用链路追踪.
+func (o *TracingOptions) Enabled() bool {
+	return o.Exporter != "" && o.Exporter != TracingExporterNone
+}
+
+// NewTracerProvider 根据配置的导出器创建 TracerProvider, 调用方需要在退出前调用 Shutdown 导出剩余的 span.
+// 采样遵循上游的采样决策, 没有上游时按照 SampleRatio 采样.
+func (o *TracingOptions) NewTracerProvider(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	exporter, err := o.newExporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(o.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(o.ServiceName))),
+	), nil
+}
+
+// newExporter 创建配置的链路导出器.
+func (o *TracingOptions) newExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	switch o.Exporter {
+	case TracingExporterStdout:
+		if o.Output != "" && o.Output != "stdout" {
+			f, err := os.OpenFile(o.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open tracing output file: %w", err)
+			}
+			exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+			if err != nil {
+				_ = f.Close()
+				return nil, err
+			}
+			return &fileExporter{SpanExporter: exporter, file: f}, nil
+		}
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case TracingExporterOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(o.Endpoint)}
+		if o.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("invalid tracing exporter: %s", o.Exporter)
+	}
+}
+
+// fileExporter 是写入文件的 stdout 导出器, 关闭导出器时同时关闭输出文件.
+type fileExporter struct {
+	sdktrace.SpanExporter
+	file *os.File
+}
+
+// Shutdown 导出剩余的 span 并关闭输出文件.
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.SpanExporter.Shutdown(ctx), e.file.Close())
+}
@@ -0,0 +1,41 @@
+package options
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStdoutExporterClosesFile(t *testing.T) {
+	o := NewTracingOptions()
+	o.Exporter = TracingExporterStdout
+	o.Output = filepath.Join(t.TempDir(), "trace.json")
+
+	tp, err := o.NewTracerProvider(context.Background())
+	if err != nil {
+		t.Fatalf("NewTracerProvider() error = %v", err)
+	}
+	_, span := tp.Tracer("test").Start(context.Background(), "span")
+	span.End()
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	// 关闭之后剩余的 span 已写入文件, 并且文件已关闭
+	data, err := os.ReadFile(o.Output)
+	if err != nil || len(data) == 0 {
+		t.Fatalf("ReadFile() = %d bytes, %v, want the exported span", len(data), err)
+	}
+	exporter, err := o.newExporter(context.Background())
+	if err != nil {
+		t.Fatalf("newExporter() error = %v", err)
+	}
+	f := exporter.(*fileExporter).file
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if err := f.Close(); err == nil {
+		t.Error("output file is not closed after Shutdown()")
+	}
+}
@@ -0,0 +1,46 @@
+package options
+
+import (
+	"fmt"
+	"time"
+)
+
+// TwoFactorOptions defines options for TOTP two-factor authentication.
+// 用户启用两步验证后, 登录时密码校验通过只返回登录挑战令牌, 提交验证码或恢复码后才签发身份验证令牌.
+type TwoFactorOptions struct {
+	// Issuer 为显示在验证器应用中的服务名称.
+	Issuer string `json:"issuer" mapstructure:"issuer"`
+	// ChallengeExpiration 为登录挑战令牌的有效期.
+	ChallengeExpiration time.Duration `json:"challenge-expiration" mapstructure:"challenge-expiration"`
+	// RecoveryCodes 为每次生成的恢复码个数.
+	RecoveryCodes int `json:"recovery-codes" mapstructure:"recovery-codes"`
+	// Skew 为校验验证码时允许的时钟偏差, 单位为时间步(30 秒).
+	Skew int `json:"skew" mapstructure:"skew"`
+}
+
+// NewTwoFactorOptions 创建并返回一个默认的 TwoFactorOptions 对象
+func NewTwoFactorOptions() *TwoFactorOptions {
+	return &TwoFactorOptions{
+		Issuer:              "fastgo",
+		ChallengeExpiration: 5 * time.Minute,
+		RecoveryCodes:       10,
+		Skew:                1,
+	}
+}
+
+// Validate 校验 TwoFactorOptions 中的选项是否合法.
+func (o *TwoFactorOptions) Validate() error {
+	if o.Issuer == "" {
+		return fmt.Errorf("two-factor issuer cannot be empty")
+	}
+	if o.ChallengeExpiration <= 0 {
+		return fmt.Errorf("two-factor challenge expiration must be positive")
+	}
+	if o.RecoveryCodes < 1 || o.RecoveryCodes > 20 {
+		return fmt.Errorf("two-factor recovery codes must be within 1 and 20, got %d", o.RecoveryCodes)
+	}
+	if o.Skew < 0 || o.Skew > 2 {
+		return fmt.Errorf("two-factor skew must be within 0 and 2, got %d", o.Skew)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package where
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor is returned when a page token cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid page token")
+
+// Cursor identifies the position of the last record of a page in keyset pagination.
+// It is encoded into an opaque page token, so clients do not depend on its content.
+type Cursor struct {
+	// ID is the primary key of the last record of the page.
+	ID int64 `json:"id"`
+	// Values holds the sort column values of the last record of the page, in the order of Options.Sorts.
+	// Decoded numbers are json.Number and times are RFC 3339 strings, callers convert them to the column types.
+	Values []any `json:"values,omitempty"`
+}
+
+// Encode encodes the cursor into an opaque page token.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a page token returned by Cursor.Encode.
+func DecodeCursor(token string) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&c); err != nil || c.ID <= 0 {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// PageToken applies keyset pagination from a page token returned by Cursor.Encode, an empty token is ignored.
+// The offset is reset, since the cursor already identifies the position of the page.
+// Sort values are used as decoded, use DecodeCursor and After instead when they need to be converted.
+func (whr *Options) PageToken(token string) (*Options, error) {
+	if token == "" {
+		return whr, nil
+	}
+	c, err := DecodeCursor(token)
+	if err != nil {
+		return whr, err
+	}
+	if len(c.Values) != len(whr.Sorts) {
+		return whr, ErrInvalidCursor
+	}
+	return whr.O(0).After(c.ID, c.Values...), nil
+}
+
+// Total returns a copy of the options without pagination and sorting, used to count all matching records.
+func (whr *Options) Total() *Options {
+	total := *whr
+	total.Offset, total.Limit, total.Sorts, total.AfterID, total.AfterValues = 0, defaultLimit, nil, 0, nil
+	return &total
+}
@@ -0,0 +1,108 @@
+package where
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TenantPlugin is a GORM plugin that enforces tenant isolation using the registered tenant.
+//
+// For every model that has a column named after the tenant key, the plugin:
+//   - sets the tenant column to the tenant of the context on Create;
+//   - adds a `tenant key = tenant value` condition to Query, Update, Delete and Row statements.
+//
+// Models without the tenant column, as well as raw SQL, are not affected.
+// The tenant value is resolved from the context of the statement, so callers must use db.WithContext(ctx).
+// Statements whose context is returned by SkipTenant are not restricted to a tenant.
+type TenantPlugin struct{}
+
+// skipTenantKey marks a context whose statements are not restricted to a tenant.
+type skipTenantKey struct{}
+
+// SkipTenant returns a copy of ctx whose statements are not restricted to a tenant.
+// It is intended for maintenance jobs working on the records of all tenants, such as purging expired records.
+func SkipTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipTenantKey{}, true)
+}
+
+// TenantSkipped reports whether ctx was returned by SkipTenant.
+func TenantSkipped(ctx context.Context) bool {
+	skipped, _ := ctx.Value(skipTenantKey{}).(bool)
+	return skipped
+}
+
+// Ensure TenantPlugin implements gorm.Plugin.
+var _ gorm.Plugin = (*TenantPlugin)(nil)
+
+// NewTenantPlugin creates a new TenantPlugin.
+func NewTenantPlugin() *TenantPlugin {
+	return &TenantPlugin{}
+}
+
+// Name returns the name of the plugin.
+func (p *TenantPlugin) Name() string {
+	return "where:tenant"
+}
+
+// Initialize registers the tenant callbacks.
+func (p *TenantPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("where:tenant_create", p.setTenant); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("where:tenant_query", p.addCondition); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("where:tenant_update", p.addCondition); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("where:tenant_delete", p.addCondition); err != nil {
+		return err
+	}
+	return cb.Row().Before("gorm:row").Register("where:tenant_row", p.addCondition)
+}
+
+// setTenant sets the tenant column of the records to be created.
+func (p *TenantPlugin) setTenant(db *gorm.DB) {
+	tenant, ok := RegisteredTenant()
+	if !ok || db.Statement.Schema == nil || db.Error != nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(tenant.Key)
+	if field == nil {
+		return
+	}
+
+	value := tenant.ValueFunc(db.Statement.Context)
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			_ = db.AddError(field.Set(db.Statement.Context, reflect.Indirect(rv.Index(i)), value))
+		}
+	case reflect.Struct:
+		_ = db.AddError(field.Set(db.Statement.Context, rv, value))
+	}
+}
+
+// addCondition restricts the statement to the records of the current tenant.
+func (p *TenantPlugin) addCondition(db *gorm.DB) {
+	tenant, ok := RegisteredTenant()
+	if !ok || db.Statement.Schema == nil || db.Error != nil || TenantSkipped(db.Statement.Context) {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(tenant.Key)
+	if field == nil {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+			Value:  tenant.ValueFunc(db.Statement.Context),
+		},
+	}})
+}
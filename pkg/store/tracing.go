@@ -0,0 +1,115 @@
+package where
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracingSpanKey is the key of the span stored in the statement instance.
+const tracingSpanKey = "where:tracing_span"
+
+// TracingPlugin is a GORM plugin that records every statement as an OpenTelemetry span.
+//
+// The span is a child of the span in the context of the statement, so callers must use db.WithContext(ctx).
+// It is named after the operation (gorm.Create, gorm.Query, ...) and carries the database system,
+// the table, the SQL statement with placeholders and the number of affected rows.
+// gorm.ErrRecordNotFound is not recorded as an error.
+type TracingPlugin struct {
+	// system is the database system, such as mysql or sqlite.
+	system string
+}
+
+// Ensure TracingPlugin implements gorm.Plugin.
+var _ gorm.Plugin = (*TracingPlugin)(nil)
+
+// NewTracingPlugin creates a new TracingPlugin for the given database system.
+func NewTracingPlugin(system string) *TracingPlugin {
+	return &TracingPlugin{system: system}
+}
+
+// Name returns the name of the plugin.
+func (p *TracingPlugin) Name() string {
+	return "where:tracing"
+}
+
+// Initialize registers the tracing callbacks.
+func (p *TracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("where:tracing_before_create", p.before("gorm.Create")); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("where:tracing_after_create", p.after); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("where:tracing_before_query", p.before("gorm.Query")); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("where:tracing_after_query", p.after); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("where:tracing_before_update", p.before("gorm.Update")); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("where:tracing_after_update", p.after); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("where:tracing_before_delete", p.before("gorm.Delete")); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("where:tracing_after_delete", p.after); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("where:tracing_before_row", p.before("gorm.Row")); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("where:tracing_after_row", p.after); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("gorm:raw").Register("where:tracing_before_raw", p.before("gorm.Raw")); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("where:tracing_after_raw", p.after)
+}
+
+// before starts a span named name for the statement.
+func (p *TracingPlugin) before(name string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		_, span := otel.Tracer("fastgo/pkg/store").Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String(string(semconv.DBSystemKey), p.system)),
+		)
+		db.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+// after ends the span of the statement.
+func (p *TracingPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBCollectionName(db.Statement.Table),
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
@@ -2,6 +2,7 @@ package where
 
 import (
 	"context"
+	"slices"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -35,6 +36,14 @@ type Query struct {
 	Args []interface{}
 }
 
+// Sort represents an ORDER BY column.
+type Sort struct {
+	// Column is the database column to sort by.
+	Column string `json:"column"`
+	// Desc sorts in descending order when true.
+	Desc bool `json:"desc,omitempty"`
+}
+
 // Option defines a function type that modifies Options.
 type Option func(*Options)
 
@@ -52,6 +61,20 @@ type Options struct {
 	Clauses []clause.Expression
 	// Queries contains a list of queries to be executed.
 	Queries []Query
+	// Sorts defines the ORDER BY columns, applied before the primary key which is always the last sort column.
+	// +optional
+	Sorts []Sort `json:"sorts,omitempty"`
+	// AfterID enables keyset pagination: only records whose primary key is less than AfterID are returned.
+	// Records are listed in descending order of the primary key, so AfterID is the ID of the last record of the previous page.
+	// +optional
+	AfterID int64 `json:"afterID,omitempty"`
+	// AfterValues holds the values of the Sorts columns of the last record of the previous page.
+	// When Sorts is set, records after the position (AfterValues..., AfterID) in the sort order are returned.
+	// +optional
+	AfterValues []any `json:"afterValues,omitempty"`
+	// SkipCount tells List not to count the total number of matching records.
+	// +optional
+	SkipCount bool `json:"skipCount,omitempty"`
 }
 
 // tenant holds the registered tenant instance.
@@ -116,6 +139,20 @@ func WithQuery(query interface{}, args ...interface{}) Option {
 	}
 }
 
+// WithAfter initializes the AfterID field in Options for keyset pagination.
+func WithAfter(id int64) Option {
+	return func(whr *Options) {
+		whr.AfterID = id
+	}
+}
+
+// WithSkipCount initializes the SkipCount field in Options.
+func WithSkipCount(skip bool) Option {
+	return func(whr *Options) {
+		whr.SkipCount = skip
+	}
+}
+
 // NewWhere constructs a new Options object, applying the given where options.
 func NewWhere(opts ...Option) *Options {
 	whr := &Options{
@@ -176,10 +213,33 @@ func (whr *Options) Q(query interface{}, args ...interface{}) *Options {
 	return whr
 }
 
+// S adds an ORDER BY column to the query.
+func (whr *Options) S(column string, desc bool) *Options {
+	whr.Sorts = append(whr.Sorts, Sort{Column: column, Desc: desc})
+	return whr
+}
+
+// After enables keyset pagination, only records whose primary key is less than id are returned.
+// When Sorts is set, values must hold the sort column values of the record identified by id;
+// if the number of values does not match Sorts, only the primary key is used as the cursor.
+// Unlike offset pagination, pages do not drift when records are inserted and the database does not scan skipped rows.
+func (whr *Options) After(id int64, values ...any) *Options {
+	whr.AfterID = id
+	whr.AfterValues = values
+	return whr
+}
+
+// NoCount tells List not to count the total number of matching records.
+func (whr *Options) NoCount() *Options {
+	whr.SkipCount = true
+	return whr
+}
+
 // T retrieves the value associated with the registered tenant using the provided context.
+// It is a no-op if no tenant has been registered.
 func (whr *Options) T(ctx context.Context) *Options {
-	if registeredTenant.Key != "" && registeredTenant.ValueFunc != nil {
-		whr.F(registeredTenant.Key, registeredTenant.ValueFunc(ctx))
+	if tenant, ok := RegisteredTenant(); ok {
+		whr.F(tenant.Key, tenant.ValueFunc(ctx))
 	}
 	return whr
 }
@@ -202,11 +262,40 @@ func (whr *Options) F(kvs ...any) *Options {
 
 // Where applies the filters and clauses to the given gorm.DB instance.
 func (whr *Options) Where(db *gorm.DB) *gorm.DB {
+	clauses := whr.Clauses
 	for _, query := range whr.Queries {
 		conds := db.Statement.BuildCondition(query.Query, query.Args...)
-		whr.Clauses = append(whr.Clauses, conds...)
+		clauses = append(clauses, conds...)
+	}
+	if whr.AfterID > 0 {
+		clauses = append(clauses, whr.keyset())
+	}
+	for _, sort := range whr.Sorts {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: sort.Column}, Desc: sort.Desc})
+	}
+	return db.Where(whr.Filters).Clauses(clauses...).Offset(whr.Offset).Limit(whr.Limit)
+}
+
+// keyset builds the condition selecting the records after (AfterValues..., AfterID) in the sort order,
+// e.g. `a < ? OR (a = ? AND id < ?)` for Sorts `a desc`.
+func (whr *Options) keyset() clause.Expression {
+	after := clause.Expression(clause.Lt{Column: clause.PrimaryColumn, Value: whr.AfterID})
+	if len(whr.Sorts) == 0 || len(whr.AfterValues) != len(whr.Sorts) {
+		return after
+	}
+
+	var ors, eqs []clause.Expression
+	for i, sort := range whr.Sorts {
+		column := clause.Column{Name: sort.Column}
+		var cmp clause.Expression = clause.Gt{Column: column, Value: whr.AfterValues[i]}
+		if sort.Desc {
+			cmp = clause.Lt{Column: column, Value: whr.AfterValues[i]}
+		}
+		ors = append(ors, clause.And(append(slices.Clone(eqs), cmp)...))
+		eqs = append(eqs, clause.Eq{Column: column, Value: whr.AfterValues[i]})
 	}
-	return db.Where(whr.Filters).Clauses(whr.Clauses...).Offset(whr.Offset).Limit(whr.Limit)
+	ors = append(ors, clause.And(append(eqs, after)...))
+	return clause.Or(ors...)
 }
 
 // O is a convenience function to create a new Options with offset.
@@ -231,7 +320,12 @@ func C(conds ...clause.Expression) *Options {
 
 // T is a convenience function to create a new Options with tenant.
 func T(ctx context.Context) *Options {
-	return NewWhere().F(registeredTenant.Key, registeredTenant.ValueFunc(ctx))
+	return NewWhere().T(ctx)
+}
+
+// After is a convenience function to create a new Options with keyset pagination.
+func After(id int64) *Options {
+	return NewWhere().After(id)
 }
 
 // F is a convenience function to create a new Options with filters.
@@ -246,3 +340,8 @@ func RegisterTenant(key string, valueFunc func(context.Context) string) {
 		ValueFunc: valueFunc,
 	}
 }
+
+// RegisteredTenant returns the registered tenant and whether a tenant has been registered.
+func RegisteredTenant() (Tenant, bool) {
+	return registeredTenant, registeredTenant.Key != "" && registeredTenant.ValueFunc != nil
+}
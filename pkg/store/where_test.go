@@ -0,0 +1,39 @@
+package where
+
+import (
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm/clause"
+)
+
+func TestKeyset(t *testing.T) {
+	id := clause.Lt{Column: clause.PrimaryColumn, Value: int64(42)}
+
+	tests := []struct {
+		name string
+		whr  *Options
+		want clause.Expression
+	}{
+		{name: "primary key only", whr: After(42), want: id},
+		{name: "values without sorts", whr: NewWhere().After(42, "go"), want: id},
+		{name: "fewer values than sorts", whr: NewWhere().S("title", false).S("version", true).After(42, "go"), want: id},
+		{name: "more values than sorts", whr: NewWhere().S("title", false).After(42, "go", 3), want: id},
+		{
+			name: "sort values",
+			whr:  NewWhere().S("title", false).S("version", true).After(42, "go", 3),
+			want: clause.Or(
+				clause.And(clause.Gt{Column: clause.Column{Name: "title"}, Value: "go"}),
+				clause.And(clause.Eq{Column: clause.Column{Name: "title"}, Value: "go"}, clause.Lt{Column: clause.Column{Name: "version"}, Value: 3}),
+				clause.And(clause.Eq{Column: clause.Column{Name: "title"}, Value: "go"}, clause.Eq{Column: clause.Column{Name: "version"}, Value: 3}, id),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.whr.keyset(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keyset() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
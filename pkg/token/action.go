@@ -0,0 +1,102 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrActionTokenInvalid 表示一次性操作 token 格式错误、签名错误、用途不符或已过期.
+var ErrActionTokenInvalid = errors.New("action token is invalid or has expired")
+
+// ActionClaims 是从一次性操作 token 中解析出的声明.
+//
+// 一次性操作 token 用于邮件链接(例如验证邮箱、重置密码), 不是 JWT, 不能作为身份验证令牌使用.
+// token 中带有签发时资源状态的摘要, 状态变化后(例如邮箱已验证、密码已修改) token 随即失效,
+// 因此无需在服务端保存 token 即可保证 token 只能使用一次.
+type ActionClaims struct {
+	// Purpose 为 token 的用途.
+	Purpose string `json:"pur"`
+	// Subject 为 token 的主体, fastgo 中是 UserID.
+	Subject string `json:"sub"`
+	// Tenant 为主体所属的租户.
+	Tenant string `json:"ten,omitempty"`
+	// State 为签发时资源状态的摘要.
+	State string `json:"st"`
+	// ExpiresAt 为 token 过期时间的 Unix 时间戳.
+	ExpiresAt int64 `json:"exp"`
+}
+
+// SignAction 签发一个用途为 purpose 的一次性操作 token, 有效期为 ttl.
+// state 为签发时资源的状态, 校验时通过 ActionClaims.Matches 比较, 状态变化后 token 失效.
+// token 使用 JWT Key 和 HMAC-SHA256 签名, 签名时带有用途前缀, 与身份验证令牌互不通用.
+func SignAction(purpose string, subject string, tenant string, state string, ttl time.Duration) (string, time.Time, error) {
+	if config.key == "" {
+		return "", time.Time{}, errors.New("token key cannot be empty")
+	}
+
+	expireAt := time.Now().Add(ttl)
+	payload, err := json.Marshal(&ActionClaims{
+		Purpose:   purpose,
+		Subject:   subject,
+		Tenant:    tenant,
+		State:     actionState(state),
+		ExpiresAt: expireAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(actionSignature(purpose, encoded)), expireAt, nil
+}
+
+// ParseAction 校验一次性操作 token 的签名、用途和过期时间, 成功则返回 token 中的声明.
+// 调用方还需要通过 ActionClaims.Matches 校验资源状态没有变化.
+func ParseAction(tokenString string, purpose string) (*ActionClaims, error) {
+	encoded, signature, ok := strings.Cut(tokenString, ".")
+	if !ok {
+		return nil, ErrActionTokenInvalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, actionSignature(purpose, encoded)) {
+		return nil, ErrActionTokenInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrActionTokenInvalid
+	}
+	var claims ActionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrActionTokenInvalid
+	}
+	if claims.Purpose != purpose || claims.Subject == "" || time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrActionTokenInvalid
+	}
+	return &claims, nil
+}
+
+// Matches 判断资源的当前状态 state 是否与签发 token 时的状态一致.
+func (c *ActionClaims) Matches(state string) bool {
+	return hmac.Equal([]byte(c.State), []byte(actionState(state)))
+}
+
+// actionSignature 计算一次性操作 token 的签名.
+func actionSignature(purpose string, encoded string) []byte {
+	mac := hmac.New(sha256.New, []byte(config.key))
+	mac.Write([]byte("action:" + purpose + ":" + encoded))
+	return mac.Sum(nil)
+}
+
+// actionState 使用 JWT Key 计算资源状态的摘要, 避免在 token 中暴露资源状态(例如密码哈希).
+// 摘要带有密钥, 持有 token 的人无法根据摘要离线猜测资源状态.
+func actionState(state string) string {
+	mac := hmac.New(sha256.New, []byte(config.key))
+	mac.Write([]byte("state:" + state))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,97 @@
+package token
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAction(t *testing.T) {
+	valid, _, err := SignAction("verify-email", "user-a", "acme", "state-a", time.Hour)
+	if err != nil {
+		t.Fatalf("SignAction() error = %v", err)
+	}
+	expired, _, _ := SignAction("verify-email", "user-a", "acme", "state-a", -time.Second)
+	noSubject, _, _ := SignAction("verify-email", "", "acme", "state-a", time.Hour)
+
+	// 替换 token 中的声明, 保留原来的签名
+	encoded, signature, _ := strings.Cut(valid, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(encoded)
+	var claims map[string]any
+	_ = json.Unmarshal(payload, &claims)
+	claims["sub"] = "user-b"
+	payload, _ = json.Marshal(claims)
+	tampered := base64.RawURLEncoding.EncodeToString(payload) + "." + signature
+
+	tests := []struct {
+		name      string
+		token     string
+		purpose   string
+		wantError bool
+	}{
+		{name: "valid", token: valid, purpose: "verify-email"},
+		{name: "another purpose", token: valid, purpose: "reset-password", wantError: true},
+		{name: "expired", token: expired, purpose: "verify-email", wantError: true},
+		{name: "tampered claims", token: tampered, purpose: "verify-email", wantError: true},
+		{name: "tampered signature", token: encoded + "." + signature[1:], purpose: "verify-email", wantError: true},
+		{name: "without signature", token: encoded, purpose: "verify-email", wantError: true},
+		{name: "malformed", token: "not.a-token", purpose: "verify-email", wantError: true},
+		{name: "empty subject", token: noSubject, purpose: "verify-email", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseAction(tt.token, tt.purpose)
+			if tt.wantError {
+				if !errors.Is(err, ErrActionTokenInvalid) {
+					t.Fatalf("ParseAction() error = %v, want %v", err, ErrActionTokenInvalid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAction() error = %v", err)
+			}
+			if claims.Purpose != tt.purpose || claims.Subject != "user-a" || claims.Tenant != "acme" {
+				t.Errorf("ParseAction() = %+v", claims)
+			}
+		})
+	}
+
+	// 身份验证令牌不能作为一次性操作 token 使用
+	authToken, _, _ := Sign("user-a")
+	if _, err := ParseAction(authToken, "verify-email"); !errors.Is(err, ErrActionTokenInvalid) {
+		t.Errorf("ParseAction() of a JWT error = %v, want %v", err, ErrActionTokenInvalid)
+	}
+}
+
+func TestActionState(t *testing.T) {
+	tok, _, err := SignAction("reset-password", "user-a", "", "$2a$10$hash-a", time.Hour)
+	if err != nil {
+		t.Fatalf("SignAction() error = %v", err)
+	}
+	claims, err := ParseAction(tok, "reset-password")
+	if err != nil {
+		t.Fatalf("ParseAction() error = %v", err)
+	}
+
+	// 资源状态变化后 token 失效
+	if !claims.Matches("$2a$10$hash-a") {
+		t.Error("Matches() of the signed state = false")
+	}
+	if claims.Matches("$2a$10$hash-b") {
+		t.Error("Matches() after the state changed = true")
+	}
+	if strings.Contains(tok, "hash-a") {
+		t.Errorf("token %s contains the state", tok)
+	}
+
+	// 状态摘要使用 JWT Key 计算, 更换密钥后摘要不同
+	key := config.key
+	config.key = "another key"
+	defer func() { config.key = key }()
+	if claims.Matches("$2a$10$hash-a") {
+		t.Error("Matches() with another key = true")
+	}
+}
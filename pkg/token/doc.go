@@ -3,8 +3,16 @@
 //		key: 用于签发和解析 token 的密钥；
 //		identityKey: token 中用户身份的键，fastgo 中是 UserID；
 //      expiration: 签发的 token 过期时间。
+// InitKeys : 设置非对称签名密钥（RS256 或 EdDSA）以及密钥轮换期间仍然接受的校验密钥，签发的 token 头部带有 kid；
+// LoadPrivateKeyFile / LoadPublicKeyFile : 从 PEM 文件中加载 RSA 或 Ed25519 密钥；
+// JWKS : 返回所有校验密钥的公钥（JSON Web Key Set），供其他服务校验 token。
 // Parse : 使用指定的密钥 key 解析 token，解析成功返回 token 上下文（fastgo 中是 UserID），否则报错。
+// ParseClaims : 与 Parse 相同，但返回 token 中的全部声明（用户身份、jti、签发时间、过期时间）。
 // ParseRequest : 从请求头中获取令牌，并将其传递给 Parse 函数以解析令牌；
-// Sign : 使用 JWT Key 签发 token，token 的 claims 中会存放用户身份（fastgo 中是 UserID）、token 生效时间、token 签发时间、token 过期时间。
+// Sign : 使用签名密钥（未配置非对称密钥时为 JWT Key）签发 token，token 的 claims 中会存放用户身份（fastgo 中是 UserID）、token 唯一标识 jti、token 生效时间、token 签发时间（iat 以及毫秒精度的 iat_ms）、token 过期时间。
+// SignWithClaims : 与 Sign 相同，同时写入自定义声明（例如租户 ID），解析时通过 Claims.Extra 获取。
+// SignRefreshToken : 签发一个长期有效的不透明 refresh token，返回明文和哈希值，服务端只持久化哈希值。
+// HashRefreshToken : 计算 refresh token 的哈希值，用于查询持久化的 refresh token。
+// SignAction / ParseAction : 签发和校验用于邮件链接的一次性操作 token（例如验证邮箱、重置密码），token 中带有资源状态的摘要，状态变化后 token 失效。
 
 package token
@@ -0,0 +1,169 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+// Key 是用于签发或校验 token 的非对称密钥, 通过 kid(ID) 区分.
+// 签名密钥同时持有私钥和公钥, 校验密钥只持有公钥.
+type Key struct {
+	// ID 为密钥标识, 签发 token 时写入 JWT 头部的 kid 字段.
+	ID string
+	// Method 为签名算法, 根据密钥类型确定: RSA 密钥使用 RS256, Ed25519 密钥使用 EdDSA.
+	Method jwt.SigningMethod
+
+	privateKey crypto.PrivateKey
+	publicKey  crypto.PublicKey
+}
+
+// JWK 是 RFC 7517 定义的 JSON Web Key, 只包含公钥信息.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA 公钥
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 公钥
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet 是 JWKS 接口返回的公钥集合.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// 非对称密钥配置, 由 InitKeys 设置.
+var (
+	// signingKey 为签发 token 使用的密钥, 为 nil 时使用 HS256 和 config.key 签发.
+	signingKey *Key
+	// verificationKeys 为校验 token 时可用的密钥, 按照 kid 索引, 包括 signingKey.
+	verificationKeys = map[string]*Key{}
+	keysOnce         sync.Once
+)
+
+// InitKeys 设置签发 token 使用的非对称密钥 signing, 以及校验 token 时额外接受的密钥 verification.
+// 密钥轮换时, 将旧的签名密钥作为 verification 传入, 使旧密钥签发的 token 在过期之前仍然有效.
+// 设置 signing 后, 不再接受 HS256 签发的 token.
+func InitKeys(signing *Key, verification ...*Key) {
+	keysOnce.Do(func() {
+		signingKey = signing
+		for _, key := range append(verification, signing) {
+			if key != nil {
+				verificationKeys[key.ID] = key
+			}
+		}
+	})
+}
+
+// LoadPrivateKeyFile 从 PEM 文件中加载 RSA 或 Ed25519 私钥, 作为签名密钥.
+func LoadPrivateKeyFile(kid string, path string) (*Key, error) {
+	key, err := loadKeyFile(kid, path)
+	if err != nil {
+		return nil, err
+	}
+	if key.privateKey == nil {
+		return nil, fmt.Errorf("%s does not contain a private key", path)
+	}
+	return key, nil
+}
+
+// LoadPublicKeyFile 从 PEM 文件中加载 RSA 或 Ed25519 公钥, 作为校验密钥.
+// 文件中是私钥时, 只使用其中的公钥部分.
+func LoadPublicKeyFile(kid string, path string) (*Key, error) {
+	key, err := loadKeyFile(kid, path)
+	if err != nil {
+		return nil, err
+	}
+	key.privateKey = nil
+	return key, nil
+}
+
+// JWKS 返回所有校验密钥的公钥, 其他服务可以使用这些公钥校验 token.
+func JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(verificationKeys))}
+	for _, key := range verificationKeys {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// JWK 返回密钥的公钥部分.
+func (k *Key) JWK() JWK {
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+	switch pub := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// loadKeyFile 从 PEM 文件中加载密钥, 支持 PKCS#1、PKCS#8 和 PKIX 格式.
+func loadKeyFile(kid string, path string) (*Key, error) {
+	if kid == "" {
+		return nil, errors.New("key id cannot be empty")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM encoded key", path)
+	}
+
+	var parsed any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q in %s", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	key := &Key{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.privateKey, key.publicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.publicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.privateKey, key.publicKey = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.publicKey = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T in %s, must be RSA or Ed25519", parsed, path)
+	}
+	return key, nil
+}
@@ -0,0 +1,180 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+// useKeys 与 InitKeys 一样设置非对称密钥, 测试结束后恢复为 HS256.
+func useKeys(t *testing.T, signing *Key, verification ...*Key) {
+	t.Helper()
+
+	reset := func() {
+		signingKey, verificationKeys, keysOnce = nil, map[string]*Key{}, sync.Once{}
+	}
+	reset()
+	InitKeys(signing, verification...)
+	t.Cleanup(reset)
+}
+
+// writePEM 将 DER 编码的密钥以 PEM 格式写入临时文件, 返回文件路径.
+func writePEM(t *testing.T, typ string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+// signRaw 使用 method 和 key 签发一个头部 kid 为 kid 的 token.
+func signRaw(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, jwt.MapClaims{config.identityKey: "user-a", "exp": time.Now().Add(time.Hour).Unix()})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return signed
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	pkix, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+
+	tests := []struct {
+		name       string
+		kid        string
+		path       string
+		public     bool
+		wantMethod jwt.SigningMethod
+		wantError  bool
+	}{
+		{name: "pkcs1 rsa private key", kid: "k1", path: writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)), wantMethod: jwt.SigningMethodRS256},
+		{name: "pkcs8 ed25519 private key", kid: "k1", path: writePEM(t, "PRIVATE KEY", pkcs8), wantMethod: jwt.SigningMethodEdDSA},
+		{name: "pkcs1 rsa public key", kid: "k1", path: writePEM(t, "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)), public: true, wantMethod: jwt.SigningMethodRS256},
+		{name: "pkix public key", kid: "k1", path: writePEM(t, "PUBLIC KEY", pkix), public: true, wantMethod: jwt.SigningMethodRS256},
+		{name: "public key from private key file", kid: "k1", path: writePEM(t, "PRIVATE KEY", pkcs8), public: true, wantMethod: jwt.SigningMethodEdDSA},
+		{name: "public key as signing key", kid: "k1", path: writePEM(t, "PUBLIC KEY", pkix), wantError: true},
+		{name: "empty kid", path: writePEM(t, "PRIVATE KEY", pkcs8), wantError: true},
+		{name: "unsupported block type", kid: "k1", path: writePEM(t, "CERTIFICATE", pkix), wantError: true},
+		{name: "malformed key", kid: "k1", path: writePEM(t, "PRIVATE KEY", []byte("garbage")), wantError: true},
+		{name: "missing file", kid: "k1", path: filepath.Join(t.TempDir(), "missing.pem"), wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			load := LoadPrivateKeyFile
+			if tt.public {
+				load = LoadPublicKeyFile
+			}
+			key, err := load(tt.kid, tt.path)
+			if tt.wantError {
+				if err == nil {
+					t.Fatal("error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if key.ID != tt.kid || key.Method != tt.wantMethod || (key.privateKey == nil) != tt.public {
+				t.Errorf("key = %s %s, has private key %v", key.ID, key.Method.Alg(), key.privateKey != nil)
+			}
+		})
+	}
+
+	// 不是 PEM 格式的文件
+	path := filepath.Join(t.TempDir(), "key.txt")
+	_ = os.WriteFile(path, []byte("not a key"), 0o600)
+	if _, err := LoadPrivateKeyFile("k1", path); err == nil {
+		t.Error("LoadPrivateKeyFile() of a non-PEM file error = nil")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	edPublic, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	useKeys(t,
+		&Key{ID: "k2", Method: jwt.SigningMethodEdDSA, privateKey: edKey, publicKey: edPublic},
+		&Key{ID: "k1", Method: jwt.SigningMethodRS256, publicKey: &rsaKey.PublicKey},
+	)
+
+	set := JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS() = %+v, want 2 keys", set)
+	}
+	if k := set.Keys[0]; k.Kid != "k1" || k.Kty != "RSA" || k.Alg != "RS256" || k.Use != "sig" || k.E != "AQAB" || k.N == "" || k.X != "" {
+		t.Errorf("JWKS()[0] = %+v", k)
+	}
+	if k := set.Keys[1]; k.Kid != "k2" || k.Kty != "OKP" || k.Crv != "Ed25519" || k.Alg != "EdDSA" || k.X == "" || k.N != "" {
+		t.Errorf("JWKS()[1] = %+v", k)
+	}
+}
+
+func TestParseWithKeys(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	useKeys(t,
+		&Key{ID: "new", Method: jwt.SigningMethodRS256, privateKey: newKey, publicKey: &newKey.PublicKey},
+		&Key{ID: "old", Method: jwt.SigningMethodRS256, publicKey: &oldKey.PublicKey},
+	)
+
+	signed, _, err := Sign("user-a")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		wantError bool
+	}{
+		{name: "current signing key", token: signed},
+		{name: "rotated verification key", token: signRaw(t, jwt.SigningMethodRS256, "old", oldKey)},
+		{name: "unknown kid", token: signRaw(t, jwt.SigningMethodRS256, "other", otherKey), wantError: true},
+		{name: "missing kid", token: signRaw(t, jwt.SigningMethodRS256, "", newKey), wantError: true},
+		{name: "kid of another key", token: signRaw(t, jwt.SigningMethodRS256, "new", otherKey), wantError: true},
+		{name: "algorithm does not match the key", token: signRaw(t, jwt.SigningMethodEdDSA, "new", edKey), wantError: true},
+		{name: "hs256 with the shared secret", token: signRaw(t, jwt.SigningMethodHS256, "", []byte(config.key)), wantError: true},
+		{name: "hs256 with the public key as secret", token: signRaw(t, jwt.SigningMethodHS256, "new", x509.MarshalPKCS1PublicKey(&newKey.PublicKey)), wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := Parse(tt.token, config.key)
+			if tt.wantError {
+				if err == nil {
+					t.Fatal("Parse() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil || identity != "user-a" {
+				t.Fatalf("Parse() = %q, %v, want user-a, nil", identity, err)
+			}
+		})
+	}
+}
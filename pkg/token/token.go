@@ -1,10 +1,15 @@
 package token
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"sync"
 	"time"
 )
@@ -18,16 +23,22 @@ type Config struct {
 	// expiration 是签发 token 的过期时间.
 	// time.Duration 类型, 表示时间段.
 	expiration time.Duration
+	// refreshExpiration 是签发 refresh token 的过期时间.
+	refreshExpiration time.Duration
 }
 
+// issuedAtMsKey 是 token 中毫秒精度签发时间的键.
+// 标准声明 iat 只精确到秒, 无法区分同一秒内吊销之前和之后签发的 token.
+const issuedAtMsKey = "iat_ms"
+
 // 包内变量
 var (
-	config = Config{"Rtg8BPKNEf2mB4mgvKONGPZZQSaJWNLijxR42qRgq0iBb5", "identityKey", 2 * time.Hour}
+	config = Config{"Rtg8BPKNEf2mB4mgvKONGPZZQSaJWNLijxR42qRgq0iBb5", "identityKey", 2 * time.Hour, 7 * 24 * time.Hour}
 	once   sync.Once
 )
 
 // Init 设置包级别的配置 config, config 会用于本包后面的 token 签发和解析.
-func Init(key string, identityKey string, expiration time.Duration) {
+func Init(key string, identityKey string, expiration time.Duration, refreshExpiration time.Duration) {
 	// 在 sync.Once 的作用下, 该闭包只会执行一次
 	// 首次调用时会执行闭包内逻辑, 后续所有调用不会再执行闭包
 	once.Do(func() {
@@ -40,13 +51,50 @@ func Init(key string, identityKey string, expiration time.Duration) {
 		if expiration != 0 {
 			config.expiration = expiration
 		}
+		if refreshExpiration != 0 {
+			config.refreshExpiration = refreshExpiration
+		}
 	})
 }
 
+// Claims 是从 token 中解析出的声明.
+type Claims struct {
+	// Identity 为用户身份, fastgo 中是 UserID.
+	Identity string
+	// ID 为 token 的唯一标识(jti), 用于吊销单个 token.
+	ID string
+	// IssuedAt 为 token 签发时间, token 中带有 iat_ms 声明时精确到毫秒, 否则精确到秒.
+	IssuedAt time.Time
+	// ExpiresAt 为 token 过期时间.
+	ExpiresAt time.Time
+	// Extra 为签发 token 时通过 SignWithClaims 传入的自定义声明.
+	Extra map[string]any
+}
+
 // Parse 使用指定的密钥 key 解析 token, 成功则返回 token 身份键; 否则报错.
 func Parse(tokenString string, key string) (string, error) {
+	claims, err := ParseClaims(tokenString, key)
+	if err != nil {
+		return "", err
+	}
+	return claims.Identity, nil
+}
+
+// ParseClaims 使用指定的密钥 key 解析 token, 成功则返回 token 中的声明; 否则报错.
+func ParseClaims(tokenString string, key string) (*Claims, error) {
 	// 解析 token
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// 配置了非对称密钥时, 根据头部的 kid 选择校验密钥
+		if signingKey != nil {
+			kid, _ := token.Header["kid"].(string)
+			verificationKey, ok := verificationKeys[kid]
+			// 确保 token 加密算法与密钥的算法一致, 防止算法混淆攻击
+			if !ok || token.Method.Alg() != verificationKey.Method.Alg() {
+				return nil, jwt.ErrSignatureInvalid
+			}
+			return verificationKey.publicKey, nil
+		}
+
 		// 确保 token 加密算法是预期的算法 (断言判断)
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrSignatureInvalid
@@ -57,67 +105,145 @@ func Parse(tokenString string, key string) (string, error) {
 
 	// 解析失败
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// 解析成功, 则从 token 中取出 token 的主题
-	var identityKey string
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if key, exists := claims[config.identityKey]; exists {
-			if identity, valid := key.(string); valid {
-				// 获得身份键
-				identityKey = identity
-			}
-		}
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	var claims Claims
+	if identity, valid := mapClaims[config.identityKey].(string); valid {
+		// 获得身份键
+		claims.Identity = identity
 	}
-	if identityKey == "" {
-		return "", jwt.ErrSignatureInvalid
+	if claims.Identity == "" {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	claims.ID, _ = mapClaims["jti"].(string)
+	if iat, valid := mapClaims["iat"].(float64); valid {
+		claims.IssuedAt = time.Unix(int64(iat), 0)
+	}
+	// 优先使用毫秒精度的签发时间, 便于和同一秒内的用户级别吊销时间点比较
+	if iatMs, valid := mapClaims[issuedAtMsKey].(float64); valid {
+		claims.IssuedAt = time.UnixMilli(int64(iatMs))
+	}
+	if exp, valid := mapClaims["exp"].(float64); valid {
+		claims.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	claims.Extra = make(map[string]any)
+	for k, v := range mapClaims {
+		switch k {
+		case config.identityKey, "jti", "nbf", "iat", issuedAtMsKey, "exp":
+		default:
+			claims.Extra[k] = v
+		}
 	}
 
-	return identityKey, nil
+	return &claims, nil
 }
 
 // ParseRequest 从请求头获取 token, 并传递给 Parse 函数以解析令牌
 func ParseRequest(c *gin.Context) (string, error) {
+	claims, err := ParseRequestClaims(c)
+	if err != nil {
+		return "", err
+	}
+	return claims.Identity, nil
+}
+
+// ParseRequestClaims 从请求头获取 token, 并传递给 ParseClaims 函数以解析令牌中的声明
+func ParseRequestClaims(c *gin.Context) (*Claims, error) {
 	// 从头部获取 token (一般 token 存放在 "Authorization")
 	header := c.Request.Header.Get("Authorization")
 
 	// HTTP 请求中若没有字段 "Authorization", 则 header 会获得空字符串
 	if len(header) == 0 {
-		return "", errors.New("the length of the `Authorization` head is zero")
+		return nil, errors.New("the length of the `Authorization` head is zero")
 	}
 
 	var token string
 	// 从请求头取出 token
 	_, err := fmt.Sscanf(header, "Bearer %s", &token)
 	if err != nil {
-		return "", errors.New("the Authorization token cannot be parsed into the specified structure")
+		return nil, errors.New("the Authorization token cannot be parsed into the specified structure")
 	}
 
-	return Parse(token, config.key)
+	return ParseClaims(token, config.key)
 }
 
-// Sign 使用 jwtSecret 签发 token，token 的 claims 中会存放传入的 subject.
+// Sign 签发 token，token 的 claims 中会存放传入的 subject.
+// 通过 InitKeys 配置了非对称密钥时使用 RS256/EdDSA 签发, 否则使用 jwtSecret 和 HS256 签发.
 func Sign(identityKey string) (string, time.Time, error) {
+	return SignWithClaims(identityKey, nil)
+}
+
+// SignWithClaims 与 Sign 相同, 同时将 extra 中的自定义声明写入 token, 自定义声明不能覆盖标准声明.
+func SignWithClaims(identityKey string, extra map[string]any) (string, time.Time, error) {
 	// 计算过期时间
-	expireAt := time.Now().Add(config.expiration)
+	now := time.Now()
+	expireAt := now.Add(config.expiration)
 
 	// Token 内容
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		config.identityKey: identityKey,       // 存放用户身份
-		"nbf":              time.Now().Unix(), // token 生效时间
-		"iat":              time.Now().Unix(), // token 签发时间
-		"exp":              time.Now().Unix(), // token 过期时间
-	})
-	if config.key == "" {
-		return "", time.Time{}, jwt.ErrInvalidKey
+	claims := jwt.MapClaims{
+		config.identityKey: identityKey,         // 存放用户身份
+		"jti":              uuid.New().String(), // token 唯一标识, 用于吊销 token
+		"nbf":              now.Unix(),          // token 生效时间
+		"iat":              now.Unix(),          // token 签发时间
+		issuedAtMsKey:      now.UnixMilli(),     // 毫秒精度的 token 签发时间
+		"exp":              expireAt.Unix(),     // token 过期时间
+	}
+	for k, v := range extra {
+		if _, ok := claims[k]; !ok {
+			claims[k] = v
+		}
+	}
+
+	// 配置了非对称密钥时使用私钥签发, 并在头部写入 kid; 否则使用 HS256 和共享密钥签发
+	var token *jwt.Token
+	var signKey any
+	if signingKey != nil {
+		token = jwt.NewWithClaims(signingKey.Method, claims)
+		token.Header["kid"] = signingKey.ID
+		signKey = signingKey.privateKey
+	} else {
+		if config.key == "" {
+			return "", time.Time{}, jwt.ErrInvalidKey
+		}
+		token = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		signKey = []byte(config.key)
 	}
 
 	// 签发 token
-	tokenString, err := token.SignedString([]byte(config.key))
+	tokenString, err := token.SignedString(signKey)
 	if err != nil {
 		return "", time.Time{}, err
 	}
 
 	return tokenString, expireAt, nil
 }
+
+// Expiration 返回签发 token 的过期时间.
+func Expiration() time.Duration {
+	return config.expiration
+}
+
+// SignRefreshToken 签发一个不透明的 refresh token.
+// 返回 refresh token 明文、用于持久化的哈希值以及过期时间. 服务端只保存哈希值, 明文只返回给客户端.
+func SignRefreshToken() (string, string, time.Time, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	refreshToken := base64.RawURLEncoding.EncodeToString(b)
+	return refreshToken, HashRefreshToken(refreshToken), time.Now().Add(config.refreshExpiration), nil
+}
+
+// HashRefreshToken 计算 refresh token 的哈希值, 用于查询持久化的 refresh token.
+func HashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
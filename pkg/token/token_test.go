@@ -0,0 +1,52 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+func TestSignWithClaims(t *testing.T) {
+	start := time.Now().Truncate(time.Millisecond)
+	tokenString, _, err := SignWithClaims("user-a", map[string]any{"tenantID": "acme", "iat": 0})
+	if err != nil {
+		t.Fatalf("SignWithClaims() error = %v", err)
+	}
+
+	claims, err := ParseClaims(tokenString, config.key)
+	if err != nil {
+		t.Fatalf("ParseClaims() error = %v", err)
+	}
+	// 签发时间精确到毫秒, 自定义声明不能覆盖标准声明
+	if claims.IssuedAt.Before(start) || claims.IssuedAt.After(time.Now()) || claims.IssuedAt.Nanosecond()%int(time.Millisecond) != 0 {
+		t.Errorf("IssuedAt = %v, want a millisecond after %v", claims.IssuedAt, start)
+	}
+	if claims.Identity != "user-a" || claims.Extra["tenantID"] != "acme" {
+		t.Errorf("ParseClaims() = %+v", claims)
+	}
+	if _, ok := claims.Extra[issuedAtMsKey]; ok {
+		t.Errorf("Extra contains %s", issuedAtMsKey)
+	}
+}
+
+func TestParseClaimsWithoutMilliseconds(t *testing.T) {
+	// 不带 iat_ms 的 token 使用精确到秒的 iat 作为签发时间
+	iat := time.Now().Add(-time.Minute).Unix()
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		config.identityKey: "user-a",
+		"iat":              iat,
+		"exp":              time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(config.key))
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+
+	claims, err := ParseClaims(tokenString, config.key)
+	if err != nil {
+		t.Fatalf("ParseClaims() error = %v", err)
+	}
+	if !claims.IssuedAt.Equal(time.Unix(iat, 0)) {
+		t.Errorf("IssuedAt = %v, want %v", claims.IssuedAt, time.Unix(iat, 0))
+	}
+}